@@ -0,0 +1,584 @@
+package kazooapi
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/sashker/kazoo-go/number"
+)
+
+var (
+	//ErrInvalidAccountID is returned by Account for ids which don't look like Kazoo ids
+	ErrInvalidAccountID = NewError("InvalidAccountID", "account id must be 32 lowercase hex symbols", nil)
+
+	accountIDPattern = regexp.MustCompile("^[0-9a-f]{32}$")
+)
+
+//AccountScope is a set of services bound to a single account,
+//so account id doesn't have to be passed to every call:
+//
+//	acc, err := client.Account("4dee5c1bef3ace50911c9917c50c9f80")
+//	users, err := acc.Users.List(ctx, false)
+type AccountScope struct {
+	ID string
+
+	Callflows    *AccountCallflowsService
+	Devices      *AccountDevicesService
+	Users        *AccountUsersService
+	PhoneNumbers *AccountPhoneNumbersService
+	Limits       *AccountLimitsService
+	Storage      *AccountStorageService
+	Clicktocall  *AccountClicktocallService
+	AppsStore    *AccountAppsStoreService
+	Recordings   *AccountRecordingsService
+	Channels     *AccountChannelsService
+	Metaflows    *AccountMetaflowsService
+
+	client *APIClient
+}
+
+type accountService struct {
+	acc    string
+	client *APIClient
+}
+
+type (
+	AccountCallflowsService    accountService
+	AccountDevicesService      accountService
+	AccountUsersService        accountService
+	AccountPhoneNumbersService accountService
+	AccountLimitsService       accountService
+	AccountStorageService      accountService
+	AccountClicktocallService  accountService
+	AccountAppsStoreService    accountService
+	AccountRecordingsService   accountService
+	AccountChannelsService     accountService
+	AccountMetaflowsService    accountService
+)
+
+//Account returns services bound to the account with the given id
+func (c *APIClient) Account(id string) (*AccountScope, error) {
+	if !accountIDPattern.MatchString(id) {
+		return nil, NewError(ErrInvalidAccountID.Code(), ErrInvalidAccountID.Message()+": "+id, ErrInvalidAccountID)
+	}
+
+	svc := accountService{acc: id, client: c}
+
+	return &AccountScope{
+		ID:     id,
+		client: c,
+
+		Callflows:    (*AccountCallflowsService)(&svc),
+		Devices:      (*AccountDevicesService)(&svc),
+		Users:        (*AccountUsersService)(&svc),
+		PhoneNumbers: (*AccountPhoneNumbersService)(&svc),
+		Limits:       (*AccountLimitsService)(&svc),
+		Storage:      (*AccountStorageService)(&svc),
+		Clicktocall:  (*AccountClicktocallService)(&svc),
+		AppsStore:    (*AccountAppsStoreService)(&svc),
+		Recordings:   (*AccountRecordingsService)(&svc),
+		Channels:     (*AccountChannelsService)(&svc),
+		Metaflows:    (*AccountMetaflowsService)(&svc),
+	}, nil
+}
+
+//Get calls AccountsAPIService.GetAccount for the account
+func (a *AccountScope) Get(ctx context.Context) (*Account, error) {
+	return a.client.AccountsAPI.GetAccount(ctx, a.ID)
+}
+
+//Update calls AccountsAPIService.UpdateAccount for the account
+func (a *AccountScope) Update(ctx context.Context, input *Account) (*Account, error) {
+	return a.client.AccountsAPI.UpdateAccount(ctx, a.ID, input)
+}
+
+//Patch calls AccountsAPIService.PatchAccount for the account
+func (a *AccountScope) Patch(ctx context.Context, input map[string]interface{}) (*Account, error) {
+	return a.client.AccountsAPI.PatchAccount(ctx, a.ID, input)
+}
+
+//Delete calls AccountsAPIService.DeleteAccount for the account
+func (a *AccountScope) Delete(ctx context.Context) error {
+	return a.client.AccountsAPI.DeleteAccount(ctx, a.ID)
+}
+
+//Modify calls AccountsAPIService.ModifyAccount for the account
+func (a *AccountScope) Modify(ctx context.Context, mutate func(acc *Account) (map[string]interface{}, error)) (*Account, error) {
+	return a.client.AccountsAPI.ModifyAccount(ctx, a.ID, mutate)
+}
+
+//ListChildren calls AccountsAPIService.ListChildren for the account
+func (a *AccountScope) ListChildren(ctx context.Context, disablePagination bool) ([]Child, error) {
+	return a.client.AccountsAPI.ListChildren(ctx, a.ID, disablePagination)
+}
+
+//ListDescendants calls AccountsAPIService.ListDescendants for the account
+func (a *AccountScope) ListDescendants(ctx context.Context, disablePagination bool) ([]Descendant, error) {
+	return a.client.AccountsAPI.ListDescendants(ctx, a.ID, disablePagination)
+}
+
+//ListChildrenPaginator calls AccountsAPIService.ListChildrenPaginator for the account
+func (a *AccountScope) ListChildrenPaginator(pageSize int64) *Paginator[Child] {
+	return a.client.AccountsAPI.ListChildrenPaginator(a.ID, pageSize)
+}
+
+//ListDescendantsPaginator calls AccountsAPIService.ListDescendantsPaginator for the account
+func (a *AccountScope) ListDescendantsPaginator(pageSize int64) *Paginator[Descendant] {
+	return a.client.AccountsAPI.ListDescendantsPaginator(a.ID, pageSize)
+}
+
+//Walk calls AccountsAPIService.Walk for the account
+func (a *AccountScope) Walk(ctx context.Context, opts *WalkOptions, fn WalkFunc) error {
+	return a.client.AccountsAPI.Walk(ctx, a.ID, opts, fn)
+}
+
+//Create calls CallflowsAPIService.CreateCallflow for the account
+func (s *AccountCallflowsService) Create(ctx context.Context, input *Callflow) (*Callflow, error) {
+	return s.client.CallflowsAPI.CreateCallflow(ctx, s.acc, input)
+}
+
+//List calls CallflowsAPIService.ListCallflows for the account
+func (s *AccountCallflowsService) List(ctx context.Context, disablePagination bool) ([]Callflow, error) {
+	return s.client.CallflowsAPI.ListCallflows(ctx, s.acc, disablePagination)
+}
+
+//ListPaginator calls CallflowsAPIService.ListCallflowsPaginator for the account
+func (s *AccountCallflowsService) ListPaginator(pageSize int64) *Paginator[Callflow] {
+	return s.client.CallflowsAPI.ListCallflowsPaginator(s.acc, pageSize)
+}
+
+//Get calls CallflowsAPIService.GetCallflow for the account
+func (s *AccountCallflowsService) Get(ctx context.Context, id string) (*Callflow, error) {
+	return s.client.CallflowsAPI.GetCallflow(ctx, s.acc, id)
+}
+
+//Update calls CallflowsAPIService.UpdateCallflow for the account
+func (s *AccountCallflowsService) Update(ctx context.Context, id string, input *Callflow) (*Callflow, error) {
+	return s.client.CallflowsAPI.UpdateCallflow(ctx, s.acc, id, input)
+}
+
+//Patch calls CallflowsAPIService.PatchCallflow for the account
+func (s *AccountCallflowsService) Patch(ctx context.Context, id string, input map[string]interface{}) (*Callflow, error) {
+	return s.client.CallflowsAPI.PatchCallflow(ctx, s.acc, id, input)
+}
+
+//Delete calls CallflowsAPIService.DeleteCallflow for the account
+func (s *AccountCallflowsService) Delete(ctx context.Context, id string) (*Callflow, error) {
+	return s.client.CallflowsAPI.DeleteCallflow(ctx, s.acc, id)
+}
+
+//Create calls DevicesAPIService.CreateDevice for the account
+func (s *AccountDevicesService) Create(ctx context.Context, input *Device) (*Device, error) {
+	return s.client.DevicesAPI.CreateDevice(ctx, s.acc, input)
+}
+
+//List calls DevicesAPIService.ListDevices for the account
+func (s *AccountDevicesService) List(ctx context.Context, disablePagination bool) ([]Device, error) {
+	return s.client.DevicesAPI.ListDevices(ctx, s.acc, disablePagination)
+}
+
+//ListPaginator calls DevicesAPIService.ListDevicesPaginator for the account
+func (s *AccountDevicesService) ListPaginator(pageSize int64) *Paginator[Device] {
+	return s.client.DevicesAPI.ListDevicesPaginator(s.acc, pageSize)
+}
+
+//Get calls DevicesAPIService.GetDevice for the account
+func (s *AccountDevicesService) Get(ctx context.Context, id string) (*Device, error) {
+	return s.client.DevicesAPI.GetDevice(ctx, s.acc, id)
+}
+
+//Update calls DevicesAPIService.UpdateDevice for the account
+func (s *AccountDevicesService) Update(ctx context.Context, id string, input *Device) (*Device, error) {
+	return s.client.DevicesAPI.UpdateDevice(ctx, s.acc, id, input)
+}
+
+//Patch calls DevicesAPIService.PatchDevice for the account
+func (s *AccountDevicesService) Patch(ctx context.Context, id string, input map[string]interface{}) (*Device, error) {
+	return s.client.DevicesAPI.PatchDevice(ctx, s.acc, id, input)
+}
+
+//Delete calls DevicesAPIService.DeleteDevice for the account
+func (s *AccountDevicesService) Delete(ctx context.Context, id string) (*Device, error) {
+	return s.client.DevicesAPI.DeleteDevice(ctx, s.acc, id)
+}
+
+//Create calls UsersAPIService.CreateUser for the account
+func (s *AccountUsersService) Create(ctx context.Context, input *User) (*User, error) {
+	return s.client.UsersAPI.CreateUser(ctx, s.acc, input)
+}
+
+//Delete calls UsersAPIService.DeleteUser for the account
+func (s *AccountUsersService) Delete(ctx context.Context, id string) (*User, error) {
+	return s.client.UsersAPI.DeleteUser(ctx, s.acc, id)
+}
+
+//List calls UsersAPIService.ListUsers for the account
+func (s *AccountUsersService) List(ctx context.Context, disablePagination bool) ([]User, error) {
+	return s.client.UsersAPI.ListUsers(ctx, s.acc, disablePagination)
+}
+
+//ListPaginator calls UsersAPIService.ListUsersPaginator for the account
+func (s *AccountUsersService) ListPaginator(pageSize int64) *Paginator[User] {
+	return s.client.UsersAPI.ListUsersPaginator(s.acc, pageSize)
+}
+
+//Get calls UsersAPIService.GetUser for the account
+func (s *AccountUsersService) Get(ctx context.Context, id string) (*User, error) {
+	return s.client.UsersAPI.GetUser(ctx, s.acc, id)
+}
+
+//Update calls UsersAPIService.UpdateUser for the account
+func (s *AccountUsersService) Update(ctx context.Context, id string, input *User) (*User, error) {
+	return s.client.UsersAPI.UpdateUser(ctx, s.acc, id, input)
+}
+
+//Patch calls UsersAPIService.PatchUser for the account
+func (s *AccountUsersService) Patch(ctx context.Context, id string, input map[string]interface{}) (*User, error) {
+	return s.client.UsersAPI.PatchUser(ctx, s.acc, id, input)
+}
+
+//Create calls PhoneNumbersAPIService.CreatePhoneNumber for the account
+func (s *AccountPhoneNumbersService) Create(ctx context.Context, num string) (*PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.CreatePhoneNumber(ctx, s.acc, num)
+}
+
+//Delete calls PhoneNumbersAPIService.DeletePhoneNumber for the account
+func (s *AccountPhoneNumbersService) Delete(ctx context.Context, num string, hard bool) (*PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.DeletePhoneNumber(ctx, s.acc, num, hard)
+}
+
+//List calls PhoneNumbersAPIService.ListPhoneNumbers for the account
+func (s *AccountPhoneNumbersService) List(ctx context.Context, disablePagination bool) ([]PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.ListPhoneNumbers(ctx, s.acc, disablePagination)
+}
+
+//ListPaginator calls PhoneNumbersAPIService.ListPhoneNumbersPaginator for the account
+func (s *AccountPhoneNumbersService) ListPaginator(pageSize int64) *Paginator[PhoneNumber] {
+	return s.client.PhoneNumbersAPI.ListPhoneNumbersPaginator(s.acc, pageSize)
+}
+
+//Get calls PhoneNumbersAPIService.GetPhoneNumber for the account
+func (s *AccountPhoneNumbersService) Get(ctx context.Context, num string) (*PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.GetPhoneNumber(ctx, s.acc, num)
+}
+
+//Update calls PhoneNumbersAPIService.UpdatePhoneNumber for the account
+func (s *AccountPhoneNumbersService) Update(ctx context.Context, num string, input map[string]interface{}) (*PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.UpdatePhoneNumber(ctx, s.acc, num, input)
+}
+
+//Patch calls PhoneNumbersAPIService.PatchPhoneNumber for the account
+func (s *AccountPhoneNumbersService) Patch(ctx context.Context, num string, input map[string]interface{}) (*PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.PatchPhoneNumber(ctx, s.acc, num, input)
+}
+
+//Search calls PhoneNumbersAPIService.SearchPhoneNumbers for the account
+func (s *AccountPhoneNumbersService) Search(ctx context.Context, search *NumberSearch) ([]NumberSearchResult, error) {
+	return s.client.PhoneNumbersAPI.SearchPhoneNumbers(ctx, s.acc, search)
+}
+
+//Reserve calls PhoneNumbersAPIService.ReservePhoneNumber for the account
+func (s *AccountPhoneNumbersService) Reserve(ctx context.Context, num string) (*PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.ReservePhoneNumber(ctx, s.acc, num)
+}
+
+//Activate calls PhoneNumbersAPIService.ActivatePhoneNumber for the account
+func (s *AccountPhoneNumbersService) Activate(ctx context.Context, num string) (*PhoneNumber, error) {
+	return s.client.PhoneNumbersAPI.ActivatePhoneNumber(ctx, s.acc, num)
+}
+
+//Check calls PhoneNumbersAPIService.CheckPhoneNumbers for the account
+func (s *AccountPhoneNumbersService) Check(ctx context.Context, nums []string) (map[string]string, error) {
+	return s.client.PhoneNumbersAPI.CheckPhoneNumbers(ctx, s.acc, nums)
+}
+
+//AddCollection calls PhoneNumbersAPIService.AddPhoneNumbers for the account
+func (s *AccountPhoneNumbersService) AddCollection(ctx context.Context, nums []string) (*PhoneNumbersCollection, error) {
+	return s.client.PhoneNumbersAPI.AddPhoneNumbers(ctx, s.acc, nums)
+}
+
+//DeleteCollection calls PhoneNumbersAPIService.DeletePhoneNumbers for the account
+func (s *AccountPhoneNumbersService) DeleteCollection(ctx context.Context, nums []string) (*PhoneNumbersCollection, error) {
+	return s.client.PhoneNumbersAPI.DeletePhoneNumbers(ctx, s.acc, nums)
+}
+
+//GetFeatures calls PhoneNumbersAPIService.GetPhoneNumberFeatures for the account
+func (s *AccountPhoneNumbersService) GetFeatures(ctx context.Context, num string) (*NumberFeatures, error) {
+	return s.client.PhoneNumbersAPI.GetPhoneNumberFeatures(ctx, s.acc, num)
+}
+
+//UpdateFeatures calls PhoneNumbersAPIService.UpdatePhoneNumberFeatures for the account
+func (s *AccountPhoneNumbersService) UpdateFeatures(ctx context.Context, num string, input *NumberFeatures) (*NumberFeatures, error) {
+	return s.client.PhoneNumbersAPI.UpdatePhoneNumberFeatures(ctx, s.acc, num, input)
+}
+
+//Import calls PhoneNumbersAPIService.ImportNumbers, rows without account are imported to the account
+func (s *AccountPhoneNumbersService) Import(ctx context.Context, rows []NumberImportRow, opts *NumberImportOptions) ([]NumberImportResult, error) {
+	scoped := NumberImportOptions{}
+	if opts != nil {
+		scoped = *opts
+	}
+	scoped.Account = s.acc
+
+	return s.client.PhoneNumbersAPI.ImportNumbers(ctx, rows, &scoped)
+}
+
+//Classifiers calls PhoneNumbersAPIService.GetNumberClassifiers for the account
+func (s *AccountPhoneNumbersService) Classifiers(ctx context.Context) (number.Classifiers, error) {
+	return s.client.PhoneNumbersAPI.GetNumberClassifiers(ctx, s.acc)
+}
+
+//Get calls LimitsAPIService.GetLimits for the account
+func (s *AccountLimitsService) Get(ctx context.Context) (*Limits, error) {
+	return s.client.LimitsAPI.GetLimits(ctx, s.acc)
+}
+
+//Update calls LimitsAPIService.UpdateLimits for the account
+func (s *AccountLimitsService) Update(ctx context.Context, input *Limits) (*Limits, error) {
+	return s.client.LimitsAPI.UpdateLimits(ctx, s.acc, input)
+}
+
+//Patch calls LimitsAPIService.PatchLimits for the account
+func (s *AccountLimitsService) Patch(ctx context.Context, input map[string]interface{}) (*Limits, error) {
+	return s.client.LimitsAPI.PatchLimits(ctx, s.acc, input)
+}
+
+//Modify calls LimitsAPIService.ModifyLimits for the account
+func (s *AccountLimitsService) Modify(ctx context.Context, mutate func(limits *Limits) error) (*Limits, error) {
+	return s.client.LimitsAPI.ModifyLimits(ctx, s.acc, mutate)
+}
+
+//Get calls StorageAPIService.GetStorage for the account
+func (s *AccountStorageService) Get(ctx context.Context) (*Storage, error) {
+	return s.client.StorageAPI.GetStorage(ctx, s.acc)
+}
+
+//Create calls StorageAPIService.CreateStorage for the account
+func (s *AccountStorageService) Create(ctx context.Context, input *Storage) (*Storage, error) {
+	return s.client.StorageAPI.CreateStorage(ctx, s.acc, input)
+}
+
+//Delete calls StorageAPIService.DeleteStorage for the account
+func (s *AccountStorageService) Delete(ctx context.Context) (*Storage, error) {
+	return s.client.StorageAPI.DeleteStorage(ctx, s.acc)
+}
+
+//Update calls StorageAPIService.UpdateStorage for the account
+func (s *AccountStorageService) Update(ctx context.Context, input *Storage) (*Storage, error) {
+	return s.client.StorageAPI.UpdateStorage(ctx, s.acc, input)
+}
+
+//Patch calls StorageAPIService.PatchStorage for the account
+func (s *AccountStorageService) Patch(ctx context.Context, input map[string]interface{}) (*Storage, error) {
+	return s.client.StorageAPI.PatchStorage(ctx, s.acc, input)
+}
+
+//ListPlans calls StorageAPIService.ListStoragePlans for the account
+func (s *AccountStorageService) ListPlans(ctx context.Context) ([]StoragePlan, error) {
+	return s.client.StorageAPI.ListStoragePlans(ctx, s.acc)
+}
+
+//CreatePlan calls StorageAPIService.CreateStoragePlan for the account
+func (s *AccountStorageService) CreatePlan(ctx context.Context, input *StoragePlan) (*StoragePlan, error) {
+	return s.client.StorageAPI.CreateStoragePlan(ctx, s.acc, input)
+}
+
+//GetPlan calls StorageAPIService.GetStoragePlan for the account
+func (s *AccountStorageService) GetPlan(ctx context.Context, id string) (*StoragePlan, error) {
+	return s.client.StorageAPI.GetStoragePlan(ctx, s.acc, id)
+}
+
+//UpdatePlan calls StorageAPIService.UpdateStoragePlan for the account
+func (s *AccountStorageService) UpdatePlan(ctx context.Context, id string, input *StoragePlan) (*StoragePlan, error) {
+	return s.client.StorageAPI.UpdateStoragePlan(ctx, s.acc, id, input)
+}
+
+//PatchPlan calls StorageAPIService.PatchStoragePlan for the account
+func (s *AccountStorageService) PatchPlan(ctx context.Context, id string, input map[string]interface{}) (*StoragePlan, error) {
+	return s.client.StorageAPI.PatchStoragePlan(ctx, s.acc, id, input)
+}
+
+//DeletePlan calls StorageAPIService.DeleteStoragePlan for the account
+func (s *AccountStorageService) DeletePlan(ctx context.Context, id string) (*StoragePlan, error) {
+	return s.client.StorageAPI.DeleteStoragePlan(ctx, s.acc, id)
+}
+
+//Get calls ClicktocallAPIService.GetClicktocall for the account
+func (s *AccountClicktocallService) Get(ctx context.Context, id string) (*Clicktocall, error) {
+	return s.client.ClicktocallAPI.GetClicktocall(ctx, s.acc, id)
+}
+
+//Create calls ClicktocallAPIService.CreateClicktocall for the account
+func (s *AccountClicktocallService) Create(ctx context.Context, input *Clicktocall) (*Clicktocall, error) {
+	return s.client.ClicktocallAPI.CreateClicktocall(ctx, s.acc, input)
+}
+
+//List calls ClicktocallAPIService.ListClick2Calls for the account
+func (s *AccountClicktocallService) List(ctx context.Context, disablePagination bool) ([]Clicktocall, error) {
+	return s.client.ClicktocallAPI.ListClick2Calls(ctx, s.acc, disablePagination)
+}
+
+//Execute calls ClicktocallAPIService.ExecuteClicktocall for the account
+func (s *AccountClicktocallService) Execute(ctx context.Context, id, contact string) (*ClicktocallExecuteResponse, error) {
+	return s.client.ClicktocallAPI.ExecuteClicktocall(ctx, s.acc, id, contact)
+}
+
+//Delete calls ClicktocallAPIService.DeleteClicktocall for the account
+func (s *AccountClicktocallService) Delete(ctx context.Context, id string) (*Clicktocall, error) {
+	return s.client.ClicktocallAPI.DeleteClicktocall(ctx, s.acc, id)
+}
+
+//ListPaginator calls ClicktocallAPIService.ListClick2CallsPaginator for the account
+func (s *AccountClicktocallService) ListPaginator(pageSize int64) *Paginator[Clicktocall] {
+	return s.client.ClicktocallAPI.ListClick2CallsPaginator(s.acc, pageSize)
+}
+
+//Modify calls ClicktocallAPIService.ModifyClicktocall for the account
+func (s *AccountClicktocallService) Modify(ctx context.Context, id string, mutate func(c2c *Clicktocall) error) (*Clicktocall, error) {
+	return s.client.ClicktocallAPI.ModifyClicktocall(ctx, s.acc, id, mutate)
+}
+
+//Update calls ClicktocallAPIService.UpdateClicktocall for the account
+func (s *AccountClicktocallService) Update(ctx context.Context, id string, input *Clicktocall) (*Clicktocall, error) {
+	return s.client.ClicktocallAPI.UpdateClicktocall(ctx, s.acc, id, input)
+}
+
+//Patch calls ClicktocallAPIService.PatchClicktocall for the account
+func (s *AccountClicktocallService) Patch(ctx context.Context, id string, input map[string]interface{}) (*Clicktocall, error) {
+	return s.client.ClicktocallAPI.PatchClicktocall(ctx, s.acc, id, input)
+}
+
+//Install calls AppsStoreAPIService.InstallApp for the account
+func (s *AccountAppsStoreService) Install(ctx context.Context, appID string, input *InstallAppInput) (*InstallAppOutput, error) {
+	return s.client.AppsStoreAPI.InstallApp(ctx, s.acc, appID, input)
+}
+
+//List calls AppsStoreAPIService.ListApps for the account
+func (s *AccountAppsStoreService) List(ctx context.Context) ([]App, error) {
+	return s.client.AppsStoreAPI.ListApps(ctx, s.acc)
+}
+
+//Get calls AppsStoreAPIService.GetApp for the account
+func (s *AccountAppsStoreService) Get(ctx context.Context, appID string) (*App, error) {
+	return s.client.AppsStoreAPI.GetApp(ctx, s.acc, appID)
+}
+
+//Update calls AppsStoreAPIService.UpdateApp for the account
+func (s *AccountAppsStoreService) Update(ctx context.Context, appID string, input *InstallAppInput) (*InstallAppOutput, error) {
+	return s.client.AppsStoreAPI.UpdateApp(ctx, s.acc, appID, input)
+}
+
+//Uninstall calls AppsStoreAPIService.UninstallApp for the account
+func (s *AccountAppsStoreService) Uninstall(ctx context.Context, appID string) (*InstallAppOutput, error) {
+	return s.client.AppsStoreAPI.UninstallApp(ctx, s.acc, appID)
+}
+
+//List calls RecordingsAPIService.ListRecordings for the account
+func (s *AccountRecordingsService) List(ctx context.Context) ([]Recording, error) {
+	return s.client.RecordingsAPI.ListRecordings(ctx, s.acc)
+}
+
+//Get calls RecordingsAPIService.GetRecording for the account
+func (s *AccountRecordingsService) Get(ctx context.Context, recording string) (*Recording, error) {
+	return s.client.RecordingsAPI.GetRecording(ctx, s.acc, recording)
+}
+
+//ListPaginator calls RecordingsAPIService.ListRecordingsPaginator for the account
+func (s *AccountRecordingsService) ListPaginator(pageSize int64) *Paginator[Recording] {
+	return s.client.RecordingsAPI.ListRecordingsPaginator(s.acc, pageSize)
+}
+
+//Delete calls RecordingsAPIService.DeleteRecording for the account
+func (s *AccountRecordingsService) Delete(ctx context.Context, recording string) (*Recording, error) {
+	return s.client.RecordingsAPI.DeleteRecording(ctx, s.acc, recording)
+}
+
+//List calls ChannelsAPIService.ListAccountChannels for the account
+func (s *AccountChannelsService) List(ctx context.Context) ([]Channel, error) {
+	return s.client.ChannelsAPI.ListAccountChannels(ctx, s.acc)
+}
+
+//Get calls ChannelsAPIService.GetChannel for the account
+func (s *AccountChannelsService) Get(ctx context.Context, uuid string) (*Channel, error) {
+	return s.client.ChannelsAPI.GetChannel(ctx, s.acc, uuid)
+}
+
+//Hangup calls ChannelsAPIService.HangupChannel for the account
+func (s *AccountChannelsService) Hangup(ctx context.Context, uuid string) error {
+	return s.client.ChannelsAPI.HangupChannel(ctx, s.acc, uuid)
+}
+
+//Transfer calls ChannelsAPIService.TransferChannel for the account
+func (s *AccountChannelsService) Transfer(ctx context.Context, uuid string, input *ChannelTransfer) error {
+	return s.client.ChannelsAPI.TransferChannel(ctx, s.acc, uuid, input)
+}
+
+//Hold calls ChannelsAPIService.HoldChannel for the account
+func (s *AccountChannelsService) Hold(ctx context.Context, uuid, moh string) error {
+	return s.client.ChannelsAPI.HoldChannel(ctx, s.acc, uuid, moh)
+}
+
+//Unhold calls ChannelsAPIService.UnholdChannel for the account
+func (s *AccountChannelsService) Unhold(ctx context.Context, uuid string) error {
+	return s.client.ChannelsAPI.UnholdChannel(ctx, s.acc, uuid)
+}
+
+//Break calls ChannelsAPIService.BreakChannel for the account
+func (s *AccountChannelsService) Break(ctx context.Context, uuid string) error {
+	return s.client.ChannelsAPI.BreakChannel(ctx, s.acc, uuid)
+}
+
+//Eavesdrop calls ChannelsAPIService.EavesdropChannel for the account
+func (s *AccountChannelsService) Eavesdrop(ctx context.Context, uuid string, input *ChannelEavesdrop) error {
+	return s.client.ChannelsAPI.EavesdropChannel(ctx, s.acc, uuid, input)
+}
+
+//Intercept calls ChannelsAPIService.InterceptChannel for the account
+func (s *AccountChannelsService) Intercept(ctx context.Context, uuid string, input *ChannelIntercept) error {
+	return s.client.ChannelsAPI.InterceptChannel(ctx, s.acc, uuid, input)
+}
+
+//Metaflow calls ChannelsAPIService.MetaflowChannel for the account
+func (s *AccountChannelsService) Metaflow(ctx context.Context, uuid string, action MetaflowAction) error {
+	return s.client.ChannelsAPI.MetaflowChannel(ctx, s.acc, uuid, action)
+}
+
+//Watcher calls ChannelsAPIService.NewChannelWatcher for the account
+func (s *AccountChannelsService) Watcher(opts *ChannelWatcherOptions) *ChannelWatcher {
+	return s.client.ChannelsAPI.NewChannelWatcher(s.acc, opts)
+}
+
+//Get calls MetaflowsAPIService.GetMetaflows for the account
+func (s *AccountMetaflowsService) Get(ctx context.Context) (*Metaflow, error) {
+	return s.client.MetaflowsAPI.GetMetaflows(ctx, s.acc)
+}
+
+//Update calls MetaflowsAPIService.UpdateMetaflows for the account
+func (s *AccountMetaflowsService) Update(ctx context.Context, input *Metaflow) (*Metaflow, error) {
+	return s.client.MetaflowsAPI.UpdateMetaflows(ctx, s.acc, input)
+}
+
+//Delete calls MetaflowsAPIService.DeleteMetaflows for the account
+func (s *AccountMetaflowsService) Delete(ctx context.Context) (*Metaflow, error) {
+	return s.client.MetaflowsAPI.DeleteMetaflows(ctx, s.acc)
+}
+
+//GetUser calls MetaflowsAPIService.GetUserMetaflows for the account
+func (s *AccountMetaflowsService) GetUser(ctx context.Context, id string) (*Metaflow, error) {
+	return s.client.MetaflowsAPI.GetUserMetaflows(ctx, s.acc, id)
+}
+
+//UpdateUser calls MetaflowsAPIService.UpdateUserMetaflows for the account
+func (s *AccountMetaflowsService) UpdateUser(ctx context.Context, id string, input *Metaflow) (*Metaflow, error) {
+	return s.client.MetaflowsAPI.UpdateUserMetaflows(ctx, s.acc, id, input)
+}
+
+//GetDevice calls MetaflowsAPIService.GetDeviceMetaflows for the account
+func (s *AccountMetaflowsService) GetDevice(ctx context.Context, id string) (*Metaflow, error) {
+	return s.client.MetaflowsAPI.GetDeviceMetaflows(ctx, s.acc, id)
+}
+
+//UpdateDevice calls MetaflowsAPIService.UpdateDeviceMetaflows for the account
+func (s *AccountMetaflowsService) UpdateDevice(ctx context.Context, id string, input *Metaflow) (*Metaflow, error) {
+	return s.client.MetaflowsAPI.UpdateDeviceMetaflows(ctx, s.acc, id, input)
+}
@@ -0,0 +1,85 @@
+package kazooapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIClient_Account(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4","first_name":"John","last_name":"Doe"}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	for _, id := range []string{"", "4DEE5C1BEF3ACE50911C9917C50C9F80", "4dee5c1bef3ace50911c9917c50c9f8", "qe0ade400015367f0069d6dfbdca072a", "../../system_config/crossbar_00"} {
+		_, err := clt.Account(id)
+		assert.True(t, errors.Is(err, kazooapi.ErrInvalidAccountID), id)
+	}
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	usr, err := acc.Users.Get(ctx, "4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4")
+	assert.NoError(t, err)
+	assert.Equal(t, "John", usr.FirstName)
+	assertRequest(t, last(), "GET", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/users/4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4")
+
+	_, err = acc.Callflows.Patch(ctx, "9e1e5f9031e9e8446f54da9df47680a0", map[string]interface{}{"name": "Main"})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/callflows/9e1e5f9031e9e8446f54da9df47680a0")
+
+	_, err = acc.PhoneNumbers.Delete(ctx, "+74955555555", true)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "DELETE", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers/+74955555555")
+	assert.Equal(t, "hard=true", last().Query)
+
+	_, err = acc.Limits.Patch(ctx, map[string]interface{}{"twoway_trunks": 5})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/limits")
+
+	_, err = acc.Storage.GetPlan(ctx, "7e0e9ebd2e8b4b3d0b5f1c8a9d6e4f21")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/storage/plans/7e0e9ebd2e8b4b3d0b5f1c8a9d6e4f21")
+
+	_, err = acc.Get(ctx)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80")
+}
+
+func TestAPIClient_PathEscaping(t *testing.T) {
+	ctx := context.Background()
+
+	var escaped string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		escaped = r.URL.EscapedPath()
+		io.WriteString(w, `{"data":{},"status":"success"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	acc, err := newMockClient(t, srv).Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	//A crafted id can't escape the collection it's used in
+	_, err = acc.Users.Get(ctx, "../devices/x?y")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/users/..%2Fdevices%2Fx%3Fy", escaped)
+
+	_, err = acc.PhoneNumbers.Get(ctx, "+1 555 0100")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers/+1%20555%200100", escaped)
+}
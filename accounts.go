@@ -2,8 +2,6 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 )
 
 type AccountsAPIService service
@@ -47,293 +45,179 @@ type (
 )
 
 func (api *AccountsAPIService) GetAccount(ctx context.Context, id string) (acc *Account, err error) {
-	var response struct {
-		Data Account `json:"data"`
-		ResponseEnvelope
-	}
-
 	if id == "" {
 		return nil, reportError("account id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + id,
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
-
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
-	}
-
-	acc = &response.Data
+	acc, _, err = do[*Account](ctx, api.client, endpoint{
+		service:   "AccountsAPI",
+		operation: "GetAccount",
+		method:    "GET",
+		path:      path("accounts", id),
+	})
 
-	return acc, nil
+	return acc, err
 }
 
 func (api *AccountsAPIService) DeleteAccount(ctx context.Context, id string) (err error) {
-	var response struct {
-		Data Account `json:"data"`
-		ResponseEnvelope
-	}
-
-	if id == "" {
-		return reportError("account id is required field")
-	}
-
-	params := Request{
-		CTX:    ctx,
-		Method: "DELETE",
-		Path:   api.client.cfg.BasePath + "/accounts/" + id,
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return err
-	}
-	defer resp.Body.Close()
+	_, err = api.deleteAccount(ctx, "DeleteAccount", id)
+	return err
+}
 
-	err = readBody(resp, &response)
-	if err != nil {
-		return reportError("Can't decode response: %v", err)
-	}
+//DeleteAccountDocument deletes the account like DeleteAccount and returns the deleted document
+func (api *AccountsAPIService) DeleteAccountDocument(ctx context.Context, id string) (acc *Account, err error) {
+	return api.deleteAccount(ctx, "DeleteAccountDocument", id)
+}
 
-	if resp.StatusCode >= 300 {
-		return prepareError(resp)
+func (api *AccountsAPIService) deleteAccount(ctx context.Context, operation, id string) (acc *Account, err error) {
+	if id == "" {
+		return nil, reportError("account id is required field")
 	}
 
+	acc, _, err = do[*Account](ctx, api.client, endpoint{
+		service:   "AccountsAPI",
+		operation: operation,
+		method:    "DELETE",
+		path:      path("accounts", id),
+	})
 
-	return nil
+	return acc, err
 }
 
 func (api *AccountsAPIService) CreateAccount(ctx context.Context, input *Account) (acc *Account, err error) {
-	var (
-		response struct {
-			Data Account `json:"data"`
-			ResponseEnvelope
-		}
-	)
-
 	if input.Name == "" {
 		return nil, reportError("account name is required field")
 	}
 
-	/*if input.Realm == "" {
-		return nil, reportError("realm is required field")
-	}*/
-
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts",
-	}
-
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
-
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
-	}
-
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
-	}
-
-	params.PostBody = body
+	acc, _, err = do[*Account](ctx, api.client, endpoint{
+		service:   "AccountsAPI",
+		operation: "CreateAccount",
+		method:    "PUT",
+		path:      path("accounts"),
+		body:      input,
+	})
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
-	}
-
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
-
-	acc = &response.Data
-
-	return acc, nil
+	return acc, err
 }
 
 //ChangeAccount enables to PATCH an existing account document
+//
+//Deprecated: use PatchAccount
 func (api *AccountsAPIService) ChangeAccount(ctx context.Context, id string, input map[string]interface{}) (acc *Account, err error) {
-	var (
-		response struct {
-			Data Account `json:"data"`
-			ResponseEnvelope
-		}
-	)
+	return api.PatchAccount(ctx, id, input)
+}
 
+//PatchAccount merges input into the account document leaving other fields untouched
+func (api *AccountsAPIService) PatchAccount(ctx context.Context, id string, input map[string]interface{}) (acc *Account, err error) {
 	if id == "" || len(id) != 32 {
 		return nil, reportError("specify correct account id")
 	}
 
-	/*if input.Realm == "" {
-		return nil, reportError("realm is required field")
-	}*/
-
-	params := Request{
-		CTX:    ctx,
-		Method: "PATCH",
-		Path:   api.client.cfg.BasePath + "/accounts/" + id,
-	}
-
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
-
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
-	}
-
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
-	}
-
-	params.PostBody = body
+	acc, _, err = do[*Account](ctx, api.client, endpoint{
+		service:   "AccountsAPI",
+		operation: "PatchAccount",
+		method:    "PATCH",
+		path:      path("accounts", id),
+		body:      input,
+	})
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return acc, err
+}
 
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
+//UpdateAccount replaces the account document with input,
+//fields missing in input are removed from the document
+func (api *AccountsAPIService) UpdateAccount(ctx context.Context, id string, input *Account) (acc *Account, err error) {
+	if id == "" || len(id) != 32 {
+		return nil, reportError("specify correct account id")
 	}
 
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
+	if input.Name == "" {
+		return nil, reportError("account name is required field")
 	}
 
-	acc = &response.Data
+	acc, _, err = do[*Account](ctx, api.client, endpoint{
+		service:   "AccountsAPI",
+		operation: "UpdateAccount",
+		method:    "POST",
+		path:      path("accounts", id),
+		body:      input,
+	})
 
-	return acc, nil
+	return acc, err
 }
 
 func (api *AccountsAPIService) ListChildren(ctx context.Context, acc string, disablePagination bool) (chldrn []Child, err error) {
-	var response struct {
-		Data []Child `json:"data"`
-		ResponseEnvelope
-	}
-
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/children",
-	}
-
-	if disablePagination {
-		params.QueryParams = map[string][]string{"paginate": []string{"false"}}
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
-	}
-
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
+	chldrn, _, err = do[[]Child](ctx, api.client, endpoint{
+		service:   "AccountsAPI",
+		operation: "ListChildren",
+		method:    "GET",
+		path:      path("accounts", acc, "children"),
+		query:     listQuery(disablePagination),
+	})
+
+	return chldrn, err
+}
 
-	chldrn = response.Data
+func (api *AccountsAPIService) ListDescendants(ctx context.Context, acc string, disablePagination bool) (chldrn []Descendant, err error) {
+	chldrn, _, err = do[[]Descendant](ctx, api.client, endpoint{
+		service:   "AccountsAPI",
+		operation: "ListDescendants",
+		method:    "GET",
+		path:      path("accounts", acc, "descendants"),
+		query:     listQuery(disablePagination),
+	})
+
+	return chldrn, err
+}
 
-	return chldrn, nil
+//ListChildrenPaginator returns a paginator over children of the account,
+//zero pageSize means the server's default page size
+func (api *AccountsAPIService) ListChildrenPaginator(acc string, pageSize int64) *Paginator[Child] {
+	return listPaginator[Child](api.client, "AccountsAPI", "ListChildrenPaginator", path("accounts", acc, "children"), pageSize)
 }
 
-func (api *AccountsAPIService) ListDescendants(ctx context.Context, acc string, disablePagination bool) (chldrn []Descendant, err error) {
-	var response struct {
-		Data []Descendant `json:"data"`
-		ResponseEnvelope
-	}
+//ListDescendantsPaginator returns a paginator over descendants of the account,
+//zero pageSize means the server's default page size
+func (api *AccountsAPIService) ListDescendantsPaginator(acc string, pageSize int64) *Paginator[Descendant] {
+	return listPaginator[Descendant](api.client, "AccountsAPI", "ListDescendantsPaginator", path("accounts", acc, "descendants"), pageSize)
+}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/descendants",
-	}
+//ModifyAccount fetches the account, asks mutate for a patch and applies it
+//only if the account hasn't been changed since it was fetched. On conflict
+//the whole round is repeated with the fresh document up to DefaultConflictAttempts times.
+//mutate returning nil patch leaves the account untouched, an account fetched without a revision
+//isn't patched and ErrNoRevision is returned
+func (api *AccountsAPIService) ModifyAccount(ctx context.Context, id string, mutate func(acc *Account) (map[string]interface{}, error)) (acc *Account, err error) {
+	err = RetryOnConflict(ctx, DefaultConflictAttempts, func(ctx context.Context) error {
+		var meta ResponseMeta
+
+		current, err := api.GetAccount(WithResponseMeta(ctx, &meta), id)
+		if err != nil {
+			return err
+		}
 
-	if disablePagination {
-		params.QueryParams = map[string][]string{"paginate": []string{"false"}}
-	}
+		patch, err := mutate(current)
+		if err != nil {
+			return err
+		}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+		if patch == nil {
+			acc = current
+			return nil
+		}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+		rev, err := documentRevision(meta)
+		if err != nil {
+			return err
+		}
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
-	}
+		acc, err = api.PatchAccount(WithRevision(ctx, rev), id, patch)
+		return err
+	})
 
-	err = readBody(resp, &response)
 	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
+		return nil, err
 	}
 
-	chldrn = response.Data
-
-	return chldrn, nil
+	return acc, nil
 }
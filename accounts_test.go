@@ -372,17 +372,10 @@ func TestAccountsAPIService_ChangeAccount(t *testing.T) {
 
 func Test_EnableCallRecording_Success(t *testing.T) {
 	ctx := context.Background()
-	cfg := kazooapi.NewConfiguration()
-	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
-	cfg.BasePath = "http://kazoo2.hz.sip3.net:8000" + "/v2"
-	//cfg.BasePath = srv.URL + "/accounts/qe0ade400015367f0069d6dfbdca072a"
-
-	clt, err := kazooapi.NewAPIClient(cfg)
-	if err != nil {
-		t.Error("Can't create the API client")
-	}
 
-	//input := &kazooapi.Account{ID: "qe0ade400015367f0069d6dfbdca072a"}
+	srv, last := MockDocumentServer(t, `{"id":"4dee5c1bef3ace50911c9917c50c9f80","name":"1002","call_recording":{"account":{"any":{"any":{"enabled":true,"format":"mp3"}}}}}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
 
 	input := map[string]interface{}{
 		"call_recording": map[string]interface{}{
@@ -397,8 +390,48 @@ func Test_EnableCallRecording_Success(t *testing.T) {
 		},
 	}
 
-	resp, err := clt.AccountsAPI.ChangeAccount(ctx, "4dee5c1bef3ace50911c9917c50c9f80", input)
+	resp, err := clt.AccountsAPI.PatchAccount(ctx, "4dee5c1bef3ace50911c9917c50c9f80", input)
 	assert.NoError(t, err)
 
-	assert.Equal(t, "9235595b763c59359bde2a277973f1e8", resp.ID, "should be equal")
+	assert.Equal(t, "4dee5c1bef3ace50911c9917c50c9f80", resp.ID, "should be equal")
+	assertRequest(t, last(), "PATCH", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80")
+	assert.Equal(t, input, last().Data)
+}
+
+func TestAccountsAPIService_UpdateAccount(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"4dee5c1bef3ace50911c9917c50c9f80","name":"ACME","realm":"acme.pbx.example.com","timezone":"Europe/Moscow"}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.AccountsAPI.UpdateAccount(ctx, "4dee5c1bef3ace50911c9917c50c9f80", &kazooapi.Account{Name: "ACME", Realm: "acme.pbx.example.com", Timezone: "Europe/Moscow"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", acc.Timezone)
+	assertRequest(t, last(), "POST", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80")
+	assert.Equal(t, map[string]interface{}{"name": "ACME", "realm": "acme.pbx.example.com", "timezone": "Europe/Moscow"}, last().Data)
+
+	_, err = clt.AccountsAPI.UpdateAccount(ctx, "4dee5c1bef3ace50911c9917c50c9f80", &kazooapi.Account{})
+	assert.EqualError(t, err, "account name is required field")
+
+	_, err = clt.AccountsAPI.UpdateAccount(ctx, "4dee5c", &kazooapi.Account{Name: "ACME"})
+	assert.EqualError(t, err, "specify correct account id")
+}
+
+func TestAccountsAPIService_DeleteAccount(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"4dee5c1bef3ace50911c9917c50c9f80","name":"ACME"}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	assert.NoError(t, clt.AccountsAPI.DeleteAccount(ctx, "4dee5c1bef3ace50911c9917c50c9f80"))
+	assertRequest(t, last(), "DELETE", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80")
+
+	acc, err := clt.AccountsAPI.DeleteAccountDocument(ctx, "4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+	assert.Equal(t, "ACME", acc.Name)
+	assertRequest(t, last(), "DELETE", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80")
+
+	assert.EqualError(t, clt.AccountsAPI.DeleteAccount(ctx, ""), "account id is required field")
 }
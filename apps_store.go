@@ -2,8 +2,6 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 )
 
 type AppsStoreAPIService service
@@ -14,6 +12,19 @@ type (
 		Users        []string `json:"users"`
 	}
 
+	App struct {
+		ID            string                 `json:"id"`
+		Name          string                 `json:"name"`
+		APIURL        string                 `json:"api_url,omitempty"`
+		SourceURL     string                 `json:"source_url,omitempty"`
+		Phase         string                 `json:"phase,omitempty"`
+		Tags          []string               `json:"tags,omitempty"`
+		I18n          map[string]interface{} `json:"i18n,omitempty"`
+		Masqueradable bool                   `json:"masqueradable,omitempty"`
+		AllowedUsers  string                 `json:"allowed_users,omitempty"`
+		Users         []string               `json:"users,omitempty"`
+	}
+
 	InstallAppOutput struct {
 		Name         string   `json:"name"`
 		AllowedUsers string   `json:"allowed_users"`
@@ -23,61 +34,94 @@ type (
 
 //InstallApp activates chosen application for given account ID
 func (api *AppsStoreAPIService) InstallApp(ctx context.Context, acc, appID string, input *InstallAppInput) (output *InstallAppOutput, err error) {
-	var response struct {
-		Data InstallAppOutput `json:"data"`
-		ResponseEnvelope
-	}
-
 	if len(appID) != 32 {
 		return nil, reportError("number must be 32 symbols")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/apps_store/" + appID,
+	output, _, err = do[*InstallAppOutput](ctx, api.client, endpoint{
+		service:   "AppsStoreAPI",
+		operation: "InstallApp",
+		method:    "PUT",
+		path:      path("accounts", acc, "apps_store", appID),
+		body:      input,
+	})
+
+	return output, err
+}
+
+//ListApps returns applications available to the account
+func (api *AppsStoreAPIService) ListApps(ctx context.Context, acc string) (apps []App, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
+	apps, _, err = do[[]App](ctx, api.client, endpoint{
+		service:   "AppsStoreAPI",
+		operation: "ListApps",
+		method:    "GET",
+		path:      path("accounts", acc, "apps_store"),
+	})
+
+	return apps, err
+}
 
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
+//GetApp returns the application document
+func (api *AppsStoreAPIService) GetApp(ctx context.Context, acc, appID string) (app *App, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
+	if len(appID) != 32 {
+		return nil, reportError("app id must be 32 symbols")
 	}
 
-	params.PostBody = body
+	app, _, err = do[*App](ctx, api.client, endpoint{
+		service:   "AppsStoreAPI",
+		operation: "GetApp",
+		method:    "GET",
+		path:      path("accounts", acc, "apps_store", appID),
+	})
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+	return app, err
+}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+//UpdateApp changes which users of the account may use the installed application
+func (api *AppsStoreAPIService) UpdateApp(ctx context.Context, acc, appID string, input *InstallAppInput) (output *InstallAppOutput, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+	if len(appID) != 32 {
+		return nil, reportError("app id must be 32 symbols")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
+	output, _, err = do[*InstallAppOutput](ctx, api.client, endpoint{
+		service:   "AppsStoreAPI",
+		operation: "UpdateApp",
+		method:    "POST",
+		path:      path("accounts", acc, "apps_store", appID),
+		body:      input,
+	})
+
+	return output, err
+}
+
+//UninstallApp deactivates the application for the account
+func (api *AppsStoreAPIService) UninstallApp(ctx context.Context, acc, appID string) (output *InstallAppOutput, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
 
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if len(appID) != 32 {
+		return nil, reportError("app id must be 32 symbols")
 	}
 
-	output = &response.Data
+	output, _, err = do[*InstallAppOutput](ctx, api.client, endpoint{
+		service:   "AppsStoreAPI",
+		operation: "UninstallApp",
+		method:    "DELETE",
+		path:      path("accounts", acc, "apps_store", appID),
+	})
 
-	return output, nil
-}
\ No newline at end of file
+	return output, err
+}
@@ -0,0 +1,47 @@
+package kazooapi_test
+
+import (
+	"context"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppsStoreAPIService_CRUD(t *testing.T) {
+	ctx := context.Background()
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/apps_store/12556b35127b8f2819d4b1f67e03baef"
+
+	listSrv, last := MockDocumentServer(t, `[{"id":"12556b35127b8f2819d4b1f67e03baef","name":"callflows","api_url":"https://api.pbx.example.com/v2","masqueradable":true}]`)
+	defer listSrv.Close()
+
+	apps, err := newMockClient(t, listSrv).AppsStoreAPI.ListApps(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	if assert.Len(t, apps, 1) {
+		assert.Equal(t, "callflows", apps[0].Name)
+		assert.True(t, apps[0].Masqueradable)
+	}
+	assertRequest(t, last(), "GET", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/apps_store")
+
+	srv, last := MockDocumentServer(t, `{"id":"12556b35127b8f2819d4b1f67e03baef","name":"callflows","allowed_users":"specific","users":["4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4"]}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	app, err := clt.AppsStoreAPI.GetApp(ctx, "qe0ade400015367f0069d6dfbdca072a", "12556b35127b8f2819d4b1f67e03baef")
+	assert.NoError(t, err)
+	assert.Equal(t, "specific", app.AllowedUsers)
+	assertRequest(t, last(), "GET", path)
+
+	out, err := clt.AppsStoreAPI.UpdateApp(ctx, "qe0ade400015367f0069d6dfbdca072a", "12556b35127b8f2819d4b1f67e03baef", &kazooapi.InstallAppInput{AllowedUsers: "specific", Users: []string{"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4"}, out.Users)
+	assertRequest(t, last(), "POST", path)
+
+	_, err = clt.AppsStoreAPI.UninstallApp(ctx, "qe0ade400015367f0069d6dfbdca072a", "12556b35127b8f2819d4b1f67e03baef")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "DELETE", path)
+
+	_, err = clt.AppsStoreAPI.GetApp(ctx, "qe0ade400015367f0069d6dfbdca072a", "callflows")
+	assert.EqualError(t, err, "app id must be 32 symbols")
+}
@@ -0,0 +1,137 @@
+//Package callflow is a fluent builder of Kazoo callflow flows, e.g.
+//
+//	flow := callflow.User(userID).Timeout(20).Then(callflow.Voicemail(boxID))
+//	cf, err := callflow.New("Reception", flow, "2000")
+//
+//Every module constructor returns a node with setters for its common data fields,
+//the typed data itself is reachable through the Data field of the node.
+//Nodes are continued with Then (the "_" default child) or keyed branches,
+//Action turns a node with all of its children into kazooapi.CallflowAction,
+//Build does the same but reports flows which aren't trees
+package callflow
+
+import (
+	"errors"
+	"fmt"
+
+	kazooapi "github.com/sashker/kazoo-go"
+)
+
+//ErrCycle is returned by Build and New when a node is its own descendant, Kazoo flows are trees
+var ErrCycle = errors.New("callflow: node is its own descendant")
+
+//Builder is anything which can be turned into a callflow action
+type Builder interface {
+	Action() kazooapi.CallflowAction
+}
+
+//builder is implemented by nodes of the package, so Build can report cycles of the whole flow.
+//visiting holds the nodes on the path from the root, it's local to a single build, so a flow
+//sharing nodes might be built from several goroutines at once
+type builder interface {
+	build(visiting map[*Node]bool) (kazooapi.CallflowAction, error)
+}
+
+//Node is a module of a flow with its children, typed nodes embed it
+type Node struct {
+	module   string
+	data     interface{}
+	children map[string]Builder
+}
+
+//Raw returns a node of any module, data is serialized as is.
+//nil data is sent as an empty object
+func Raw(module string, data interface{}) *Node {
+	return &Node{module: module, data: data}
+}
+
+//Module returns the name of the node's module
+func (n *Node) Module() string {
+	return n.module
+}
+
+//Then sets the default child the call continues with
+func (n *Node) Then(next Builder) *Node {
+	return n.Branch(kazooapi.CallflowDefaultChild, next)
+}
+
+//Branch sets the child for the given key, nil next removes the child
+func (n *Node) Branch(key string, next Builder) *Node {
+	if next == nil {
+		delete(n.children, key)
+		return n
+	}
+
+	if n.children == nil {
+		n.children = make(map[string]Builder)
+	}
+	n.children[key] = next
+
+	return n
+}
+
+//Action builds the flow starting at the node. A node which is its own descendant
+//is built without its children the second time, use Build to get the error instead
+func (n *Node) Action() kazooapi.CallflowAction {
+	action, _ := n.build(make(map[*Node]bool))
+	return action
+}
+
+//Build builds the flow starting at the node, the returned error matches ErrCycle
+//if a node is its own descendant. The same node might be used in several branches though
+func (n *Node) Build() (kazooapi.CallflowAction, error) {
+	return n.build(make(map[*Node]bool))
+}
+
+func (n *Node) build(visiting map[*Node]bool) (kazooapi.CallflowAction, error) {
+	action := kazooapi.CallflowAction{
+		Module:   n.module,
+		Children: make(map[string]kazooapi.CallflowAction, len(n.children)),
+		Data:     n.data,
+	}
+
+	if action.Data == nil {
+		action.Data = map[string]interface{}{}
+	}
+
+	if visiting[n] {
+		return action, fmt.Errorf("%w: %s module", ErrCycle, n.module)
+	}
+	visiting[n] = true
+	defer delete(visiting, n)
+
+	var err error
+	children := action.Children.(map[string]kazooapi.CallflowAction)
+	for key, child := range n.children {
+		built, childErr := build(child, visiting)
+		if childErr != nil && err == nil {
+			err = childErr
+		}
+		children[key] = built
+	}
+
+	return action, err
+}
+
+//build builds a flow of any Builder, nodes of the package are checked for cycles
+func build(b Builder, visiting map[*Node]bool) (kazooapi.CallflowAction, error) {
+	if nb, ok := b.(builder); ok {
+		return nb.build(visiting)
+	}
+	return b.Action(), nil
+}
+
+//New returns a callflow document routing the numbers to the flow.
+//The returned error matches ErrCycle if a node of the flow is its own descendant
+func New(name string, flow Builder, numbers ...string) (*kazooapi.Callflow, error) {
+	action, err := build(flow, make(map[*Node]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	return &kazooapi.Callflow{
+		Name:    name,
+		Flow:    action,
+		Numbers: numbers,
+	}, nil
+}
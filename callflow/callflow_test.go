@@ -0,0 +1,196 @@
+package callflow_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sashker/kazoo-go/callflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserThenVoicemail(t *testing.T) {
+	flow := callflow.User("d201633c77337fc469302947a56f4c44").Timeout(20).Then(callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"))
+
+	data, err := json.Marshal(flow.Action())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"module": "user",
+		"data": {"id": "d201633c77337fc469302947a56f4c44", "timeout": 20},
+		"children": {
+			"_": {
+				"module": "voicemail",
+				"data": {"id": "2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"},
+				"children": {}
+			}
+		}
+	}`, string(data))
+}
+
+func TestKeyedBranches(t *testing.T) {
+	sales := callflow.RingGroup("Sales").
+		User("d201633c77337fc469302947a56f4c44", 0, 20).
+		Device("a1b2c3d4e5f60718293a4b5c6d7e8f90", 5, 15).
+		Strategy(callflow.StrategySimultaneous).
+		Then(callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"))
+
+	menu := callflow.Menu("0f1e2d3c4b5a69788796a5b4c3d2e1f0").
+		Option("1", sales).
+		Option("2", callflow.Resources().ToDID("+14158867900")).
+		Option("0", callflow.Conference("").Moderator()).
+		Then(callflow.Response(486, "User busy"))
+
+	flow := callflow.TemporalRoute().
+		Timezone("America/Los_Angeles").
+		Route("b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c", callflow.Play("closed").Answer()).
+		Route("b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c", callflow.Play("holiday").Answer()).
+		Then(callflow.SetCID("Main", "+14158867900").Then(menu))
+
+	cf, err := callflow.New("Reception", flow, "+14158867900", "2000")
+	assert.NoError(t, err)
+	data, err := json.Marshal(cf)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "Reception",
+		"numbers": ["+14158867900", "2000"],
+		"flow": {
+			"module": "temporal_route",
+			"data": {"rules": ["b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c"], "timezone": "America/Los_Angeles"},
+			"children": {
+				"b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c": {
+					"module": "play",
+					"data": {"id": "holiday", "answer": true},
+					"children": {}
+				},
+				"_": {
+					"module": "set_cid",
+					"data": {"caller_id_name": "Main", "caller_id_number": "+14158867900"},
+					"children": {
+						"_": {
+							"module": "menu",
+							"data": {"id": "0f1e2d3c4b5a69788796a5b4c3d2e1f0"},
+							"children": {
+								"0": {
+									"module": "conference",
+									"data": {"moderator": true},
+									"children": {}
+								},
+								"1": {
+									"module": "ring_group",
+									"data": {
+										"name": "Sales",
+										"strategy": "simultaneous",
+										"endpoints": [
+											{"id": "d201633c77337fc469302947a56f4c44", "endpoint_type": "user", "timeout": 20},
+											{"id": "a1b2c3d4e5f60718293a4b5c6d7e8f90", "endpoint_type": "device", "delay": 5, "timeout": 15}
+										]
+									},
+									"children": {
+										"_": {
+											"module": "voicemail",
+											"data": {"id": "2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"},
+											"children": {}
+										}
+									}
+								},
+								"2": {
+									"module": "resources",
+									"data": {"to_did": "+14158867900"},
+									"children": {}
+								},
+								"_": {
+									"module": "response",
+									"data": {"code": 486, "message": "User busy"},
+									"children": {}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}`, string(data))
+}
+
+func TestRawAndRemovedBranch(t *testing.T) {
+	flow := callflow.Raw("callflow", map[string]string{"id": "9e1e5f9031e9e8446f54da9df47680a0"}).
+		Then(callflow.Raw("hangup", nil))
+	flow.Then(nil)
+
+	data, err := json.Marshal(flow.Action())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"module": "callflow", "data": {"id": "9e1e5f9031e9e8446f54da9df47680a0"}, "children": {}}`, string(data))
+
+	data, err = json.Marshal(callflow.Raw("hangup", nil).Action())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"module": "hangup", "data": {}, "children": {}}`, string(data))
+}
+
+func TestCycle(t *testing.T) {
+	menu := callflow.Menu("0f1e2d3c4b5a69788796a5b4c3d2e1f0")
+	menu.Option("9", callflow.Play("goodbye").Then(menu))
+
+	_, err := menu.Build()
+	assert.True(t, errors.Is(err, callflow.ErrCycle))
+	assert.EqualError(t, err, "callflow: node is its own descendant: menu module")
+
+	//New refuses to make a document of it
+	cf, err := callflow.New("Reception", menu, "2000")
+	assert.Nil(t, cf)
+	assert.True(t, errors.Is(err, callflow.ErrCycle))
+
+	//Action cuts the cycle at the repeated node
+	data, err := json.Marshal(menu.Action())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"module": "menu",
+		"data": {"id": "0f1e2d3c4b5a69788796a5b4c3d2e1f0"},
+		"children": {
+			"9": {
+				"module": "play",
+				"data": {"id": "goodbye"},
+				"children": {
+					"_": {"module": "menu", "data": {"id": "0f1e2d3c4b5a69788796a5b4c3d2e1f0"}, "children": {}}
+				}
+			}
+		}
+	}`, string(data))
+
+	//The same node might be used in several branches
+	vm := callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e")
+	flow := callflow.Menu("0f1e2d3c4b5a69788796a5b4c3d2e1f0").Option("1", vm).Option("2", vm)
+	action, err := flow.Build()
+	assert.NoError(t, err)
+	assert.Len(t, action.Children, 2)
+}
+
+func ExampleUser() {
+	flow := callflow.User("d201633c77337fc469302947a56f4c44").Timeout(20).
+		Then(callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"))
+
+	cf, _ := callflow.New("John Doe", flow, "2001")
+	data, _ := json.Marshal(cf)
+	fmt.Println(string(data))
+	// Output: {"name":"John Doe","flow":{"module":"user","children":{"_":{"module":"voicemail","children":{},"data":{"id":"2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"}}},"data":{"id":"d201633c77337fc469302947a56f4c44","timeout":20}},"numbers":["2001"]}
+}
+
+func TestBuild_Concurrent(t *testing.T) {
+	//Flows sharing a node are built from several goroutines at once
+	vm := callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e")
+	shared := callflow.Play("welcome").Then(vm)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := callflow.Menu("0f1e2d3c4b5a69788796a5b4c3d2e1f0").Option("1", shared).Option("2", shared).Build()
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,497 @@
+package callflow
+
+import (
+	kazooapi "github.com/sashker/kazoo-go"
+)
+
+//Ring strategies of user and ring_group modules
+const (
+	StrategySimultaneous   = "simultaneous"
+	StrategySingle         = "single"
+	StrategyWeightedRandom = "weighted_random"
+)
+
+//UserNode rings all devices of a user
+type UserNode struct {
+	*Node
+	Data *kazooapi.UserModule
+}
+
+//User returns a user module node
+func User(id string) *UserNode {
+	data := &kazooapi.UserModule{ID: id}
+	return &UserNode{Node: Raw(kazooapi.ModuleUser, data), Data: data}
+}
+
+//Timeout sets how long (in seconds) the user's devices ring
+func (n *UserNode) Timeout(sec int64) *UserNode {
+	n.Data.Timeout = sec
+	return n
+}
+
+//Delay sets how long (in seconds) to wait before ringing
+func (n *UserNode) Delay(sec int64) *UserNode {
+	n.Data.Delay = sec
+	return n
+}
+
+//CanCallSelf lets the user's devices ring when the call comes from the user
+func (n *UserNode) CanCallSelf() *UserNode {
+	n.Data.CanCallSelf = true
+	return n
+}
+
+//Strategy sets the order the user's devices ring in
+func (n *UserNode) Strategy(strategy string) *UserNode {
+	n.Data.Strategy = strategy
+	return n
+}
+
+//DeviceNode rings a single device
+type DeviceNode struct {
+	*Node
+	Data *kazooapi.DeviceModule
+}
+
+//Device returns a device module node
+func Device(id string) *DeviceNode {
+	data := &kazooapi.DeviceModule{ID: id}
+	return &DeviceNode{Node: Raw(kazooapi.ModuleDevice, data), Data: data}
+}
+
+//Timeout sets how long (in seconds) the device rings
+func (n *DeviceNode) Timeout(sec int64) *DeviceNode {
+	n.Data.Timeout = sec
+	return n
+}
+
+//Delay sets how long (in seconds) to wait before ringing
+func (n *DeviceNode) Delay(sec int64) *DeviceNode {
+	n.Data.Delay = sec
+	return n
+}
+
+//CanCallSelf lets the device ring when the call comes from the device itself
+func (n *DeviceNode) CanCallSelf() *DeviceNode {
+	n.Data.CanCallSelf = true
+	return n
+}
+
+//StaticInvite sets the user part of the request URI sent to the device
+func (n *DeviceNode) StaticInvite(user string) *DeviceNode {
+	n.Data.StaticInvite = user
+	return n
+}
+
+//RingGroupNode rings a set of users, devices and groups
+type RingGroupNode struct {
+	*Node
+	Data *kazooapi.RingGroupModule
+}
+
+//RingGroup returns a ring_group module node without endpoints
+func RingGroup(name string) *RingGroupNode {
+	data := &kazooapi.RingGroupModule{Name: name, Endpoints: []kazooapi.RingGroupEndpoint{}}
+	return &RingGroupNode{Node: Raw(kazooapi.ModuleRingGroup, data), Data: data}
+}
+
+//User adds a user to the group
+func (n *RingGroupNode) User(id string, delay, timeout int64) *RingGroupNode {
+	return n.Endpoint(kazooapi.RingGroupEndpoint{ID: id, EndpointType: "user", Delay: delay, Timeout: timeout})
+}
+
+//Device adds a device to the group
+func (n *RingGroupNode) Device(id string, delay, timeout int64) *RingGroupNode {
+	return n.Endpoint(kazooapi.RingGroupEndpoint{ID: id, EndpointType: "device", Delay: delay, Timeout: timeout})
+}
+
+//Group adds members of a group to the group
+func (n *RingGroupNode) Group(id string, delay, timeout int64) *RingGroupNode {
+	return n.Endpoint(kazooapi.RingGroupEndpoint{ID: id, EndpointType: "group", Delay: delay, Timeout: timeout})
+}
+
+//Endpoint adds an endpoint to the group
+func (n *RingGroupNode) Endpoint(ep kazooapi.RingGroupEndpoint) *RingGroupNode {
+	n.Data.Endpoints = append(n.Data.Endpoints, ep)
+	return n
+}
+
+//Strategy sets the order endpoints ring in
+func (n *RingGroupNode) Strategy(strategy string) *RingGroupNode {
+	n.Data.Strategy = strategy
+	return n
+}
+
+//Timeout sets how long (in seconds) the whole group rings
+func (n *RingGroupNode) Timeout(sec int64) *RingGroupNode {
+	n.Data.Timeout = sec
+	return n
+}
+
+//Repeats sets how many times the group rings
+func (n *RingGroupNode) Repeats(count int64) *RingGroupNode {
+	n.Data.Repeats = count
+	return n
+}
+
+//Ringback sets the media the caller hears while the group rings
+func (n *RingGroupNode) Ringback(media string) *RingGroupNode {
+	n.Data.Ringback = media
+	return n
+}
+
+//VoicemailNode leaves a message in a mailbox or checks it
+type VoicemailNode struct {
+	*Node
+	Data *kazooapi.VoicemailModule
+}
+
+//Voicemail returns a voicemail module node leaving a message in the box
+func Voicemail(id string) *VoicemailNode {
+	data := &kazooapi.VoicemailModule{ID: id}
+	return &VoicemailNode{Node: Raw(kazooapi.ModuleVoicemail, data), Data: data}
+}
+
+//Check makes the caller log into the box instead of leaving a message
+func (n *VoicemailNode) Check() *VoicemailNode {
+	n.Data.Action = "check"
+	return n
+}
+
+//MaxMessageLength sets the longest message (in seconds) the box accepts
+func (n *VoicemailNode) MaxMessageLength(sec int64) *VoicemailNode {
+	n.Data.MaxMessageLength = sec
+	return n
+}
+
+//MenuNode plays an IVR menu and branches on the pressed digit
+type MenuNode struct {
+	*Node
+	Data *kazooapi.MenuModule
+}
+
+//Menu returns a menu module node
+func Menu(id string) *MenuNode {
+	data := &kazooapi.MenuModule{ID: id}
+	return &MenuNode{Node: Raw(kazooapi.ModuleMenu, data), Data: data}
+}
+
+//Option sets the branch taken when the caller presses digit
+func (n *MenuNode) Option(digit string, next Builder) *MenuNode {
+	n.Branch(digit, next)
+	return n
+}
+
+//TemporalRouteNode branches on the first matching temporal rule
+type TemporalRouteNode struct {
+	*Node
+	Data *kazooapi.TemporalRouteModule
+}
+
+//TemporalRoute returns a temporal_route module node,
+//routes are added with Route and the default child is taken when none of the rules matches
+func TemporalRoute() *TemporalRouteNode {
+	data := &kazooapi.TemporalRouteModule{}
+	return &TemporalRouteNode{Node: Raw(kazooapi.ModuleTemporalRoute, data), Data: data}
+}
+
+//Route sets the branch taken when the temporal rule matches
+//and adds the rule to the ones the module checks
+func (n *TemporalRouteNode) Route(rule string, next Builder) *TemporalRouteNode {
+	n.Branch(rule, next)
+
+	for _, r := range n.Data.Rules {
+		if r == rule {
+			return n
+		}
+	}
+	n.Data.Rules = append(n.Data.Rules, rule)
+
+	return n
+}
+
+//Timezone sets the timezone the rules are checked in
+func (n *TemporalRouteNode) Timezone(tz string) *TemporalRouteNode {
+	n.Data.Timezone = tz
+	return n
+}
+
+//ResourcesNode sends the call to a carrier
+type ResourcesNode struct {
+	*Node
+	Data *kazooapi.Resources
+}
+
+//Resources returns a resources module node using global carriers
+func Resources() *ResourcesNode {
+	data := &kazooapi.Resources{}
+	return &ResourcesNode{Node: Raw(kazooapi.ModuleResources, data), Data: data}
+}
+
+//Offnet returns an offnet module node, the legacy alias of resources
+func Offnet() *ResourcesNode {
+	data := &kazooapi.Resources{}
+	return &ResourcesNode{Node: Raw(kazooapi.ModuleOffnet, data), Data: data}
+}
+
+//Local makes the call use the account's own carriers
+func (n *ResourcesNode) Local() *ResourcesNode {
+	n.Data.UseLocalResources = true
+	return n
+}
+
+//HuntAccount makes the call use carriers of the given account
+func (n *ResourcesNode) HuntAccount(id string) *ResourcesNode {
+	n.Data.UseLocalResources = true
+	n.Data.HuntAccountID = id
+	return n
+}
+
+//ToDID sets the number the call is sent to instead of the dialed one
+func (n *ResourcesNode) ToDID(number string) *ResourcesNode {
+	n.Data.ToDID = number
+	return n
+}
+
+//Timeout sets how long (in seconds) to wait for the carrier to answer
+func (n *ResourcesNode) Timeout(sec int64) *ResourcesNode {
+	n.Data.Timeout = sec
+	return n
+}
+
+//Ringback sets the media the caller hears while the call is ringing
+func (n *ResourcesNode) Ringback(media string) *ResourcesNode {
+	n.Data.Ringback = media
+	return n
+}
+
+//PlayNode plays a media file or URL
+type PlayNode struct {
+	*Node
+	Data *kazooapi.PlayModule
+}
+
+//Play returns a play module node, media is a media id or URL
+func Play(media string) *PlayNode {
+	data := &kazooapi.PlayModule{ID: media}
+	return &PlayNode{Node: Raw(kazooapi.ModulePlay, data), Data: data}
+}
+
+//Answer answers the call before playing
+func (n *PlayNode) Answer() *PlayNode {
+	n.Data.Answer = true
+	return n
+}
+
+//Endless plays the media in a loop
+func (n *PlayNode) Endless() *PlayNode {
+	n.Data.EndlessPlayback = true
+	return n
+}
+
+//Terminators sets DTMF keys stopping the playback
+func (n *PlayNode) Terminators(keys ...string) *PlayNode {
+	n.Data.Terminators = keys
+	return n
+}
+
+//TTSNode reads a text to the caller
+type TTSNode struct {
+	*Node
+	Data *kazooapi.TTSModule
+}
+
+//TTS returns a tts module node
+func TTS(text string) *TTSNode {
+	data := &kazooapi.TTSModule{Text: text}
+	return &TTSNode{Node: Raw(kazooapi.ModuleTTS, data), Data: data}
+}
+
+//Voice sets the voice the text is read with
+func (n *TTSNode) Voice(voice string) *TTSNode {
+	n.Data.Voice = voice
+	return n
+}
+
+//Language sets the language of the text, e.g. en-US
+func (n *TTSNode) Language(lang string) *TTSNode {
+	n.Data.Language = lang
+	return n
+}
+
+//Engine sets the TTS engine
+func (n *TTSNode) Engine(engine string) *TTSNode {
+	n.Data.Engine = engine
+	return n
+}
+
+//Terminators sets DTMF keys stopping the reading
+func (n *TTSNode) Terminators(keys ...string) *TTSNode {
+	n.Data.Terminators = keys
+	return n
+}
+
+//RecordCallNode starts or stops recording of the call
+type RecordCallNode struct {
+	*Node
+	Data *kazooapi.RecordCallModule
+}
+
+//StartRecording returns a record_call module node starting the recording
+func StartRecording() *RecordCallNode {
+	data := &kazooapi.RecordCallModule{Action: "start"}
+	return &RecordCallNode{Node: Raw(kazooapi.ModuleRecordCall, data), Data: data}
+}
+
+//StopRecording returns a record_call module node stopping the recording
+func StopRecording() *RecordCallNode {
+	data := &kazooapi.RecordCallModule{Action: "stop"}
+	return &RecordCallNode{Node: Raw(kazooapi.ModuleRecordCall, data), Data: data}
+}
+
+//Format sets the format of the recording, mp3 or wav
+func (n *RecordCallNode) Format(format string) *RecordCallNode {
+	n.Data.Format = format
+	return n
+}
+
+//TimeLimit sets the longest recording (in seconds)
+func (n *RecordCallNode) TimeLimit(sec int64) *RecordCallNode {
+	n.Data.TimeLimit = sec
+	return n
+}
+
+//URL sets where the recording is stored
+func (n *RecordCallNode) URL(url string) *RecordCallNode {
+	n.Data.URL = url
+	return n
+}
+
+//OnAnswer postpones the recording until the call is answered
+func (n *RecordCallNode) OnAnswer() *RecordCallNode {
+	n.Data.RecordOnAnswer = true
+	return n
+}
+
+//PivotNode asks an external web server what to do with the call
+type PivotNode struct {
+	*Node
+	Data *kazooapi.PivotModule
+}
+
+//Pivot returns a pivot module node
+func Pivot(voiceURL string) *PivotNode {
+	data := &kazooapi.PivotModule{VoiceURL: voiceURL}
+	return &PivotNode{Node: Raw(kazooapi.ModulePivot, data), Data: data}
+}
+
+//Method sets the HTTP method of the request to the voice URL
+func (n *PivotNode) Method(method string) *PivotNode {
+	n.Data.Method = method
+	return n
+}
+
+//ReqFormat sets the format of the server's response, kazoo or twiml
+func (n *PivotNode) ReqFormat(format string) *PivotNode {
+	n.Data.ReqFormat = format
+	return n
+}
+
+//CDRURL sets where the CDR of the call is sent
+func (n *PivotNode) CDRURL(url string) *PivotNode {
+	n.Data.CDRURL = url
+	return n
+}
+
+//Debug makes Kazoo store requests and responses of the call
+func (n *PivotNode) Debug() *PivotNode {
+	n.Data.Debug = true
+	return n
+}
+
+//ConferenceNode puts the caller into a conference
+type ConferenceNode struct {
+	*Node
+	Data *kazooapi.ConferenceModule
+}
+
+//Conference returns a conference module node,
+//empty id makes the caller enter the conference number
+func Conference(id string) *ConferenceNode {
+	data := &kazooapi.ConferenceModule{ID: id}
+	return &ConferenceNode{Node: Raw(kazooapi.ModuleConference, data), Data: data}
+}
+
+//Moderator joins the caller as a moderator
+func (n *ConferenceNode) Moderator() *ConferenceNode {
+	n.Data.Moderator = true
+	return n
+}
+
+//PlayName asks the caller for their name and announces it to the conference
+func (n *ConferenceNode) PlayName() *ConferenceNode {
+	n.Data.PlayName = true
+	return n
+}
+
+//ParkNode parks the call or retrieves a parked one
+type ParkNode struct {
+	*Node
+	Data *kazooapi.ParkModule
+}
+
+//Park returns a park module node parking the call in the slot,
+//empty slot means the first free one
+func Park(slot string) *ParkNode {
+	data := &kazooapi.ParkModule{Action: "park", Slot: slot}
+	return &ParkNode{Node: Raw(kazooapi.ModulePark, data), Data: data}
+}
+
+//Retrieve picks up the call parked in the slot instead
+func (n *ParkNode) Retrieve() *ParkNode {
+	n.Data.Action = "retrieve"
+	return n
+}
+
+//Auto retrieves a call parked in the slot or parks the call if the slot is free
+func (n *ParkNode) Auto() *ParkNode {
+	n.Data.Action = "auto"
+	return n
+}
+
+//RingbackTimeout sets how long (in milliseconds) the parker's phone rings
+//when the parked call isn't picked up
+func (n *ParkNode) RingbackTimeout(ms int64) *ParkNode {
+	n.Data.DefaultRingbackTimeout = ms
+	return n
+}
+
+//ResponseNode ends the call with a SIP response
+type ResponseNode struct {
+	*Node
+	Data *kazooapi.ResponseModule
+}
+
+//Response returns a response module node
+func Response(code int64, message string) *ResponseNode {
+	data := &kazooapi.ResponseModule{Code: code, Message: message}
+	return &ResponseNode{Node: Raw(kazooapi.ModuleResponse, data), Data: data}
+}
+
+//Media sets the media played before the response is sent
+func (n *ResponseNode) Media(media string) *ResponseNode {
+	n.Data.Media = media
+	return n
+}
+
+//SetCIDNode changes the caller id of the call
+type SetCIDNode struct {
+	*Node
+	Data *kazooapi.SetCIDModule
+}
+
+//SetCID returns a set_cid module node
+func SetCID(name, number string) *SetCIDNode {
+	data := &kazooapi.SetCIDModule{CallerIDName: name, CallerIDNumber: number}
+	return &SetCIDNode{Node: Raw(kazooapi.ModuleSetCID, data), Data: data}
+}
@@ -0,0 +1,381 @@
+package kazooapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//FlowNode is a decoded callflow action. Data of known modules is decoded into
+//their typed structs, so it might be inspected and changed in place, e.g.
+//
+//	if dev, ok := node.Data.(*DeviceModule); ok {
+//		dev.Timeout = 30
+//	}
+//
+//FlowNode keeps the data it was decoded from: fields the typed struct doesn't know
+//survive encoding, and data which wasn't changed is encoded exactly as it was received
+type FlowNode struct {
+	Module string
+	//Data is a pointer to the typed data of known modules (e.g. *DeviceModule),
+	//json.RawMessage for other modules and for data which doesn't fit the typed struct
+	Data     interface{}
+	Children map[string]*FlowNode
+
+	//raw is the data the node was decoded from, snapshot is the typed data encoded right after decoding
+	raw      json.RawMessage
+	snapshot []byte
+	rawType  reflect.Type
+	//extra are fields of the action other than module, data and children
+	extra map[string]json.RawMessage
+}
+
+//DecodeFlow turns an action (e.g. Callflow.Flow returned by GetCallflow) into a typed tree
+func DecodeFlow(action CallflowAction) (*FlowNode, error) {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, reportError("can't encode flow: %v", err)
+	}
+
+	node := &FlowNode{}
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, reportError("can't decode flow: %v", err)
+	}
+
+	return node, nil
+}
+
+//UnmarshalJSON decodes a flow action with all of its children
+func (n *FlowNode) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	if fields == nil {
+		return errors.New("flow action must be an object")
+	}
+
+	*n = FlowNode{}
+
+	if module, ok := fields["module"]; ok {
+		if err := json.Unmarshal(module, &n.Module); err != nil {
+			return fmt.Errorf("module: %v", err)
+		}
+		delete(fields, "module")
+	}
+
+	if children, ok := fields["children"]; ok {
+		if err := n.decodeChildren(children); err != nil {
+			return err
+		}
+		delete(fields, "children")
+	}
+
+	if data, ok := fields["data"]; ok {
+		n.decodeData(data)
+		delete(fields, "data")
+	}
+
+	if len(fields) > 0 {
+		n.extra = fields
+	}
+
+	return nil
+}
+
+func (n *FlowNode) decodeChildren(b json.RawMessage) error {
+	//Kazoo might store a module without children as an empty list
+	trimmed := bytes.TrimSpace(b)
+	if bytes.Equal(trimmed, []byte("null")) || bytes.Equal(trimmed, []byte("[]")) {
+		return nil
+	}
+
+	var children map[string]json.RawMessage
+	if err := json.Unmarshal(b, &children); err != nil {
+		return fmt.Errorf("children of %s module: %v", n.Module, err)
+	}
+
+	n.Children = make(map[string]*FlowNode, len(children))
+	for key, raw := range children {
+		child := &FlowNode{}
+		if err := json.Unmarshal(raw, child); err != nil {
+			return fmt.Errorf("child %q of %s module: %v", key, n.Module, err)
+		}
+		n.Children[key] = child
+	}
+
+	return nil
+}
+
+//decodeData falls back to the raw data if the module is unknown or its data doesn't fit the typed struct
+func (n *FlowNode) decodeData(raw json.RawMessage) {
+	n.raw = raw
+	n.Data = raw
+
+	newData, ok := callflowModules[n.Module]
+	if !ok {
+		return
+	}
+
+	data := newData()
+	if err := json.Unmarshal(raw, data); err != nil {
+		return
+	}
+
+	snapshot, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	n.Data, n.snapshot, n.rawType = data, snapshot, reflect.TypeOf(data)
+}
+
+//MarshalJSON encodes the node with all of its children.
+//Modules without children get an empty object
+func (n *FlowNode) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]json.RawMessage, len(n.extra)+3)
+	for k, v := range n.extra {
+		fields[k] = v
+	}
+
+	module, err := json.Marshal(n.Module)
+	if err != nil {
+		return nil, err
+	}
+	fields["module"] = module
+
+	children := make(map[string]*FlowNode, len(n.Children))
+	for key, child := range n.Children {
+		if child != nil {
+			children[key] = child
+		}
+	}
+	if fields["children"], err = json.Marshal(children); err != nil {
+		return nil, err
+	}
+
+	if fields["data"], err = n.encodeData(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+//Action turns the node back into CallflowAction, e.g. to update the callflow
+//with changes made to the tree. Fields of the action other than module, data and children are lost
+func (n *FlowNode) Action() CallflowAction {
+	children := make(map[string]CallflowAction, len(n.Children))
+	for key, child := range n.Children {
+		if child != nil {
+			children[key] = child.Action()
+		}
+	}
+
+	var data interface{} = n.Data
+	if encoded, err := n.encodeData(); err == nil {
+		data = encoded
+	}
+
+	return CallflowAction{Module: n.Module, Children: children, Data: data}
+}
+
+//encodeData returns the raw data if the typed data wasn't changed, otherwise
+//fields known to the typed struct are taken from it and the rest from the raw data
+func (n *FlowNode) encodeData() (json.RawMessage, error) {
+	switch data := n.Data.(type) {
+	case nil:
+		return json.RawMessage("{}"), nil
+	case json.RawMessage:
+		if len(data) == 0 {
+			return json.RawMessage("{}"), nil
+		}
+		return data, nil
+	}
+
+	typed, err := json.Marshal(n.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.rawType == nil || n.rawType != reflect.TypeOf(n.Data) {
+		return typed, nil
+	}
+
+	if bytes.Equal(typed, n.snapshot) {
+		return n.raw, nil
+	}
+
+	var merged, fresh map[string]json.RawMessage
+	if err := json.Unmarshal(n.raw, &merged); err != nil || merged == nil {
+		return typed, nil
+	}
+	if err := json.Unmarshal(typed, &fresh); err != nil {
+		return typed, nil
+	}
+
+	for _, key := range jsonFields(n.rawType) {
+		delete(merged, key)
+	}
+	for k, v := range fresh {
+		merged[k] = v
+	}
+
+	return json.Marshal(merged)
+}
+
+//jsonFields returns JSON names of the struct's fields
+func jsonFields(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+
+		switch {
+		case name == "-":
+		case name == "" && f.Anonymous:
+			names = append(names, jsonFields(f.Type)...)
+		case name == "" && f.IsExported():
+			names = append(names, f.Name)
+		case name != "":
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+//FlowVisitFunc is called by FlowNode.Walk for every node of the flow,
+//path holds child keys leading from the root to the node
+type FlowVisitFunc func(path []string, n *FlowNode) error
+
+//Walk visits the node and its descendants depth-first, children in the order of their keys.
+//If fn returns SkipSubtree descendants of the node are skipped, any other error stops the walk and is returned
+func (n *FlowNode) Walk(fn FlowVisitFunc) error {
+	err := n.walk(nil, fn)
+	if errors.Is(err, SkipSubtree) {
+		return nil
+	}
+	return err
+}
+
+func (n *FlowNode) walk(path []string, fn FlowVisitFunc) error {
+	if err := fn(path, n); err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(n.Children) {
+		childPath := append(path[:len(path):len(path)], key)
+		err := n.Children[key].walk(childPath, fn)
+		if err != nil && !errors.Is(err, SkipSubtree) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//sortedKeys returns keys of the children which aren't nil, in the order FlowNode.Walk visits them
+func sortedKeys(children map[string]*FlowNode) []string {
+	var keys []string
+	for key, child := range children {
+		if child != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//References returns ids of documents (users, devices, mailboxes, menus, media, etc.)
+//the node itself refers to. For modules without typed data the "id" field is used
+func (n *FlowNode) References() []string {
+	var ids []string
+	add := func(id string) {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	switch data := n.Data.(type) {
+	case *UserModule:
+		add(data.ID)
+	case *DeviceModule:
+		add(data.ID)
+	case *VoicemailModule:
+		add(data.ID)
+	case *MenuModule:
+		add(data.ID)
+	case *PlayModule:
+		add(data.ID)
+	case *ConferenceModule:
+		add(data.ID)
+	case *RingGroupModule:
+		for _, ep := range data.Endpoints {
+			add(ep.ID)
+		}
+	case *TemporalRouteModule:
+		for _, rule := range data.Rules {
+			add(rule)
+		}
+	case *Resources:
+		add(data.HuntAccountID)
+	case json.RawMessage:
+		var doc struct {
+			ID interface{} `json:"id"`
+		}
+		if json.Unmarshal(data, &doc) == nil {
+			if id, ok := doc.ID.(string); ok {
+				add(id)
+			}
+		}
+	}
+
+	return ids
+}
+
+//CallflowsReferencing returns callflows with a module referring to the document id,
+//e.g. all callflows ringing a device. Callflows must carry their flows, ListCallflows
+//returns summaries only, so they should be fetched with GetCallflow
+func CallflowsReferencing(cfs []Callflow, id string) ([]Callflow, error) {
+	var found []Callflow
+
+	for _, cf := range cfs {
+		flow, err := DecodeFlow(cf.Flow)
+		if err != nil {
+			return nil, reportError("callflow %s: %v", cf.ID, err)
+		}
+
+		refers := false
+		err = flow.Walk(func(path []string, n *FlowNode) error {
+			for _, ref := range n.References() {
+				if ref == id {
+					refers = true
+					return errStopFlowWalk
+				}
+			}
+			return nil
+		})
+		if err != nil && err != errStopFlowWalk {
+			return nil, err
+		}
+
+		if refers {
+			found = append(found, cf)
+		}
+	}
+
+	return found, nil
+}
+
+var errStopFlowWalk = errors.New("stop walking")
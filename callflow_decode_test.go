@@ -0,0 +1,182 @@
+package kazooapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/sashker/kazoo-go/callflow"
+	"github.com/stretchr/testify/assert"
+)
+
+//mustCallflow is callflow.New for flows of tests which are known to be trees
+func mustCallflow(name string, flow callflow.Builder, numbers ...string) *kazooapi.Callflow {
+	cf, err := callflow.New(name, flow, numbers...)
+	if err != nil {
+		panic(err)
+	}
+	return cf
+}
+
+const smartPBXFlow = `{
+	"module": "temporal_route",
+	"data": {"rules": ["b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c"], "timezone": "America/Los_Angeles", "ui_is_main_number_cf": true},
+	"children": {
+		"b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c": {
+			"module": "play",
+			"data": {"id": "closed", "answer": false},
+			"children": []
+		},
+		"_": {
+			"module": "ring_group",
+			"data": {
+				"name": "Sales",
+				"endpoints": [
+					{"id": "d201633c77337fc469302947a56f4c44", "endpoint_type": "user", "timeout": 20},
+					{"id": "a1b2c3d4e5f60718293a4b5c6d7e8f90", "endpoint_type": "device", "delay": 5, "timeout": 15}
+				]
+			},
+			"children": {
+				"_": {
+					"module": "user",
+					"data": {"id": "d201633c77337fc469302947a56f4c44", "timeout": "20"},
+					"children": {
+						"_": {
+							"module": "callflow",
+							"data": {"id": "9e1e5f9031e9e8446f54da9df47680a0"},
+							"children": {}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestDecodeFlow(t *testing.T) {
+	var flow kazooapi.FlowNode
+	assert.NoError(t, json.Unmarshal([]byte(smartPBXFlow), &flow))
+
+	assert.Equal(t, "temporal_route", flow.Module)
+	if route, ok := flow.Data.(*kazooapi.TemporalRouteModule); assert.True(t, ok) {
+		assert.Equal(t, "America/Los_Angeles", route.Timezone)
+	}
+
+	group := flow.Children["_"]
+	if rg, ok := group.Data.(*kazooapi.RingGroupModule); assert.True(t, ok) {
+		assert.Len(t, rg.Endpoints, 2)
+		assert.Equal(t, "device", rg.Endpoints[1].EndpointType)
+	}
+
+	//Data not fitting the typed struct and unknown modules are kept raw
+	usr := group.Children["_"]
+	assert.IsType(t, json.RawMessage{}, usr.Data)
+	assert.IsType(t, json.RawMessage{}, usr.Children["_"].Data)
+	assert.Equal(t, []string{"d201633c77337fc469302947a56f4c44"}, usr.References())
+	assert.Equal(t, []string{"9e1e5f9031e9e8446f54da9df47680a0"}, usr.Children["_"].References())
+
+	//Unchanged flow is encoded as it was received
+	data, err := json.Marshal(&flow)
+	assert.NoError(t, err)
+	assert.JSONEq(t, strings.Replace(smartPBXFlow, `"children": []`, `"children": {}`, 1), string(data))
+
+	//Changes keep fields unknown to the typed data
+	flow.Data.(*kazooapi.TemporalRouteModule).Timezone = "Europe/Berlin"
+	group.Data.(*kazooapi.RingGroupModule).Endpoints = group.Data.(*kazooapi.RingGroupModule).Endpoints[:1]
+
+	data, err = json.Marshal(flow.Action())
+	assert.NoError(t, err)
+
+	var back kazooapi.FlowNode
+	assert.NoError(t, json.Unmarshal(data, &back))
+	assert.JSONEq(t, `{"rules": ["b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c"], "timezone": "Europe/Berlin", "ui_is_main_number_cf": true}`, string(back.Action().Data.(json.RawMessage)))
+	assert.Len(t, back.Children["_"].Data.(*kazooapi.RingGroupModule).Endpoints, 1)
+	assert.Equal(t, "callflow", back.Children["_"].Children["_"].Children["_"].Module)
+
+	//Decoding errors point to the broken child
+	err = json.Unmarshal([]byte(`{"module": "menu", "children": {"1": {"module": 5}}}`), &back)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `child "1" of menu module`)
+}
+
+func TestDecodeFlowFromBuilder(t *testing.T) {
+	built := callflow.User("d201633c77337fc469302947a56f4c44").Timeout(20).
+		Then(callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e")).Action()
+
+	flow, err := kazooapi.DecodeFlow(built)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), flow.Data.(*kazooapi.UserModule).Timeout)
+
+	before, _ := json.Marshal(built)
+	after, _ := json.Marshal(flow.Action())
+	assert.JSONEq(t, string(before), string(after))
+}
+
+func TestFlowNode_Walk(t *testing.T) {
+	var flow kazooapi.FlowNode
+	assert.NoError(t, json.Unmarshal([]byte(smartPBXFlow), &flow))
+
+	var visited []string
+	err := flow.Walk(func(path []string, n *kazooapi.FlowNode) error {
+		visited = append(visited, strings.Join(append(path, n.Module), "/"))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"temporal_route",
+		"_/ring_group",
+		"_/_/user",
+		"_/_/_/callflow",
+		"b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c/play",
+	}, visited)
+
+	visited = nil
+	err = flow.Walk(func(path []string, n *kazooapi.FlowNode) error {
+		visited = append(visited, n.Module)
+		if n.Module == "ring_group" {
+			return kazooapi.SkipSubtree
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"temporal_route", "ring_group", "play"}, visited)
+
+	errStop := errors.New("stop")
+	visited = nil
+	err = flow.Walk(func(path []string, n *kazooapi.FlowNode) error {
+		visited = append(visited, n.Module)
+		if n.Module == "user" {
+			return errStop
+		}
+		return nil
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, []string{"temporal_route", "ring_group", "user"}, visited)
+}
+
+func TestCallflowsReferencing(t *testing.T) {
+	var main kazooapi.Callflow
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "970af9b5ad818ffc700dc9847dc2bb11", "numbers": ["+14158867900"], "flow": `+smartPBXFlow+`}`), &main))
+
+	cfs := []kazooapi.Callflow{
+		main,
+		*mustCallflow("John Doe", callflow.User("d201633c77337fc469302947a56f4c44"), "2001"),
+		*mustCallflow("Lobby", callflow.Device("0f1e2d3c4b5a69788796a5b4c3d2e1f0"), "2002"),
+	}
+
+	found, err := kazooapi.CallflowsReferencing(cfs, "a1b2c3d4e5f60718293a4b5c6d7e8f90")
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "970af9b5ad818ffc700dc9847dc2bb11", found[0].ID)
+	}
+
+	found, err = kazooapi.CallflowsReferencing(cfs, "d201633c77337fc469302947a56f4c44")
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	found, err = kazooapi.CallflowsReferencing(cfs, "ffffffffffffffffffffffffffffffff")
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+}
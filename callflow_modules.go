@@ -0,0 +1,170 @@
+package kazooapi
+
+//Names of callflow modules, CallflowAction.Module
+const (
+	ModuleUser          = "user"
+	ModuleDevice        = "device"
+	ModuleRingGroup     = "ring_group"
+	ModuleVoicemail     = "voicemail"
+	ModuleMenu          = "menu"
+	ModuleTemporalRoute = "temporal_route"
+	ModuleOffnet        = "offnet"
+	ModuleResources     = "resources"
+	ModulePlay          = "play"
+	ModuleTTS           = "tts"
+	ModuleRecordCall    = "record_call"
+	ModulePivot         = "pivot"
+	ModuleConference    = "conference"
+	ModulePark          = "park"
+	ModuleResponse      = "response"
+	ModuleSetCID        = "set_cid"
+	//ModuleCallflow continues with another callflow of the account, its data is {"id": callflow id}
+	ModuleCallflow = "callflow"
+)
+
+//CallflowDefaultChild is the key of the child a module continues with
+//when none of its keyed branches matches (or when it has no branches at all)
+const CallflowDefaultChild = "_"
+
+//Data of callflow modules. UserModule and Resources (used by both offnet and
+//resources modules) are declared with Callflow
+type (
+	DeviceModule struct {
+		ID               string      `json:"id,omitempty"`
+		SkipModule       bool        `json:"skip_module,omitempty"`
+		CanCallSelf      bool        `json:"can_call_self,omitempty"`
+		CanTextSelf      bool        `json:"can_text_self,omitempty"`
+		CustomSIPHeaders interface{} `json:"custom_sip_headers,omitempty"`
+		Delay            int64       `json:"delay,omitempty"`
+		Timeout          int64       `json:"timeout,omitempty"`
+		StaticInvite     string      `json:"static_invite,omitempty"`
+		SupressCLID      bool        `json:"suppress_clid,omitempty"`
+	}
+
+	RingGroupEndpoint struct {
+		ID           string `json:"id"`
+		EndpointType string `json:"endpoint_type"` //user, device or group
+		Delay        int64  `json:"delay,omitempty"`
+		Timeout      int64  `json:"timeout,omitempty"`
+		Weight       int64  `json:"weight,omitempty"` //used by weighted_random strategy
+	}
+
+	RingGroupModule struct {
+		Name               string              `json:"name,omitempty"`
+		Endpoints          []RingGroupEndpoint `json:"endpoints"`
+		Strategy           string              `json:"strategy,omitempty"` //simultaneous - is default value
+		Timeout            int64               `json:"timeout,omitempty"`
+		Repeats            int64               `json:"repeats,omitempty"`
+		Ringback           string              `json:"ringback,omitempty"`
+		IgnoreForward      bool                `json:"ignore_forward,omitempty"`
+		FailOnSingleReject bool                `json:"fail_on_single_reject,omitempty"`
+	}
+
+	VoicemailModule struct {
+		ID                 string `json:"id,omitempty"`
+		Action             string `json:"action,omitempty"` //compose - is default value, check
+		CallerIDMatchLogin bool   `json:"callerid_match_login,omitempty"`
+		InterdigitTimeout  int64  `json:"interdigit_timeout,omitempty"`
+		MaxMessageLength   int64  `json:"max_message_length,omitempty"`
+		SingleMailboxLogin bool   `json:"single_mailbox_login,omitempty"`
+	}
+
+	//MenuModule branches are keyed by the pressed digit
+	MenuModule struct {
+		ID string `json:"id"`
+	}
+
+	//TemporalRouteModule branches are keyed by temporal rule ids
+	TemporalRouteModule struct {
+		Action            string   `json:"action,omitempty"` //enable, disable, reset or menu for toggling rules
+		Rules             []string `json:"rules,omitempty"`
+		Timezone          string   `json:"timezone,omitempty"`
+		InterdigitTimeout int64    `json:"interdigit_timeout,omitempty"`
+	}
+
+	PlayModule struct {
+		ID              string   `json:"id"` //media id or URL
+		Answer          bool     `json:"answer,omitempty"`
+		EndlessPlayback bool     `json:"endless_playback,omitempty"`
+		Terminators     []string `json:"terminators,omitempty"`
+	}
+
+	TTSModule struct {
+		Text        string   `json:"text"`
+		Voice       string   `json:"voice,omitempty"` //female - is default value
+		Language    string   `json:"language,omitempty"`
+		Engine      string   `json:"engine,omitempty"`
+		Terminators []string `json:"terminators,omitempty"`
+	}
+
+	RecordCallModule struct {
+		Action           string `json:"action"` //start or stop
+		Format           string `json:"format,omitempty"`
+		TimeLimit        int64  `json:"time_limit,omitempty"`
+		URL              string `json:"url,omitempty"`
+		RecordOnAnswer   bool   `json:"record_on_answer,omitempty"`
+		RecordSampleRate int64  `json:"record_sample_rate,omitempty"`
+		RecordMinSec     int64  `json:"record_min_sec,omitempty"`
+	}
+
+	PivotModule struct {
+		VoiceURL  string `json:"voice_url"`
+		Method    string `json:"method,omitempty"`     //GET - is default value
+		ReqFormat string `json:"req_format,omitempty"` //kazoo - is default value, twiml
+		CDRURL    string `json:"cdr_url,omitempty"`
+		Debug     bool   `json:"debug,omitempty"`
+	}
+
+	ConferenceMember struct {
+		Numbers   []string `json:"numbers,omitempty"`
+		Pins      []string `json:"pins,omitempty"`
+		JoinMuted bool     `json:"join_muted,omitempty"`
+		JoinDeaf  bool     `json:"join_deaf,omitempty"`
+	}
+
+	ConferenceModule struct {
+		ID        string            `json:"id,omitempty"` //without id the caller is asked for a conference number
+		Moderator bool              `json:"moderator,omitempty"`
+		PlayName  bool              `json:"play_name,omitempty"`
+		Member    *ConferenceMember `json:"member,omitempty"`
+	}
+
+	ParkModule struct {
+		Action                 string `json:"action,omitempty"` //park - is default value, retrieve, auto
+		Slot                   string `json:"slot,omitempty"`
+		DefaultRingbackTimeout int64  `json:"default_ringback_timeout,omitempty"`
+		DefaultCallbackTimeout int64  `json:"default_callback_timeout,omitempty"`
+		DefaultPresenceType    string `json:"default_presence_type,omitempty"`
+	}
+
+	ResponseModule struct {
+		Code    int64  `json:"code"`
+		Message string `json:"message,omitempty"`
+		Media   string `json:"media,omitempty"`
+	}
+
+	SetCIDModule struct {
+		CallerIDName   string `json:"caller_id_name,omitempty"`
+		CallerIDNumber string `json:"caller_id_number,omitempty"`
+	}
+)
+
+//callflowModules returns new typed data of known modules, data of other modules is kept raw
+var callflowModules = map[string]func() interface{}{
+	ModuleUser:          func() interface{} { return &UserModule{} },
+	ModuleDevice:        func() interface{} { return &DeviceModule{} },
+	ModuleRingGroup:     func() interface{} { return &RingGroupModule{} },
+	ModuleVoicemail:     func() interface{} { return &VoicemailModule{} },
+	ModuleMenu:          func() interface{} { return &MenuModule{} },
+	ModuleTemporalRoute: func() interface{} { return &TemporalRouteModule{} },
+	ModuleOffnet:        func() interface{} { return &Resources{} },
+	ModuleResources:     func() interface{} { return &Resources{} },
+	ModulePlay:          func() interface{} { return &PlayModule{} },
+	ModuleTTS:           func() interface{} { return &TTSModule{} },
+	ModuleRecordCall:    func() interface{} { return &RecordCallModule{} },
+	ModulePivot:         func() interface{} { return &PivotModule{} },
+	ModuleConference:    func() interface{} { return &ConferenceModule{} },
+	ModulePark:          func() interface{} { return &ParkModule{} },
+	ModuleResponse:      func() interface{} { return &ResponseModule{} },
+	ModuleSetCID:        func() interface{} { return &SetCIDModule{} },
+}
@@ -0,0 +1,225 @@
+package kazooapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+//renderTextLimit is the longest text (e.g. of tts module) shown on a diagram node
+const renderTextLimit = 40
+
+//RenderOptions resolves ids referenced by modules (and temporal rule ids used as child keys)
+//to names shown on diagrams
+type RenderOptions struct {
+	Users   []User
+	Devices []Device
+	//Names maps any other ids (voicemail boxes, menus, media, temporal rules, callflows) to names
+	Names map[string]string
+}
+
+func (opts *RenderOptions) names() map[string]string {
+	names := make(map[string]string)
+	if opts == nil {
+		return names
+	}
+
+	for _, usr := range opts.Users {
+		name := strings.TrimSpace(usr.FirstName + " " + usr.LastName)
+		if name == "" {
+			name = usr.Username
+		}
+		names[usr.ID] = name
+	}
+	for _, dev := range opts.Devices {
+		names[dev.ID] = dev.Name
+	}
+	for id, name := range opts.Names {
+		names[id] = name
+	}
+
+	return names
+}
+
+type renderNode struct {
+	id    string
+	lines []string
+}
+
+type renderEdge struct {
+	from, to, label string
+}
+
+type callflowGraph struct {
+	title string
+	start renderNode
+	nodes []renderNode
+	edges []renderEdge
+}
+
+//graph turns the callflow into nodes and edges, the start node holds the name and numbers of the callflow
+func (cf *Callflow) graph(opts *RenderOptions) (*callflowGraph, error) {
+	flow, err := DecodeFlow(cf.Flow)
+	if err != nil {
+		return nil, err
+	}
+
+	names := opts.names()
+	resolve := func(id string) string {
+		if name, ok := names[id]; ok && name != "" {
+			return name
+		}
+		return id
+	}
+
+	g := &callflowGraph{title: cf.Name, start: renderNode{id: "start"}}
+	if g.title == "" {
+		g.title = cf.ID
+	}
+	if cf.Name != "" {
+		g.start.lines = append(g.start.lines, cf.Name)
+	}
+	if routes := append(append([]string{}, cf.Numbers...), cf.Patterns...); len(routes) > 0 {
+		g.start.lines = append(g.start.lines, strings.Join(routes, ", "))
+	}
+
+	ids := make(map[*FlowNode]string)
+	err = flow.Walk(func(path []string, n *FlowNode) error {
+		id := fmt.Sprintf("n%d", len(g.nodes)+1)
+		ids[n] = id
+
+		node := renderNode{id: id, lines: []string{n.Module}}
+		if detail := describeModule(n, resolve); detail != "" {
+			node.lines = append(node.lines, detail)
+		}
+		g.nodes = append(g.nodes, node)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	g.edges = append(g.edges, renderEdge{from: "start", to: ids[flow]})
+	flow.Walk(func(path []string, n *FlowNode) error {
+		for _, key := range sortedKeys(n.Children) {
+			label := key
+			if key == CallflowDefaultChild {
+				label = ""
+			} else if _, ok := n.Data.(*TemporalRouteModule); ok {
+				label = resolve(key)
+			}
+			g.edges = append(g.edges, renderEdge{from: ids[n], to: ids[n.Children[key]], label: label})
+		}
+		return nil
+	})
+
+	return g, nil
+}
+
+//describeModule returns the second line of the node's label
+func describeModule(n *FlowNode, resolve func(id string) string) string {
+	var parts []string
+
+	switch d := n.Data.(type) {
+	case *RingGroupModule:
+		if d.Name != "" {
+			return d.Name
+		}
+		for _, ep := range d.Endpoints {
+			parts = append(parts, resolve(ep.ID))
+		}
+	case *TTSModule:
+		text := d.Text
+		if len([]rune(text)) > renderTextLimit {
+			text = string([]rune(text)[:renderTextLimit]) + "..."
+		}
+		return `"` + text + `"`
+	case *ResponseModule:
+		return strings.TrimSpace(fmt.Sprintf("%d %s", d.Code, d.Message))
+	case *SetCIDModule:
+		return strings.TrimSpace(d.CallerIDName + " " + d.CallerIDNumber)
+	case *PivotModule:
+		return d.VoiceURL
+	case *RecordCallModule:
+		return d.Action
+	case *ParkModule:
+		return strings.TrimSpace(d.Action + " " + d.Slot)
+	case *TemporalRouteModule:
+		return d.Timezone
+	case *Resources:
+		if d.UseLocalResources {
+			parts = append(parts, "local")
+		}
+		if d.HuntAccountID != "" {
+			parts = append(parts, resolve(d.HuntAccountID))
+		}
+	default:
+		for _, id := range n.References() {
+			parts = append(parts, resolve(id))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+//DOT renders the callflow as a Graphviz digraph, modules are nodes and child keys are edge labels
+//(the default child has no label)
+func (cf *Callflow) DOT(opts *RenderOptions) (string, error) {
+	g, err := cf.graph(opts)
+	if err != nil {
+		return "", err
+	}
+
+	quote := func(lines ...string) string {
+		r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+		return `"` + r.Replace(strings.Join(lines, "\n")) + `"`
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quote(g.title))
+	b.WriteString("\tnode [shape=box];\n")
+	fmt.Fprintf(&b, "\t%s [label=%s, shape=ellipse];\n", g.start.id, quote(g.start.lines...))
+	for _, n := range g.nodes {
+		fmt.Fprintf(&b, "\t%s [label=%s];\n", n.id, quote(n.lines...))
+	}
+	for _, e := range g.edges {
+		if e.label == "" {
+			fmt.Fprintf(&b, "\t%s -> %s;\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s];\n", e.from, e.to, quote(e.label))
+	}
+	b.WriteString("}\n")
+
+	return b.String(), nil
+}
+
+//Mermaid renders the callflow as a Mermaid flowchart, modules are nodes and child keys are edge labels
+//(the default child has no label)
+func (cf *Callflow) Mermaid(opts *RenderOptions) (string, error) {
+	g, err := cf.graph(opts)
+	if err != nil {
+		return "", err
+	}
+
+	quote := func(lines ...string) string {
+		r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", "<br/>")
+		return `"` + r.Replace(strings.Join(lines, "\n")) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	fmt.Fprintf(&b, "\t%s([%s])\n", g.start.id, quote(g.start.lines...))
+	for _, n := range g.nodes {
+		fmt.Fprintf(&b, "\t%s[%s]\n", n.id, quote(n.lines...))
+	}
+	for _, e := range g.edges {
+		if e.label == "" {
+			fmt.Fprintf(&b, "\t%s --> %s\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s -->|%s| %s\n", e.from, quote(e.label), e.to)
+	}
+
+	return b.String(), nil
+}
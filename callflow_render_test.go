@@ -0,0 +1,81 @@
+package kazooapi_test
+
+import (
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/sashker/kazoo-go/callflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func renderedCallflow() (*kazooapi.Callflow, *kazooapi.RenderOptions) {
+	flow := callflow.TemporalRoute().
+		Timezone("America/Los_Angeles").
+		Route("b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c", callflow.TTS(`We are closed, please call back "tomorrow" between 9 and 5`)).
+		Then(callflow.Menu("0f1e2d3c4b5a69788796a5b4c3d2e1f0").
+			Option("1", callflow.RingGroup("").
+				User("d201633c77337fc469302947a56f4c44", 0, 20).
+				Device("a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 20)).
+			Then(callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e")))
+
+	cf := mustCallflow("Main <office>", flow, "+14158867900", "2000")
+
+	opts := &kazooapi.RenderOptions{
+		Users:   []kazooapi.User{{ID: "d201633c77337fc469302947a56f4c44", FirstName: "John", LastName: "Doe"}},
+		Devices: []kazooapi.Device{{ID: "a1b2c3d4e5f60718293a4b5c6d7e8f90", Name: "Lobby phone"}},
+		Names: map[string]string{
+			"b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c": "After hours",
+			"0f1e2d3c4b5a69788796a5b4c3d2e1f0": "Main menu",
+		},
+	}
+
+	return cf, opts
+}
+
+func TestCallflow_DOT(t *testing.T) {
+	cf, opts := renderedCallflow()
+
+	dot, err := cf.DOT(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph "Main <office>" {
+	node [shape=box];
+	start [label="Main <office>\n+14158867900, 2000", shape=ellipse];
+	n1 [label="temporal_route\nAmerica/Los_Angeles"];
+	n2 [label="menu\nMain menu"];
+	n3 [label="ring_group\nJohn Doe, Lobby phone"];
+	n4 [label="voicemail\n2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"];
+	n5 [label="tts\n\"We are closed, please call back \"tomorro...\""];
+	start -> n1;
+	n1 -> n2;
+	n1 -> n5 [label="After hours"];
+	n2 -> n3 [label="1"];
+	n2 -> n4;
+}
+`, dot)
+}
+
+func TestCallflow_Mermaid(t *testing.T) {
+	cf, opts := renderedCallflow()
+
+	mermaid, err := cf.Mermaid(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, `flowchart TD
+	start(["Main #lt;office#gt;<br/>+14158867900, 2000"])
+	n1["temporal_route<br/>America/Los_Angeles"]
+	n2["menu<br/>Main menu"]
+	n3["ring_group<br/>John Doe, Lobby phone"]
+	n4["voicemail<br/>2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"]
+	n5["tts<br/>#quot;We are closed, please call back #quot;tomorro...#quot;"]
+	start --> n1
+	n1 --> n2
+	n1 -->|"After hours"| n5
+	n2 -->|"1"| n3
+	n2 --> n4
+`, mermaid)
+
+	//Ids are shown as is without options
+	mermaid, err = cf.Mermaid(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, mermaid, `n1 -->|"b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c"| n5`)
+	assert.Contains(t, mermaid, `n3["ring_group<br/>d201633c77337fc469302947a56f4c44, a1b2c3d4e5f60718293a4b5c6d7e8f90"]`)
+}
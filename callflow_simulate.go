@@ -0,0 +1,196 @@
+package kazooapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//Ways SimulateCall matches a dialed number to a callflow
+const (
+	MatchNumber  = "number"
+	MatchPattern = "pattern"
+	MatchNoMatch = "no_match"
+)
+
+//NoMatchNumber is the number of the callflow Kazoo routes calls to when nothing else matches
+const NoMatchNumber = "no_match"
+
+//ErrNoCallflow is returned by SimulateCall when no callflow matches the number
+var ErrNoCallflow = NewError("NoCallflow", "no callflow matches the number", nil)
+
+//SimulateOptions controls the path SimulateCall takes through the flow
+type SimulateOptions struct {
+	//Branch picks the child the call continues with at the node, e.g. the digit pressed in a menu.
+	//Empty key, nil Branch and keys the node has no child for mean the default child
+	Branch func(n *FlowNode) string
+}
+
+//SimulationStep is a module the call passed through
+type SimulationStep struct {
+	//CallflowID is the callflow the module belongs to, it changes after callflow modules
+	CallflowID string
+	//Key is the child key the call took to reach the module, empty for the first module of a callflow
+	Key  string
+	Node *FlowNode
+}
+
+//Simulation is the result of SimulateCall
+type Simulation struct {
+	Callflow *Callflow
+	//Match is one of MatchNumber, MatchPattern or MatchNoMatch
+	Match string
+	//Pattern is the matched pattern, CaptureGroup is the part of the number
+	//it captured (modules like resources dial it instead of the whole number)
+	Pattern      string
+	CaptureGroup string
+	Steps        []SimulationStep
+}
+
+//Path returns modules the call passed through, e.g. "menu -1-> callflow -> user -_-> voicemail"
+func (s *Simulation) Path() string {
+	var b strings.Builder
+	for i, step := range s.Steps {
+		switch {
+		case i == 0:
+		case step.Key == "":
+			b.WriteString(" -> ")
+		default:
+			fmt.Fprintf(&b, " -%s-> ", step.Key)
+		}
+		b.WriteString(step.Node.Module)
+	}
+	return b.String()
+}
+
+//SimulateCall finds the callflow Kazoo routes the dialed number to and follows its flow.
+//Numbers are matched exactly first, then patterns are tried and the one capturing
+//the longest part of the number wins, finally the call goes to the no_match callflow.
+//Feature codes are callflows with patterns (e.g. ^\*72([0-9]*)$) or numbers (e.g. *97)
+//and are matched the same way.
+//The flow is followed through callflow modules into other callflows of cfs,
+//so cfs should carry flows (fetched with GetCallflow) for the path to be reported
+func SimulateCall(cfs []Callflow, number string, opts *SimulateOptions) (*Simulation, error) {
+	if opts == nil {
+		opts = &SimulateOptions{}
+	}
+
+	sim := matchCallflow(cfs, number)
+	if sim == nil {
+		return nil, ErrNoCallflow
+	}
+
+	byID := make(map[string]*Callflow, len(cfs))
+	for i := range cfs {
+		if cfs[i].ID != "" {
+			byID[cfs[i].ID] = &cfs[i]
+		}
+	}
+
+	visited := make(map[string]bool)
+	cf := sim.Callflow
+
+	for cf != nil && cf.Flow.Module != "" {
+		if cf.ID != "" {
+			if visited[cf.ID] {
+				return sim, reportError("callflows loop at %s", cf.ID)
+			}
+			visited[cf.ID] = true
+		}
+
+		node, err := DecodeFlow(cf.Flow)
+		if err != nil {
+			return sim, reportError("callflow %s: %v", cf.ID, err)
+		}
+
+		var next *Callflow
+		key := ""
+		for node != nil {
+			sim.Steps = append(sim.Steps, SimulationStep{CallflowID: cf.ID, Key: key, Node: node})
+
+			if node.Module == ModuleCallflow {
+				if refs := node.References(); len(refs) > 0 {
+					next = byID[refs[0]]
+				}
+				break
+			}
+
+			key = ""
+			if opts.Branch != nil {
+				key = opts.Branch(node)
+			}
+			if _, ok := node.Children[key]; !ok {
+				key = CallflowDefaultChild
+			}
+			node = node.Children[key]
+		}
+
+		cf = next
+	}
+
+	return sim, nil
+}
+
+//matchCallflow mimics Kazoo's callflow lookup
+func matchCallflow(cfs []Callflow, number string) *Simulation {
+	for i := range cfs {
+		for _, n := range cfs[i].Numbers {
+			if n == number {
+				return &Simulation{Callflow: &cfs[i], Match: MatchNumber}
+			}
+		}
+	}
+
+	var best *Simulation
+	for i := range cfs {
+		for _, pattern := range cfs[i].Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				continue
+			}
+
+			loc := re.FindStringSubmatchIndex(number)
+			if loc == nil {
+				continue
+			}
+
+			capture := longestCapture(number, loc)
+			//Kazoo prefers the longest capture, the pattern listed first of equally long ones
+			if best == nil || len(capture) > len(best.CaptureGroup) {
+				best = &Simulation{Callflow: &cfs[i], Match: MatchPattern, Pattern: pattern, CaptureGroup: capture}
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	for i := range cfs {
+		for _, n := range cfs[i].Numbers {
+			if n == NoMatchNumber {
+				return &Simulation{Callflow: &cfs[i], Match: MatchNoMatch}
+			}
+		}
+	}
+
+	return nil
+}
+
+//longestCapture returns the longest capture group or the whole match if the pattern has no groups.
+//Of equally long groups the last one wins, as Kazoo takes the head of the reversed stable keysort by length
+func longestCapture(s string, loc []int) string {
+	if len(loc) == 2 {
+		return s[loc[0]:loc[1]]
+	}
+
+	capture := ""
+	for i := 2; i+1 < len(loc); i += 2 {
+		if loc[i] < 0 {
+			continue
+		}
+		if group := s[loc[i]:loc[i+1]]; len(group) >= len(capture) {
+			capture = group
+		}
+	}
+	return capture
+}
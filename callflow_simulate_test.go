@@ -0,0 +1,141 @@
+package kazooapi_test
+
+import (
+	"errors"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/sashker/kazoo-go/callflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func dialPlan() []kazooapi.Callflow {
+	newCallflow := func(id string, flow callflow.Builder, numbers []string, patterns ...string) kazooapi.Callflow {
+		cf := mustCallflow("", flow, numbers...)
+		cf.ID = id
+		cf.Patterns = patterns
+		return *cf
+	}
+
+	return []kazooapi.Callflow{
+		newCallflow("970af9b5ad818ffc700dc9847dc2bb11", callflow.TemporalRoute().
+			Route("b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c", callflow.Play("closed")).
+			Then(callflow.Menu("0f1e2d3c4b5a69788796a5b4c3d2e1f0").
+				Option("1", callflow.Raw("callflow", map[string]string{"id": "c3a1b2c3d4e5f60718293a4b5c6d7e8f"})).
+				Option("0", callflow.User("d201633c77337fc469302947a56f4c44")).
+				Then(callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e"))),
+			[]string{"+14158867900", "2000"}),
+		newCallflow("c3a1b2c3d4e5f60718293a4b5c6d7e8f", callflow.RingGroup("Sales").
+			User("d201633c77337fc469302947a56f4c44", 0, 20).
+			Then(callflow.Voicemail("2c3b9b4c1c2a4b1e9f0d8e7c6b5a4d3e")),
+			[]string{"2100"}),
+		newCallflow("9e1e5f9031e9e8446f54da9df47680a0", callflow.Raw("call_forward", map[string]string{"action": "activate"}),
+			nil, `^\*72([0-9]*)$`),
+		newCallflow("5b6c7d8e9f0a1b2c3d4e5f60718293a4", callflow.Resources(),
+			nil, `^\+?1?(\d{10})$`),
+		newCallflow("6c7d8e9f0a1b2c3d4e5f60718293a4b5", callflow.Resources().Local(),
+			nil, `^(\+1\d{10})$`),
+		newCallflow("7d8e9f0a1b2c3d4e5f60718293a4b5c6", callflow.Response(404, "Not found"),
+			[]string{"no_match"}),
+		newCallflow("8e9f0a1b2c3d4e5f60718293a4b5c6d7", callflow.Raw("callflow", map[string]string{"id": "9f0a1b2c3d4e5f60718293a4b5c6d7e8"}),
+			[]string{"3000"}),
+		newCallflow("9f0a1b2c3d4e5f60718293a4b5c6d7e8", callflow.TTS("Looping").Then(callflow.Raw("callflow", map[string]string{"id": "8e9f0a1b2c3d4e5f60718293a4b5c6d7"})),
+			[]string{"3001"}),
+	}
+}
+
+func TestSimulateCall(t *testing.T) {
+	cfs := dialPlan()
+
+	sim, err := kazooapi.SimulateCall(cfs, "+14158867900", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, kazooapi.MatchNumber, sim.Match)
+	assert.Equal(t, "970af9b5ad818ffc700dc9847dc2bb11", sim.Callflow.ID)
+	assert.Equal(t, "temporal_route -_-> menu -_-> voicemail", sim.Path())
+
+	//Caller presses 1 in the menu and lands in the sales callflow
+	sim, err = kazooapi.SimulateCall(cfs, "2000", &kazooapi.SimulateOptions{
+		Branch: func(n *kazooapi.FlowNode) string {
+			if n.Module == "menu" {
+				return "1"
+			}
+			return ""
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "temporal_route -_-> menu -1-> callflow -> ring_group -_-> voicemail", sim.Path())
+	assert.Equal(t, "970af9b5ad818ffc700dc9847dc2bb11", sim.Steps[2].CallflowID)
+	assert.Equal(t, "c3a1b2c3d4e5f60718293a4b5c6d7e8f", sim.Steps[3].CallflowID)
+
+	//Branches the node doesn't have fall back to the default child
+	sim, err = kazooapi.SimulateCall(cfs, "2000", &kazooapi.SimulateOptions{
+		Branch: func(n *kazooapi.FlowNode) string { return "b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c" },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "temporal_route -b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c-> play", sim.Path())
+}
+
+func TestSimulateCall_Patterns(t *testing.T) {
+	cfs := dialPlan()
+
+	sim, err := kazooapi.SimulateCall(cfs, "*7221000", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, kazooapi.MatchPattern, sim.Match)
+	assert.Equal(t, "9e1e5f9031e9e8446f54da9df47680a0", sim.Callflow.ID)
+	assert.Equal(t, "21000", sim.CaptureGroup)
+	assert.Equal(t, "call_forward", sim.Path())
+
+	//The longest capture wins
+	sim, err = kazooapi.SimulateCall(cfs, "+12125551234", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "6c7d8e9f0a1b2c3d4e5f60718293a4b5", sim.Callflow.ID)
+	assert.Equal(t, "+12125551234", sim.CaptureGroup)
+
+	sim, err = kazooapi.SimulateCall(cfs, "2125551234", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "5b6c7d8e9f0a1b2c3d4e5f60718293a4", sim.Callflow.ID)
+	assert.Equal(t, `^\+?1?(\d{10})$`, sim.Pattern)
+	assert.Equal(t, "2125551234", sim.CaptureGroup)
+
+	sim, err = kazooapi.SimulateCall(cfs, "4444", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, kazooapi.MatchNoMatch, sim.Match)
+	assert.Equal(t, "response", sim.Path())
+
+	_, err = kazooapi.SimulateCall(cfs[:5], "4444", nil)
+	assert.True(t, errors.Is(err, kazooapi.ErrNoCallflow))
+}
+
+func TestSimulateCall_PatternTie(t *testing.T) {
+	groups := kazooapi.Callflow{ID: "a1b2c3d4e5f60718293a4b5c6d7e8f90", Patterns: []string{`^(\d{2})(\d{2})$`}}
+	suffix := kazooapi.Callflow{ID: "b2c3d4e5f60718293a4b5c6d7e8f90a1", Patterns: []string{`^\d{2}(\d{2})$`}}
+
+	//Both patterns capture 2 digits and the first of them wins,
+	//but of its equally long groups the last one does
+	sim, err := kazooapi.SimulateCall([]kazooapi.Callflow{groups, suffix}, "2913", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, groups.ID, sim.Callflow.ID)
+	assert.Equal(t, "13", sim.CaptureGroup)
+
+	sim, err = kazooapi.SimulateCall([]kazooapi.Callflow{suffix, groups}, "2913", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, suffix.ID, sim.Callflow.ID)
+	assert.Equal(t, "13", sim.CaptureGroup)
+}
+
+func TestSimulateCall_Loop(t *testing.T) {
+	sim, err := kazooapi.SimulateCall(dialPlan(), "3000", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "loop at 8e9f0a1b2c3d4e5f60718293a4b5c6d7")
+	assert.Equal(t, "callflow -> tts -_-> callflow", sim.Path())
+
+	//Callflows without flows (e.g. listed ones) are matched without a path
+	summaries := dialPlan()
+	for i := range summaries {
+		summaries[i].Flow = kazooapi.CallflowAction{}
+	}
+	sim, err = kazooapi.SimulateCall(summaries, "3000", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "8e9f0a1b2c3d4e5f60718293a4b5c6d7", sim.Callflow.ID)
+	assert.Empty(t, sim.Steps)
+}
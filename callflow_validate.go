@@ -0,0 +1,390 @@
+package kazooapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+var (
+	menuKeyPattern = regexp.MustCompile(`^[0-9*#]$`)
+	ruleIDPattern  = regexp.MustCompile(`^[0-9a-f]{32}$`)
+)
+
+//CallflowIssue is a single problem found by Callflow.Validate
+type CallflowIssue struct {
+	//Field is the path to the problem in the callflow document,
+	//e.g. "numbers.1" or "flow.children._.data.id"
+	Field   string
+	Message string
+	//Warning is set for issues which Kazoo might be fine with, but which can't be checked locally
+	Warning bool
+}
+
+//CallflowValidationError is returned by Callflow.Validate, it matches ErrValidation
+type CallflowValidationError struct {
+	Issues []CallflowIssue
+}
+
+func (e *CallflowValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.Warning {
+			lines = append(lines, issue.Field+": warning: "+issue.Message)
+			continue
+		}
+		lines = append(lines, issue.Field+": "+issue.Message)
+	}
+
+	return SprintError(ErrValidation.Code(), "callflow failed validation", strings.Join(lines, "\n\t"), nil)
+}
+
+//Is enables errors.Is matching against ErrValidation
+func (e *CallflowValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+//Validate checks the callflow before it's sent to Kazoo: data of known modules,
+//child keys, number and pattern syntax. existing are other callflows of the account
+//(e.g. returned by ListCallflows), numbers and patterns used by them are reported as collisions,
+//and callflow modules of those carrying flows are followed to find loops.
+//The returned error is *CallflowValidationError, warnings alone don't fail the validation
+//but are listed along with the errors
+func (cf *Callflow) Validate(existing []Callflow) error {
+	issues := cf.Inspect(existing)
+	for _, issue := range issues {
+		if !issue.Warning {
+			return &CallflowValidationError{Issues: issues}
+		}
+	}
+	return nil
+}
+
+//Inspect returns every issue Validate would report, warnings included
+func (cf *Callflow) Inspect(existing []Callflow) []CallflowIssue {
+	v := &callflowValidator{}
+
+	v.numbers(cf, existing)
+	v.flow(cf, existing)
+
+	return v.issues
+}
+
+type callflowValidator struct {
+	issues []CallflowIssue
+}
+
+func (v *callflowValidator) add(field, format string, args ...interface{}) {
+	v.issues = append(v.issues, CallflowIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *callflowValidator) warn(field, format string, args ...interface{}) {
+	v.issues = append(v.issues, CallflowIssue{Field: field, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (v *callflowValidator) numbers(cf *Callflow, existing []Callflow) {
+	if len(cf.Numbers) == 0 && len(cf.Patterns) == 0 {
+		v.add("numbers", "either numbers or patterns are required")
+	}
+
+	usedNumbers := make(map[string]string)
+	usedPatterns := make(map[string]string)
+	for _, other := range existing {
+		if cf.ID != "" && other.ID == cf.ID {
+			continue
+		}
+		for _, number := range other.Numbers {
+			usedNumbers[number] = other.ID
+		}
+		for _, pattern := range other.Patterns {
+			usedPatterns[pattern] = other.ID
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, number := range cf.Numbers {
+		field := fmt.Sprintf("numbers.%d", i)
+
+		switch {
+		case number == "":
+			v.add(field, "number is empty")
+		case strings.ContainsAny(number, " \t\r\n"):
+			v.add(field, "number %q contains whitespace", number)
+		case seen[number]:
+			v.add(field, "number %q is listed twice", number)
+		}
+		seen[number] = true
+
+		if id, ok := usedNumbers[number]; ok {
+			v.add(field, "number %q is already used by callflow %s", number, id)
+		}
+	}
+
+	for i, pattern := range cf.Patterns {
+		field := fmt.Sprintf("patterns.%d", i)
+
+		if _, err := regexp.Compile(pattern); err != nil {
+			if pcreOnly(err) {
+				v.warn(field, "pattern %q can't be checked: %v", pattern, err)
+			} else {
+				v.add(field, "pattern %q isn't a valid regular expression: %v", pattern, err)
+			}
+		}
+
+		if id, ok := usedPatterns[pattern]; ok {
+			v.add(field, "pattern %q is already used by callflow %s", pattern, id)
+		}
+	}
+}
+
+func (v *callflowValidator) flow(cf *Callflow, existing []Callflow) {
+	flow, err := DecodeFlow(cf.Flow)
+	if err != nil {
+		v.add("flow", "%v", err)
+		return
+	}
+
+	flow.Walk(func(path []string, n *FlowNode) error {
+		field := "flow"
+		for _, key := range path {
+			field += ".children." + key
+		}
+
+		v.module(field, n)
+		v.children(field, n)
+
+		if n.Module == ModuleCallflow {
+			v.loop(field, cf, n, existing)
+		}
+
+		return nil
+	})
+}
+
+//module checks data of known modules
+func (v *callflowValidator) module(field string, n *FlowNode) {
+	if n.Module == "" {
+		v.add(field+".module", "module is required")
+		return
+	}
+
+	data := field + ".data"
+	required := func(name, value string) {
+		if value == "" {
+			v.add(data+"."+name, "%s is required by %s module", name, n.Module)
+		}
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		if value == "" {
+			return
+		}
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		v.add(data+"."+name, "%q isn't one of %s", value, strings.Join(allowed, ", "))
+	}
+
+	switch d := n.Data.(type) {
+	case *UserModule:
+		required("id", d.ID)
+	case *DeviceModule:
+		required("id", d.ID)
+	case *RingGroupModule:
+		if len(d.Endpoints) == 0 {
+			v.add(data+".endpoints", "ring group has no endpoints")
+		}
+		for i, ep := range d.Endpoints {
+			prefix := fmt.Sprintf("endpoints.%d.", i)
+			required(prefix+"id", ep.ID)
+			required(prefix+"endpoint_type", ep.EndpointType)
+			oneOf(prefix+"endpoint_type", ep.EndpointType, "user", "device", "group")
+		}
+		oneOf("strategy", d.Strategy, "simultaneous", "single", "weighted_random")
+	case *VoicemailModule:
+		oneOf("action", d.Action, "compose", "check")
+		if d.Action != "check" {
+			required("id", d.ID)
+		}
+	case *MenuModule:
+		required("id", d.ID)
+	case *TemporalRouteModule:
+		oneOf("action", d.Action, "enable", "disable", "reset", "menu")
+		for i, rule := range d.Rules {
+			required(fmt.Sprintf("rules.%d", i), rule)
+		}
+	case *PlayModule:
+		required("id", d.ID)
+	case *TTSModule:
+		required("text", d.Text)
+	case *RecordCallModule:
+		required("action", d.Action)
+		oneOf("action", d.Action, "start", "stop")
+	case *PivotModule:
+		required("voice_url", d.VoiceURL)
+		if u, err := url.Parse(d.VoiceURL); d.VoiceURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https")) {
+			v.add(data+".voice_url", "%q isn't an http(s) URL", d.VoiceURL)
+		}
+		oneOf("req_format", d.ReqFormat, "kazoo", "twiml")
+	case *ParkModule:
+		oneOf("action", d.Action, "park", "retrieve", "auto")
+	case *ResponseModule:
+		if d.Code < 100 || d.Code > 699 {
+			v.add(data+".code", "%d isn't a SIP response code", d.Code)
+		}
+	case *SetCIDModule:
+		if d.CallerIDName == "" && d.CallerIDNumber == "" {
+			v.add(data, "either caller_id_name or caller_id_number is required by %s module", n.Module)
+		}
+	case json.RawMessage:
+		//Data of a known module which didn't fit the typed struct
+		if newData, ok := callflowModules[n.Module]; ok {
+			if err := json.Unmarshal(d, newData()); err != nil {
+				v.add(data, "data doesn't match %s module: %v", n.Module, err)
+			}
+		}
+	}
+}
+
+//children checks keys of the module's branches, modules which aren't known to branch might have the default child only
+func (v *callflowValidator) children(field string, n *FlowNode) {
+	if _, known := callflowModules[n.Module]; !known {
+		return
+	}
+
+	keys := make([]string, 0, len(n.Children))
+	for key := range n.Children {
+		if key != CallflowDefaultChild {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+
+		switch d := n.Data.(type) {
+		case *MenuModule:
+			if !menuKeyPattern.MatchString(key) {
+				v.add(field+".children."+key, "menu branches must be keyed by a single digit, * or #")
+			}
+		case *TemporalRouteModule:
+			//Kazoo takes rules from the branch keys, flows made by Monster UI usually have no rules in data
+			if len(d.Rules) == 0 {
+				if !ruleIDPattern.MatchString(key) {
+					v.add(field+".children."+key, "temporal route branch %q isn't keyed by a rule id", key)
+				}
+				break
+			}
+
+			listed := false
+			for _, rule := range d.Rules {
+				listed = listed || rule == key
+			}
+			if !listed {
+				v.add(field+".children."+key, "temporal route branch %q isn't listed in rules", key)
+			}
+		default:
+			v.add(field+".children."+key, "%s module has the default child only", n.Module)
+		}
+	}
+}
+
+//pcreOnly reports whether the pattern failed to compile because of PCRE syntax RE2 lacks
+//(e.g. lookarounds or backreferences), Kazoo matches patterns with PCRE
+func pcreOnly(err error) bool {
+	var syntaxErr *syntax.Error
+	if !errors.As(err, &syntaxErr) {
+		return false
+	}
+
+	switch syntaxErr.Code {
+	case syntax.ErrInvalidPerlOp, syntax.ErrInvalidEscape, syntax.ErrInvalidRepeatOp:
+		return true
+	}
+	return false
+}
+
+//loop follows callflow modules through existing callflows and reports the first one leading back
+func (v *callflowValidator) loop(field string, cf *Callflow, n *FlowNode, existing []Callflow) {
+	refs := n.References()
+	if len(refs) == 0 {
+		v.add(field+".data.id", "id is required by %s module", n.Module)
+		return
+	}
+
+	flows := make(map[string]CallflowAction, len(existing))
+	for _, other := range existing {
+		if other.Flow.Module != "" {
+			flows[other.ID] = other.Flow
+		}
+	}
+	if cf.ID != "" {
+		flows[cf.ID] = cf.Flow
+	}
+
+	start := refs[0]
+	chain := []string{start}
+	onChain := map[string]bool{start: true}
+	if cf.ID != "" {
+		chain = append([]string{cf.ID}, chain...)
+		onChain[cf.ID] = true
+		if start == cf.ID {
+			v.add(field+".data.id", "callflow refers to itself")
+			return
+		}
+	}
+
+	visited := make(map[string]bool)
+	var follow func(id string) bool
+	follow = func(id string) bool {
+		if visited[id] {
+			return false
+		}
+		visited[id] = true
+
+		action, ok := flows[id]
+		if !ok {
+			return false
+		}
+		flow, err := DecodeFlow(action)
+		if err != nil {
+			return false
+		}
+
+		found := false
+		flow.Walk(func(path []string, next *FlowNode) error {
+			if next.Module != ModuleCallflow || found {
+				return nil
+			}
+			for _, ref := range next.References() {
+				if onChain[ref] {
+					chain = append(chain, ref)
+					found = true
+					return nil
+				}
+
+				chain = append(chain, ref)
+				onChain[ref] = true
+				if follow(ref) {
+					found = true
+					return nil
+				}
+				chain = chain[:len(chain)-1]
+				delete(onChain, ref)
+			}
+			return nil
+		})
+
+		return found
+	}
+
+	if follow(start) {
+		v.add(field+".data.id", "callflows loop: %s", strings.Join(chain, " -> "))
+	}
+}
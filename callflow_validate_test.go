@@ -0,0 +1,163 @@
+package kazooapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/sashker/kazoo-go/callflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func validationIssues(t *testing.T, err error) map[string]string {
+	var verr *kazooapi.CallflowValidationError
+	if !assert.True(t, errors.As(err, &verr)) {
+		return nil
+	}
+	assert.True(t, errors.Is(err, kazooapi.ErrValidation))
+
+	issues := make(map[string]string)
+	for _, issue := range verr.Issues {
+		issues[issue.Field] = issue.Message
+	}
+	return issues
+}
+
+func TestCallflow_Validate(t *testing.T) {
+	flow := callflow.Menu("0f1e2d3c4b5a69788796a5b4c3d2e1f0").
+		Option("1", callflow.RingGroup("Sales").User("d201633c77337fc469302947a56f4c44", 0, 20)).
+		Option("2", callflow.Device("a1b2c3d4e5f60718293a4b5c6d7e8f90")).
+		Then(callflow.TemporalRoute().
+			Route("b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c", callflow.Play("closed")).
+			Then(callflow.Voicemail("").Check()))
+
+	cf := mustCallflow("Main", flow, "+14158867900", "2000")
+	cf.Patterns = []string{`^\+1(\d{10})$`}
+	assert.NoError(t, cf.Validate(nil))
+
+	existing := []kazooapi.Callflow{
+		{ID: "970af9b5ad818ffc700dc9847dc2bb11", Numbers: []string{"2000"}},
+		{ID: "89ab5ec550f5727474f5bd5bfaa581e3", Patterns: []string{`^\+1(\d{10})$`}},
+	}
+	issues := validationIssues(t, cf.Validate(existing))
+	assert.Equal(t, map[string]string{
+		"numbers.1":  `number "2000" is already used by callflow 970af9b5ad818ffc700dc9847dc2bb11`,
+		"patterns.0": `pattern "^\\+1(\\d{10})$" is already used by callflow 89ab5ec550f5727474f5bd5bfaa581e3`,
+	}, issues)
+
+	//The callflow doesn't collide with its own stored version
+	cf.ID = "970af9b5ad818ffc700dc9847dc2bb11"
+	assert.NoError(t, cf.Validate(existing[:1]))
+}
+
+func TestCallflow_ValidateFlow(t *testing.T) {
+	var cf kazooapi.Callflow
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"numbers": ["2000", "2000", "20 01"],
+		"patterns": ["^*72([0-9]*$"],
+		"flow": {
+			"module": "menu",
+			"data": {"id": ""},
+			"children": {
+				"12": {"module": "user", "data": {}, "children": {}},
+				"_": {
+					"module": "temporal_route",
+					"data": {"rules": ["b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c"]},
+					"children": {
+						"ffffffffffffffffffffffffffffffff": {"module": "ring_group", "data": {"endpoints": [{"id": "d201633c77337fc469302947a56f4c44", "endpoint_type": "phone"}]}, "children": {}},
+						"_": {
+							"module": "device",
+							"data": {"id": "a1b2c3d4e5f60718293a4b5c6d7e8f90", "timeout": "20"},
+							"children": {
+								"1": {"module": "response", "data": {"code": 1000}, "children": {}},
+								"_": {"module": "pivot", "data": {"voice_url": "ftp://example.com/"}, "children": {}}
+							}
+						}
+					}
+				}
+			}
+		}
+	}`), &cf))
+
+	issues := validationIssues(t, cf.Validate(nil))
+	assert.Len(t, issues, 12)
+	for _, field := range []string{
+		"numbers.1",
+		"numbers.2",
+		"patterns.0",
+		"flow.data.id",
+		"flow.children.12",
+		"flow.children.12.data.id",
+		"flow.children._.children.ffffffffffffffffffffffffffffffff",
+		"flow.children._.children.ffffffffffffffffffffffffffffffff.data.endpoints.0.endpoint_type",
+		"flow.children._.children._.data",
+		"flow.children._.children._.children.1",
+		"flow.children._.children._.children.1.data.code",
+		"flow.children._.children._.children._.data.voice_url",
+	} {
+		assert.Contains(t, issues, field)
+	}
+	assert.Contains(t, issues["flow.children._.children._.data"], "doesn't match device module")
+
+	issues = validationIssues(t, (&kazooapi.Callflow{}).Validate(nil))
+	assert.Contains(t, issues, "numbers")
+	assert.Contains(t, issues, "flow.module")
+}
+
+func TestCallflow_ValidateLoops(t *testing.T) {
+	existing := []kazooapi.Callflow{
+		*mustCallflow("B", callflow.Raw("callflow", map[string]string{"id": "cccccccccccccccccccccccccccccccc"}), "2001"),
+		*mustCallflow("C", callflow.TTS("Transferring").Then(callflow.Raw("callflow", map[string]string{"id": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"})), "2002"),
+		{ID: "dddddddddddddddddddddddddddddddd", Numbers: []string{"2003"}},
+	}
+	existing[0].ID = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	existing[1].ID = "cccccccccccccccccccccccccccccccc"
+
+	cf := mustCallflow("A", callflow.Raw("callflow", map[string]string{"id": "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"}), "2000")
+	cf.ID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	issues := validationIssues(t, cf.Validate(existing))
+	assert.Equal(t, map[string]string{
+		"flow.data.id": "callflows loop: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa -> bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb -> cccccccccccccccccccccccccccccccc -> aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	}, issues)
+
+	cf.ID = "ffffffffffffffffffffffffffffffff"
+	cf.Flow = callflow.Raw("callflow", map[string]string{"id": "dddddddddddddddddddddddddddddddd"}).Action()
+	assert.NoError(t, cf.Validate(existing))
+
+	cf.Flow = callflow.Raw("callflow", map[string]string{"id": "ffffffffffffffffffffffffffffffff"}).Action()
+	issues = validationIssues(t, cf.Validate(existing))
+	assert.Equal(t, "callflow refers to itself", issues["flow.data.id"])
+}
+
+func TestCallflow_ValidateMonsterUIFlow(t *testing.T) {
+	//Monster UI keys temporal route branches by rule ids without listing them in data
+	var cf kazooapi.Callflow
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"numbers": ["+14158867900"],
+		"patterns": ["^\\+1(?!900)(\\d{10})$"],
+		"flow": {
+			"module": "temporal_route",
+			"data": {"timezone": "America/Los_Angeles"},
+			"children": {
+				"b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c": {"module": "play", "data": {"id": "closed"}, "children": {}},
+				"_": {"module": "user", "data": {"id": "d201633c77337fc469302947a56f4c44"}, "children": {}}
+			}
+		}
+	}`), &cf))
+
+	//The lookahead is fine for Kazoo's PCRE, it's reported as a warning only
+	assert.NoError(t, cf.Validate(nil))
+	if issues := cf.Inspect(nil); assert.Len(t, issues, 1) {
+		assert.Equal(t, "patterns.0", issues[0].Field)
+		assert.True(t, issues[0].Warning)
+	}
+
+	cf.Flow.Children.(map[string]interface{})["closed"] = map[string]interface{}{"module": "play", "data": map[string]interface{}{"id": "closed"}}
+	issues := validationIssues(t, cf.Validate(nil))
+	assert.Equal(t, map[string]string{
+		"patterns.0":           `pattern "^\\+1(?!900)(\\d{10})$" can't be checked: error parsing regexp: invalid or unsupported Perl syntax: ` + "`(?!`",
+		"flow.children.closed": `temporal route branch "closed" isn't keyed by a rule id`,
+	}, issues)
+}
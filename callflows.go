@@ -2,7 +2,6 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
 )
 
 type CallflowsAPIService service
@@ -30,13 +29,14 @@ type (
 		Name   string `json:"name,omitempty"`
 	}
 
+	//Metaflow configures in-call feature codes: after the binding digit (* by default)
+	//the dialed number is looked up in Numbers and then in Patterns
 	Metaflow struct {
-		BindingDigit string      `json:"binding_digit,omitempty"`
-		DigitTimeout int         `json:"digit_timeout,omitempty"`
-		ListenOn     string      `json:"listen_on,omitempty"`
-		Numbers      []string    `json:"numbers,omitempty"`
-		Patterns     []string    `json:"patterns,omitempty"`
-		FeatureCode  FeatureCode `json:"featurecode,omitempty"`
+		BindingDigit string                    `json:"binding_digit,omitempty"`
+		DigitTimeout int                       `json:"digit_timeout,omitempty"` //in milliseconds
+		ListenOn     string                    `json:"listen_on,omitempty"`     //both, self or peer
+		Numbers      map[string]MetaflowAction `json:"numbers,omitempty"`
+		Patterns     map[string]MetaflowAction `json:"patterns,omitempty"`
 	}
 
 	MetaflowAction struct {
@@ -76,101 +76,115 @@ type (
 )
 
 func (api *CallflowsAPIService) CreateCallflow(ctx context.Context, acc string, input *Callflow) (cf *Callflow, err error) {
-	var response struct {
-		Data Callflow `json:"data"`
-		ResponseEnvelope
-	}
+	cf, _, err = do[*Callflow](ctx, api.client, endpoint{
+		service:   "CallflowsAPI",
+		operation: "CreateCallflow",
+		method:    "PUT",
+		path:      path("accounts", acc, "callflows"),
+		body:      input,
+	})
+
+	return cf, err
+}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/callflows",
-	}
+//ListCallflows shows callflows belong to a given account
+func (api *CallflowsAPIService) ListCallflows(ctx context.Context, acc string, disablePagination bool) (cfs []Callflow, err error) {
+	cfs, _, err = do[[]Callflow](ctx, api.client, endpoint{
+		service:   "CallflowsAPI",
+		operation: "ListCallflows",
+		method:    "GET",
+		path:      path("accounts", acc, "callflows"),
+		query:     listQuery(disablePagination),
+	})
+
+	return cfs, err
+}
 
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
+//ListCallflowsPaginator returns a paginator over callflows of the account,
+//zero pageSize means the server's default page size
+func (api *CallflowsAPIService) ListCallflowsPaginator(acc string, pageSize int64) *Paginator[Callflow] {
+	return listPaginator[Callflow](api.client, "CallflowsAPI", "ListCallflowsPaginator", path("accounts", acc, "callflows"), pageSize)
+}
 
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
+//GetCallflow returns the callflow document
+func (api *CallflowsAPIService) GetCallflow(ctx context.Context, acc, id string) (cf *Callflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
+	if id == "" {
+		return nil, reportError("callflow id is required field")
 	}
 
-	params.PostBody = body
+	cf, _, err = do[*Callflow](ctx, api.client, endpoint{
+		service:   "CallflowsAPI",
+		operation: "GetCallflow",
+		method:    "GET",
+		path:      path("accounts", acc, "callflows", id),
+	})
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return cf, err
+}
 
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
+//UpdateCallflow replaces the callflow document with input,
+//fields missing in input are removed from the document
+func (api *CallflowsAPIService) UpdateCallflow(ctx context.Context, acc, id string, input *Callflow) (cf *Callflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if id == "" {
+		return nil, reportError("callflow id is required field")
 	}
 
-	cf = &response.Data
+	cf, _, err = do[*Callflow](ctx, api.client, endpoint{
+		service:   "CallflowsAPI",
+		operation: "UpdateCallflow",
+		method:    "POST",
+		path:      path("accounts", acc, "callflows", id),
+		body:      input,
+	})
 
-	return cf, nil
+	return cf, err
 }
 
-//ListCallflows shows callflows belong to a given account
-func (api *CallflowsAPIService) ListCallflows(ctx context.Context, acc string, disablePagination bool) (cfs []Callflow, err error) {
-	var response struct {
-		Data []Callflow `json:"data"`
-		ResponseEnvelope
-	}
-
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/callflows",
+//PatchCallflow merges input into the callflow document leaving other fields untouched
+func (api *CallflowsAPIService) PatchCallflow(ctx context.Context, acc, id string, input map[string]interface{}) (cf *Callflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	if disablePagination {
-		params.QueryParams = map[string][]string{"paginate": []string{"false"}}
+	if id == "" {
+		return nil, reportError("callflow id is required field")
 	}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+	cf, _, err = do[*Callflow](ctx, api.client, endpoint{
+		service:   "CallflowsAPI",
+		operation: "PatchCallflow",
+		method:    "PATCH",
+		path:      path("accounts", acc, "callflows", id),
+		body:      input,
+	})
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return cf, err
+}
 
-	if resp.StatusCode >= 300 {
-		//bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("status code: %d", resp.StatusCode)
+//DeleteCallflow removes the callflow and returns the deleted document
+func (api *CallflowsAPIService) DeleteCallflow(ctx context.Context, acc, id string) (cf *Callflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if id == "" {
+		return nil, reportError("callflow id is required field")
 	}
 
-	cfs = response.Data
+	cf, _, err = do[*Callflow](ctx, api.client, endpoint{
+		service:   "CallflowsAPI",
+		operation: "DeleteCallflow",
+		method:    "DELETE",
+		path:      path("accounts", acc, "callflows", id),
+	})
 
-	return cfs, nil
+	return cf, err
 }
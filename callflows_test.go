@@ -353,3 +353,37 @@ func TestCallflowsAPIService_ListCallflowsAPIService(t *testing.T) {
 	assert.Equal(t, "9e1e5f9031e9e8446f54da9df47680a0", resp[0].ID, "ID's should be equal")
 	assert.ElementsMatch(t, []string{}, resp[0].Numbers, "Should be empty list")
 }
+
+func TestCallflowsAPIService_CRUD(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"9e1e5f9031e9e8446f54da9df47680a0","name":"Main","numbers":["+74955555555"],"flow":{"module":"user","data":{"id":"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4"},"children":{}}}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/callflows/9e1e5f9031e9e8446f54da9df47680a0"
+
+	cf, err := clt.CallflowsAPI.GetCallflow(ctx, "qe0ade400015367f0069d6dfbdca072a", "9e1e5f9031e9e8446f54da9df47680a0")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", cf.Flow.Module)
+	assert.Equal(t, []string{"+74955555555"}, cf.Numbers)
+	assertRequest(t, last(), "GET", path)
+
+	cf.Name = "Main line"
+	_, err = clt.CallflowsAPI.UpdateCallflow(ctx, "qe0ade400015367f0069d6dfbdca072a", cf.ID, cf)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "POST", path)
+	assert.Equal(t, "Main line", last().Data.(map[string]interface{})["name"])
+
+	_, err = clt.CallflowsAPI.PatchCallflow(ctx, "qe0ade400015367f0069d6dfbdca072a", cf.ID, map[string]interface{}{"numbers": []string{"+74955555556"}})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", path)
+	assert.Equal(t, map[string]interface{}{"numbers": []interface{}{"+74955555556"}}, last().Data)
+
+	_, err = clt.CallflowsAPI.DeleteCallflow(ctx, "qe0ade400015367f0069d6dfbdca072a", cf.ID)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "DELETE", path)
+
+	_, err = clt.CallflowsAPI.DeleteCallflow(ctx, "", cf.ID)
+	assert.EqualError(t, err, "account id is required field")
+}
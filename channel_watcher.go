@@ -0,0 +1,192 @@
+package kazooapi
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+//Default settings of ChannelWatcher
+const (
+	DefaultChannelPollInterval = 2 * time.Second
+	DefaultChannelPollBackoff  = time.Minute
+	defaultChannelEventsBuffer = 64
+)
+
+//ChannelEventType is the kind of change ChannelWatcher noticed
+type ChannelEventType string
+
+const (
+	ChannelCreated   ChannelEventType = "created"
+	ChannelAnswered  ChannelEventType = "answered"
+	ChannelBridged   ChannelEventType = "bridged"
+	ChannelDestroyed ChannelEventType = "destroyed"
+)
+
+//ChannelEvent is a change of a channel between two polls
+type ChannelEvent struct {
+	Type ChannelEventType
+	//Channel is the state of the channel when the change was noticed,
+	//for destroyed channels it's the last state seen
+	Channel Channel
+	//At is the time of the poll which noticed the change
+	At time.Time
+}
+
+//ChannelWatcherOptions controls ChannelWatcher, zero values mean defaults
+type ChannelWatcherOptions struct {
+	//Interval between polls
+	Interval time.Duration
+	//MaxBackoff caps the delay after failed polls, which doubles the interval with every failure
+	MaxBackoff time.Duration
+	//Buffer is the capacity of the events channel
+	Buffer int
+	//SkipExisting makes the first poll a baseline, so channels which were up
+	//before the watcher started don't get created events
+	SkipExisting bool
+	//OnError is called with every failed poll and the delay before the next one
+	OnError func(err error, delay time.Duration)
+}
+
+//ChannelWatcher polls channels of an account and turns differences between
+//snapshots into events. Channels are matched by UUID, every event is sent
+//once per channel, e.g. a channel is reported answered only once
+type ChannelWatcher struct {
+	api     *ChannelsAPIService
+	acc     string
+	opts    ChannelWatcherOptions
+	events  chan ChannelEvent
+	running int32
+
+	known map[string]*watchedChannel
+}
+
+type watchedChannel struct {
+	ch       Channel
+	answered bool
+	bridged  string
+}
+
+//NewChannelWatcher returns a watcher of the account's channels, it starts polling with Run
+func (chanapi *ChannelsAPIService) NewChannelWatcher(acc string, opts *ChannelWatcherOptions) *ChannelWatcher {
+	w := &ChannelWatcher{api: chanapi, acc: acc, known: make(map[string]*watchedChannel)}
+	if opts != nil {
+		w.opts = *opts
+	}
+
+	if w.opts.Interval <= 0 {
+		w.opts.Interval = DefaultChannelPollInterval
+	}
+	if w.opts.MaxBackoff <= 0 {
+		w.opts.MaxBackoff = DefaultChannelPollBackoff
+	}
+	if w.opts.Buffer <= 0 {
+		w.opts.Buffer = defaultChannelEventsBuffer
+	}
+
+	w.events = make(chan ChannelEvent, w.opts.Buffer)
+
+	return w
+}
+
+//Events returns the channel events are delivered on, it's closed when Run returns
+func (w *ChannelWatcher) Events() <-chan ChannelEvent {
+	return w.events
+}
+
+//Run polls channels until ctx is done and returns ctx.Err().
+//A watcher runs only once, later calls return an error right away
+func (w *ChannelWatcher) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&w.running, 0, 1) {
+		return reportError("channel watcher has already been run")
+	}
+	defer close(w.events)
+
+	failures := 0
+	baseline := w.opts.SkipExisting
+
+	for {
+		at := time.Now()
+		channels, err := w.api.ListAccountChannels(ctx, w.acc)
+
+		delay := w.opts.Interval
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			failures++
+			for i := 0; i < failures && delay < w.opts.MaxBackoff; i++ {
+				delay *= 2
+			}
+			if delay > w.opts.MaxBackoff {
+				delay = w.opts.MaxBackoff
+			}
+			if w.opts.OnError != nil {
+				w.opts.OnError(err, delay)
+			}
+		default:
+			failures = 0
+			for _, ev := range w.diff(channels, at) {
+				if baseline {
+					continue
+				}
+				select {
+				case w.events <- ev:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			baseline = false
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+//diff updates known channels with the snapshot and returns events of the changes
+func (w *ChannelWatcher) diff(channels []Channel, at time.Time) (events []ChannelEvent) {
+	seen := make(map[string]bool, len(channels))
+
+	for _, ch := range channels {
+		if ch.UUID == "" || seen[ch.UUID] {
+			continue
+		}
+		seen[ch.UUID] = true
+
+		known, ok := w.known[ch.UUID]
+		if !ok {
+			known = &watchedChannel{}
+			w.known[ch.UUID] = known
+			events = append(events, ChannelEvent{Type: ChannelCreated, Channel: ch, At: at})
+		}
+		known.ch = ch
+
+		if ch.Answered && !known.answered {
+			known.answered = true
+			events = append(events, ChannelEvent{Type: ChannelAnswered, Channel: ch, At: at})
+		}
+
+		if ch.OtherLeg != "" && ch.OtherLeg != known.bridged {
+			known.bridged = ch.OtherLeg
+			events = append(events, ChannelEvent{Type: ChannelBridged, Channel: ch, At: at})
+		}
+	}
+
+	var gone []string
+	for uuid := range w.known {
+		if !seen[uuid] {
+			gone = append(gone, uuid)
+		}
+	}
+	sort.Strings(gone)
+
+	for _, uuid := range gone {
+		events = append(events, ChannelEvent{Type: ChannelDestroyed, Channel: w.known[uuid].ch, At: at})
+		delete(w.known, uuid)
+	}
+
+	return events
+}
@@ -0,0 +1,131 @@
+package kazooapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+//MockChannelsServer answers polls of account channels with snapshots one by one,
+//empty snapshot means a failed poll, the last snapshot is repeated
+func MockChannelsServer(t *testing.T, snapshots ...string) *httptest.Server {
+	var (
+		mu   sync.Mutex
+		poll int
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/channels", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		snapshot := snapshots[poll]
+		if poll < len(snapshots)-1 {
+			poll++
+		}
+		mu.Unlock()
+
+		if snapshot == "" {
+			w.WriteHeader(500)
+			io.WriteString(w, `{"data":{},"error":"500","message":"internal_server_error","status":"error"}`)
+			return
+		}
+		io.WriteString(w, `{"data":`+snapshot+`,"status":"success"}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestChannelWatcher_Run(t *testing.T) {
+	srv := MockChannelsServer(t,
+		`[{"uuid":"a-leg","answered":false}]`,
+		`[{"uuid":"a-leg","answered":true},{"uuid":"b-leg","answered":true,"other_leg":"a-leg"}]`,
+		``,
+		``,
+		`[{"uuid":"a-leg","answered":true,"other_leg":"b-leg"},{"uuid":"a-leg","answered":true,"other_leg":"b-leg"}]`,
+		`[{"uuid":"a-leg","answered":false,"other_leg":"b-leg"}]`,
+		`[]`,
+	)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	var delays []time.Duration
+	w := acc.Channels.Watcher(&kazooapi.ChannelWatcherOptions{
+		Interval:   time.Millisecond,
+		MaxBackoff: 3 * time.Millisecond,
+		OnError:    func(err error, delay time.Duration) { delays = append(delays, delay) },
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	var got []string
+	for ev := range w.Events() {
+		got = append(got, string(ev.Type)+" "+ev.Channel.UUID)
+		assert.False(t, ev.At.IsZero())
+		if ev.Type == kazooapi.ChannelDestroyed && ev.Channel.UUID == "a-leg" {
+			assert.Equal(t, "b-leg", ev.Channel.OtherLeg)
+			cancel()
+		}
+	}
+
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, []string{
+		"created a-leg",
+		"answered a-leg",
+		"created b-leg",
+		"answered b-leg",
+		"bridged b-leg",
+		"bridged a-leg",
+		"destroyed b-leg",
+		"destroyed a-leg",
+	}, got)
+	assert.Equal(t, []time.Duration{2 * time.Millisecond, 3 * time.Millisecond}, delays)
+
+	assert.Error(t, w.Run(context.Background()))
+}
+
+func TestChannelWatcher_SkipExisting(t *testing.T) {
+	srv := MockChannelsServer(t,
+		`[{"uuid":"a-leg","answered":true}]`,
+		`[{"uuid":"a-leg","answered":true,"other_leg":"b-leg"},{"uuid":"b-leg"}]`,
+	)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	w := clt.ChannelsAPI.NewChannelWatcher("4dee5c1bef3ace50911c9917c50c9f80", &kazooapi.ChannelWatcherOptions{
+		Interval:     time.Millisecond,
+		SkipExisting: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go w.Run(ctx)
+
+	first := <-w.Events()
+	assert.Equal(t, kazooapi.ChannelBridged, first.Type)
+	assert.Equal(t, "a-leg", first.Channel.UUID)
+
+	second := <-w.Events()
+	assert.Equal(t, kazooapi.ChannelCreated, second.Type)
+	assert.Equal(t, "b-leg", second.Channel.UUID)
+
+	cancel()
+	for range w.Events() {
+	}
+}
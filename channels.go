@@ -6,9 +6,6 @@ package kazooapi
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"time"
 )
 
 var (
@@ -20,13 +17,15 @@ type ChannelsAPIService service
 type Channel struct {
 	Answered        bool      `json:"answered"`
 	AuthorizingID   string    `json:"authorizing_id"`
-	AuthorizingType string    `json:"authorizing_ype"`
+	AuthorizingType string    `json:"authorizing_type"`
+	BridgeID        string    `json:"bridge_id,omitempty"`
 	Destination     string    `json:"destination"`
 	Direction       string    `json:"direction"`
+	ElapsedSeconds  int64     `json:"elapsed_s,omitempty"`
 	OtherLeg        string    `json:"other_leg"`
 	OwnerID         string    `json:"owner_id"`
 	PresenceID      string    `json:"presence_id"`
-	Timestamp       time.Time `json:"timestamp"`
+	Timestamp       Timestamp `json:"timestamp"`
 	Username        string    `json:"username"`
 	UUID            string    `json:"uuid"`
 }
@@ -35,82 +34,170 @@ type ChannelList struct {
 	List []Channel
 }
 
+//Request bodies of channel actions
+type (
+	ChannelTransfer struct {
+		Target       string `json:"target"` //number or extension the channel is transferred to
+		TakebackDTMF string `json:"takeback_dtmf,omitempty"`
+		MOH          string `json:"moh,omitempty"`
+	}
+
+	ChannelEavesdrop struct {
+		ID   string `json:"id"`             //user or device which is rung to listen to the channel
+		Mode string `json:"mode,omitempty"` //listen - is default value, whisper, full
+	}
+
+	ChannelIntercept struct {
+		TargetType string `json:"target_type"` //user or device picking up the channel
+		TargetID   string `json:"target_id"`
+	}
+)
+
 //ListGlobalChannels returns a global list of channels
 //for the whole cluster
 //It should explicitely enabled by an admin
 //system_config->crossbar.channels->system_wide_channels_list = true
 func (chanapi *ChannelsAPIService) ListGlobalChannels(ctx context.Context) (chl []Channel, err error) {
-		params := Request{
-			CTX:    ctx,
-			Method: "GET",
-			Path:   chanapi.client.cfg.BasePath + "/channels",
-		}
-
-		req, err := chanapi.client.prepareRequest(&params)
-		if err != nil {
-			return nil, reportError("Can't prepare a request %s", err)
-		}
+	chl, _, err = do[[]Channel](ctx, chanapi.client, endpoint{
+		service:   "ChannelsAPI",
+		operation: "ListGlobalChannels",
+		method:    "GET",
+		path:      path("channels"),
+	})
 
-		resp, err := chanapi.client.callAPI(ctx, req)
-		if err != nil || resp == nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
+	return chl, err
+}
 
-		if resp.StatusCode >= 300 {
-			bodyBytes, _ := ioutil.ReadAll(resp.Body)
-			return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
-		}
+//ListAccountChannels returns live channels of the account
+func (chanapi *ChannelsAPIService) ListAccountChannels(ctx context.Context, acc string) (chl []Channel, err error) {
+	chl, _, err = do[[]Channel](ctx, chanapi.client, endpoint{
+		service:   "ChannelsAPI",
+		operation: "ListAccountChannels",
+		method:    "GET",
+		path:      path("accounts", acc, "channels"),
+	})
 
-		env := RequestEnvelope{}
+	return chl, err
+}
 
-		decoder := json.NewDecoder(resp.Body)
+//GetChannel returns a live channel of the account
+func (chanapi *ChannelsAPIService) GetChannel(ctx context.Context, acc, uuid string) (ch *Channel, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
 
-		decErr := decoder.Decode(&env)
-		if decErr != nil {
-			return nil, reportError("Can't decode response: %v", decErr)
-		}
+	if uuid == "" {
+		return nil, reportError("channel uuid is required field")
+	}
 
-		fmt.Println(&env.Data)
+	ch, _, err = do[*Channel](ctx, chanapi.client, endpoint{
+		service:   "ChannelsAPI",
+		operation: "GetChannel",
+		method:    "GET",
+		path:      path("accounts", acc, "channels", uuid),
+	})
 
-		return chl, err
+	return ch, err
+}
 
+//HangupChannel hangs up the channel
+func (chanapi *ChannelsAPIService) HangupChannel(ctx context.Context, acc, uuid string) error {
+	return chanapi.channelAction(ctx, "HangupChannel", "POST", acc, uuid, "hangup", nil)
 }
 
-func (chanapi *ChannelsAPIService) ListAccountChannels(ctx context.Context, acc string) (chl []Channel, err error) {
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   chanapi.client.cfg.BasePath + "/accounts/" + acc + "/channels",
+//TransferChannel transfers the channel to another number
+func (chanapi *ChannelsAPIService) TransferChannel(ctx context.Context, acc, uuid string, input *ChannelTransfer) error {
+	if input == nil || input.Target == "" {
+		return reportError("transfer target is required field")
 	}
 
-	req, err := chanapi.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
+	return chanapi.channelAction(ctx, "TransferChannel", "POST", acc, uuid, "transfer", input)
+}
+
+//HoldChannel puts the channel on hold, moh is the media played while it's held (optional)
+func (chanapi *ChannelsAPIService) HoldChannel(ctx context.Context, acc, uuid, moh string) error {
+	var input interface{}
+	if moh != "" {
+		input = map[string]string{"moh": moh}
 	}
 
-	resp, err := chanapi.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+	return chanapi.channelAction(ctx, "HoldChannel", "POST", acc, uuid, "hold", input)
+}
+
+//UnholdChannel takes the channel off hold
+func (chanapi *ChannelsAPIService) UnholdChannel(ctx context.Context, acc, uuid string) error {
+	return chanapi.channelAction(ctx, "UnholdChannel", "POST", acc, uuid, "unhold", nil)
+}
+
+//BreakChannel stops the callflow currently executed by the channel
+func (chanapi *ChannelsAPIService) BreakChannel(ctx context.Context, acc, uuid string) error {
+	return chanapi.channelAction(ctx, "BreakChannel", "POST", acc, uuid, "break", nil)
+}
+
+//EavesdropChannel rings the user or device and lets it listen to the channel
+func (chanapi *ChannelsAPIService) EavesdropChannel(ctx context.Context, acc, uuid string, input *ChannelEavesdrop) error {
+	if input == nil || input.ID == "" {
+		return reportError("eavesdropping endpoint id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+	return chanapi.channelAction(ctx, "EavesdropChannel", "PUT", acc, uuid, "eavesdrop", input)
+}
+
+//InterceptChannel makes the user or device pick up the ringing channel
+func (chanapi *ChannelsAPIService) InterceptChannel(ctx context.Context, acc, uuid string, input *ChannelIntercept) error {
+	if input == nil || input.TargetID == "" {
+		return reportError("intercepting endpoint id is required field")
 	}
 
-	env := RequestEnvelope{}
+	return chanapi.channelAction(ctx, "InterceptChannel", "PUT", acc, uuid, "intercept", input)
+}
 
-	decoder := json.NewDecoder(resp.Body)
+//MetaflowChannel runs the metaflow action (e.g. record_call) on the channel
+func (chanapi *ChannelsAPIService) MetaflowChannel(ctx context.Context, acc, uuid string, action MetaflowAction) error {
+	if action.Module == "" {
+		return reportError("metaflow module is required field")
+	}
 
-	decErr := decoder.Decode(&env)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	input := map[string]interface{}{"module": action.Module}
+	if action.Data != nil {
+		input["data"] = action.Data
 	}
 
-	fmt.Println(&env.Data)
+	return chanapi.channelAction(ctx, "MetaflowChannel", "PUT", acc, uuid, "metaflow", input)
+}
 
-	return chl, err
+//channelAction sends {"action": action} merged with fields of input to the channel.
+//Actions are never retried: Kazoo might have acted before a proxy answered 502/504,
+//and a replayed transfer or eavesdrop would run twice
+func (chanapi *ChannelsAPIService) channelAction(ctx context.Context, operation, method, acc, uuid, action string, input interface{}) error {
+	if acc == "" {
+		return reportError("account id is required field")
+	}
 
+	if uuid == "" {
+		return reportError("channel uuid is required field")
+	}
+
+	body := map[string]interface{}{}
+	if input != nil {
+		raw, err := json.Marshal(input)
+		if err != nil {
+			return reportError("can't marshall body for request: %v", err)
+		}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			return reportError("can't marshall body for request: %v", err)
+		}
+	}
+	body["action"] = action
+
+	_, _, err := do[json.RawMessage](ctx, chanapi.client, endpoint{
+		service:   "ChannelsAPI",
+		operation: operation,
+		method:    method,
+		path:      path("accounts", acc, "channels", uuid),
+		body:      body,
+		noRetry:   true,
+	})
+
+	return err
 }
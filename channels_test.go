@@ -1 +1,172 @@
-package kazooapi
+package kazooapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const liveChannel = `{
+		"answered": true,
+		"authorizing_id": "a1b2c3d4e5f60718293a4b5c6d7e8f90",
+		"authorizing_type": "device",
+		"bridge_id": "3a1c6d2e-7b0f-11e9-8f2c-0242ac110002",
+		"destination": "2001",
+		"direction": "inbound",
+		"elapsed_s": 42,
+		"other_leg": "5b2d7e3f-7b0f-11e9-8f2c-0242ac110002",
+		"owner_id": "d201633c77337fc469302947a56f4c44",
+		"presence_id": "2000@office.pbx.example.com",
+		"timestamp": 63727878993,
+		"username": "user_k2j3h4",
+		"uuid": "3a1c6d2e-7b0f-11e9-8f2c-0242ac110002"
+	}`
+
+func TestChannelsAPIService_ListAccountChannels(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, "["+liveChannel+"]")
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	chl, err := clt.ChannelsAPI.ListAccountChannels(ctx, "4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/channels")
+
+	if assert.Len(t, chl, 1) {
+		assert.True(t, chl[0].Answered)
+		assert.Equal(t, "device", chl[0].AuthorizingType)
+		assert.Equal(t, "5b2d7e3f-7b0f-11e9-8f2c-0242ac110002", chl[0].OtherLeg)
+		assert.Equal(t, int64(42), chl[0].ElapsedSeconds)
+		assert.Equal(t, time.Date(2019, 6, 16, 4, 36, 33, 0, time.UTC), chl[0].Timestamp.Time().UTC())
+	}
+}
+
+func TestChannelsAPIService_Actions(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, liveChannel)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	const channelPath = "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/channels/3a1c6d2e-7b0f-11e9-8f2c-0242ac110002"
+	const uuid = "3a1c6d2e-7b0f-11e9-8f2c-0242ac110002"
+
+	ch, err := acc.Channels.Get(ctx, uuid)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", channelPath)
+	assert.Equal(t, uuid, ch.UUID)
+
+	cases := []struct {
+		name   string
+		call   func() error
+		method string
+		data   map[string]interface{}
+	}{
+		{"hangup", func() error { return acc.Channels.Hangup(ctx, uuid) }, "POST",
+			map[string]interface{}{"action": "hangup"}},
+		{"transfer", func() error {
+			return acc.Channels.Transfer(ctx, uuid, &kazooapi.ChannelTransfer{Target: "2002", TakebackDTMF: "*1"})
+		}, "POST", map[string]interface{}{"action": "transfer", "target": "2002", "takeback_dtmf": "*1"}},
+		{"hold", func() error { return acc.Channels.Hold(ctx, uuid, "") }, "POST",
+			map[string]interface{}{"action": "hold"}},
+		{"hold with music", func() error { return acc.Channels.Hold(ctx, uuid, "b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c") }, "POST",
+			map[string]interface{}{"action": "hold", "moh": "b2c8b4ea2a3b1f0e9d8c7b6a5f4e3d2c"}},
+		{"unhold", func() error { return acc.Channels.Unhold(ctx, uuid) }, "POST",
+			map[string]interface{}{"action": "unhold"}},
+		{"break", func() error { return acc.Channels.Break(ctx, uuid) }, "POST",
+			map[string]interface{}{"action": "break"}},
+		{"eavesdrop", func() error {
+			return acc.Channels.Eavesdrop(ctx, uuid, &kazooapi.ChannelEavesdrop{ID: "a1b2c3d4e5f60718293a4b5c6d7e8f90", Mode: "whisper"})
+		}, "PUT", map[string]interface{}{"action": "eavesdrop", "id": "a1b2c3d4e5f60718293a4b5c6d7e8f90", "mode": "whisper"}},
+		{"intercept", func() error {
+			return acc.Channels.Intercept(ctx, uuid, &kazooapi.ChannelIntercept{TargetType: "user", TargetID: "d201633c77337fc469302947a56f4c44"})
+		}, "PUT", map[string]interface{}{"action": "intercept", "target_type": "user", "target_id": "d201633c77337fc469302947a56f4c44"}},
+		{"metaflow", func() error {
+			return acc.Channels.Metaflow(ctx, uuid, kazooapi.NewMetaflowAction(kazooapi.MetaflowRecordCall, &kazooapi.RecordCallModule{Action: "start"}))
+		}, "PUT", map[string]interface{}{"action": "metaflow", "module": "record_call", "data": map[string]interface{}{"action": "start"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.NoError(t, c.call())
+			req := last()
+			assertRequest(t, req, c.method, channelPath)
+			assert.Equal(t, c.data, req.Data)
+		})
+	}
+
+	assert.Error(t, acc.Channels.Transfer(ctx, uuid, &kazooapi.ChannelTransfer{}))
+	assert.Error(t, acc.Channels.Eavesdrop(ctx, uuid, nil))
+	assert.Error(t, acc.Channels.Hangup(ctx, ""))
+}
+
+func TestChannelsAPIService_ActionsAreNotRetried(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/channels/3a1c6d2e-7b0f-11e9-8f2c-0242ac110002", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(502)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	//Even the policy replaying any write doesn't replay channel actions
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+	cfg.RetryPolicy.InitialBackoff = time.Millisecond
+	cfg.RetryPolicy.RetryNonIdempotent = true
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	const acc = "4dee5c1bef3ace50911c9917c50c9f80"
+	const uuid = "3a1c6d2e-7b0f-11e9-8f2c-0242ac110002"
+
+	for name, call := range map[string]func() error{
+		"hangup": func() error { return clt.ChannelsAPI.HangupChannel(ctx, acc, uuid) },
+		"transfer": func() error {
+			return clt.ChannelsAPI.TransferChannel(ctx, acc, uuid, &kazooapi.ChannelTransfer{Target: "2002"})
+		},
+		"hold":   func() error { return clt.ChannelsAPI.HoldChannel(ctx, acc, uuid, "") },
+		"unhold": func() error { return clt.ChannelsAPI.UnholdChannel(ctx, acc, uuid) },
+		"break":  func() error { return clt.ChannelsAPI.BreakChannel(ctx, acc, uuid) },
+		"eavesdrop": func() error {
+			return clt.ChannelsAPI.EavesdropChannel(ctx, acc, uuid, &kazooapi.ChannelEavesdrop{ID: "a1b2c3d4e5f60718293a4b5c6d7e8f90"})
+		},
+		"intercept": func() error {
+			return clt.ChannelsAPI.InterceptChannel(ctx, acc, uuid, &kazooapi.ChannelIntercept{TargetID: "a1b2c3d4e5f60718293a4b5c6d7e8f90"})
+		},
+		"metaflow": func() error {
+			return clt.ChannelsAPI.MetaflowChannel(ctx, acc, uuid, kazooapi.NewMetaflowAction(kazooapi.MetaflowRecordCall, &kazooapi.RecordCallModule{Action: "start"}))
+		},
+	} {
+		atomic.StoreInt32(&hits, 0)
+
+		var apiErr *kazooapi.APIError
+		if assert.True(t, errors.As(call(), &apiErr), name) {
+			assert.Equal(t, 502, apiErr.StatusCode, name)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits), name)
+	}
+}
@@ -2,8 +2,7 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"net/url"
 )
 
 type ClicktocallAPIService service
@@ -72,55 +71,22 @@ type (
 
 //GetClicktocall fetches parameters of selected clicktocall
 func (api *ClicktocallAPIService) GetClicktocall(ctx context.Context, acc, id string) (c2c *Clicktocall, err error) {
-	var response struct {
-		Data Clicktocall `json:"data"`
-		ResponseEnvelope
-	}
-
 	if id == "" {
 		return nil, reportError("clicktocall id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/clicktocall/" + id,
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
-
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
-	}
-
-	c2c = &response.Data
+	c2c, _, err = do[*Clicktocall](ctx, api.client, endpoint{
+		service:   "ClicktocallAPI",
+		operation: "GetClicktocall",
+		method:    "GET",
+		path:      path("accounts", acc, "clicktocall", id),
+	})
 
-	return c2c, nil
+	return c2c, err
 }
 
 //CreateClicktocall creates a new clicktocall with given parameters
 func (api *ClicktocallAPIService) CreateClicktocall(ctx context.Context, acc string, input *Clicktocall) (c2c *Clicktocall, err error) {
-	var (
-		response struct {
-			Data Clicktocall `json:"data"`
-			ResponseEnvelope
-		}
-	)
-
 	if input.Name == "" {
 		return nil, reportError("Clicktocall name is required field")
 	}
@@ -129,192 +95,118 @@ func (api *ClicktocallAPIService) CreateClicktocall(ctx context.Context, acc str
 		return nil, reportError("Extension is required field")
 	}
 
-	/*if input.Realm == "" {
-		return nil, reportError("realm is required field")
-	}*/
-
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/clicktocall",
-	}
-
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
-
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
-	}
-
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
-	}
-
-	params.PostBody = body
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
-	}
-
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
-
-	c2c = &response.Data
+	c2c, _, err = do[*Clicktocall](ctx, api.client, endpoint{
+		service:   "ClicktocallAPI",
+		operation: "CreateClicktocall",
+		method:    "PUT",
+		path:      path("accounts", acc, "clicktocall"),
+		body:      input,
+	})
 
-	return c2c, nil
+	return c2c, err
 }
 
 //ListClick2Calls lists all clicktocall endpoints
 func (api *ClicktocallAPIService) ListClick2Calls(ctx context.Context, acc string, disablePagination bool) (result []Clicktocall, err error) {
-	var response struct {
-		Data []Clicktocall `json:"data"`
-		ResponseEnvelope
-	}
-
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/clicktocall",
-	}
-
-	if disablePagination {
-		params.QueryParams = map[string][]string{"paginate": []string{"false"}}
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
-	}
-
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
-
-	result = response.Data
-
-	return result, nil
+	result, _, err = do[[]Clicktocall](ctx, api.client, endpoint{
+		service:   "ClicktocallAPI",
+		operation: "ListClick2Calls",
+		method:    "GET",
+		path:      path("accounts", acc, "clicktocall"),
+		query:     listQuery(disablePagination),
+	})
+
+	return result, err
 }
 
 //ExecuteClicktocall executes non-blocking version of clicktocall
 func (api *ClicktocallAPIService) ExecuteClicktocall(ctx context.Context, acc, id, contact string) (cer *ClicktocallExecuteResponse, err error) {
-	var response struct {
-		Data ClicktocallExecuteResponse `json:"data"`
-		ResponseEnvelope
-	}
-
 	if id == "" {
 		return nil, reportError("clicktocall id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/clicktocall/" + id + "/connect?contact=" + contact,
-	}
+	cer, _, err = do[*ClicktocallExecuteResponse](ctx, api.client, endpoint{
+		service:   "ClicktocallAPI",
+		operation: "ExecuteClicktocall",
+		method:    "GET",
+		path:      path("accounts", acc, "clicktocall", id, "connect"),
+		query:     url.Values{"contact": []string{contact}},
+		//The connect is a GET, but every replay places one more call
+		noRetry: true,
+	})
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+	return cer, err
+}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+//DeleteClicktocall deletes selected clicktocall
+func (api *ClicktocallAPIService) DeleteClicktocall(ctx context.Context, acc, id string) (c2c *Clicktocall, err error) {
+	if id == "" {
+		return nil, reportError("clicktocall id is required field")
 	}
-	defer resp.Body.Close()
 
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
+	c2c, _, err = do[*Clicktocall](ctx, api.client, endpoint{
+		service:   "ClicktocallAPI",
+		operation: "DeleteClicktocall",
+		method:    "DELETE",
+		path:      path("accounts", acc, "clicktocall", id),
+	})
 
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
-	}
+	return c2c, err
+}
 
-	cer = &response.Data
+//ChangeClicktocall changes clicktocall's parameters
+//
+//Deprecated: use UpdateClicktocall
+func (api *ClicktocallAPIService) ChangeClicktocall(ctx context.Context, acc, id string, input *Clicktocall) (c2c *Clicktocall, err error) {
+	return api.UpdateClicktocall(ctx, acc, id, input)
+}
 
-	return cer, nil
+//ListClick2CallsPaginator returns a paginator over clicktocall endpoints of the account,
+//zero pageSize means the server's default page size
+func (api *ClicktocallAPIService) ListClick2CallsPaginator(acc string, pageSize int64) *Paginator[Clicktocall] {
+	return listPaginator[Clicktocall](api.client, "ClicktocallAPI", "ListClick2CallsPaginator", path("accounts", acc, "clicktocall"), pageSize)
 }
 
-//DeleteClicktocall deletes selected clicktocall
-func (api *ClicktocallAPIService) DeleteClicktocall(ctx context.Context, acc, id string) (c2c *Clicktocall, err error) {
-	var response struct {
-		Data Clicktocall `json:"data"`
-		ResponseEnvelope
+//ModifyClicktocall fetches the clicktocall, lets mutate change it and saves it
+//only if it hasn't been changed since it was fetched, fields Clicktocall doesn't model are kept.
+//On conflict the whole round is repeated with the fresh document up to DefaultConflictAttempts times.
+//A clicktocall fetched without a revision isn't saved, ErrNoRevision is returned
+func (api *ClicktocallAPIService) ModifyClicktocall(ctx context.Context, acc, id string, mutate func(c2c *Clicktocall) error) (c2c *Clicktocall, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
 	if id == "" {
 		return nil, reportError("clicktocall id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "DELETE",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/clicktocall/" + id,
-	}
+	return modifyDocument(ctx, api.client, "ClicktocallAPI", "ModifyClicktocall", path("accounts", acc, "clicktocall", id), func(c2c *Clicktocall) error {
+		if err := mutate(c2c); err != nil {
+			return err
+		}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+		if c2c.Name == "" {
+			return reportError("Clicktocall name is required field")
+		}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+		if c2c.Extension == "" {
+			return reportError("Extension is required field")
+		}
 
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
-	}
+		return nil
+	})
+}
 
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
+//UpdateClicktocall replaces the clicktocall document with input,
+//fields missing in input are removed from the document
+func (api *ClicktocallAPIService) UpdateClicktocall(ctx context.Context, acc, id string, input *Clicktocall) (c2c *Clicktocall, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	c2c = &response.Data
-
-	return c2c, nil
-}
-
-//ChangeClicktocall changes clicktocall's parameters
-func (api *ClicktocallAPIService) ChangeClicktocall(ctx context.Context, acc,id string, input *Clicktocall) (c2c *Clicktocall, err error) {
-	var (
-		response struct {
-			Data Clicktocall `json:"data"`
-			ResponseEnvelope
-		}
-	)
+	if id == "" {
+		return nil, reportError("clicktocall id is required field")
+	}
 
 	if input.Name == "" {
 		return nil, reportError("Clicktocall name is required field")
@@ -324,54 +216,34 @@ func (api *ClicktocallAPIService) ChangeClicktocall(ctx context.Context, acc,id
 		return nil, reportError("Extension is required field")
 	}
 
-	/*if input.Realm == "" {
-		return nil, reportError("realm is required field")
-	}*/
-
-	params := Request{
-		CTX:    ctx,
-		Method: "POST",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/clicktocall/" + id,
-	}
-
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
+	c2c, _, err = do[*Clicktocall](ctx, api.client, endpoint{
+		service:   "ClicktocallAPI",
+		operation: "UpdateClicktocall",
+		method:    "POST",
+		path:      path("accounts", acc, "clicktocall", id),
+		body:      input,
+	})
 
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
-	}
-
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
-	}
-
-	params.PostBody = body
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return c2c, err
+}
 
-	if resp.StatusCode >= 300 {
-		return nil, prepareError(resp)
+//PatchClicktocall merges input into the clicktocall document leaving other fields untouched
+func (api *ClicktocallAPIService) PatchClicktocall(ctx context.Context, acc, id string, input map[string]interface{}) (c2c *Clicktocall, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	err = readBody(resp, &response)
-	if err != nil {
-		return nil, reportError("Can't decode response: %v", err)
+	if id == "" {
+		return nil, reportError("clicktocall id is required field")
 	}
 
-	c2c = &response.Data
+	c2c, _, err = do[*Clicktocall](ctx, api.client, endpoint{
+		service:   "ClicktocallAPI",
+		operation: "PatchClicktocall",
+		method:    "PATCH",
+		path:      path("accounts", acc, "clicktocall", id),
+		body:      input,
+	})
 
-	return c2c, nil
+	return c2c, err
 }
-
@@ -0,0 +1,32 @@
+package kazooapi_test
+
+import (
+	"context"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClicktocallAPIService_UpdatePatch(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"a0e1a6c6c84bd9a3c5b4e07fc4b5a1b2","name":"Sales","extension":"2000","timeout":30}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/clicktocall/a0e1a6c6c84bd9a3c5b4e07fc4b5a1b2"
+
+	c2c, err := clt.ClicktocallAPI.UpdateClicktocall(ctx, "qe0ade400015367f0069d6dfbdca072a", "a0e1a6c6c84bd9a3c5b4e07fc4b5a1b2", &kazooapi.Clicktocall{Name: "Sales", Extension: "2000"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30), c2c.Timeout)
+	assertRequest(t, last(), "POST", path)
+
+	_, err = clt.ClicktocallAPI.PatchClicktocall(ctx, "qe0ade400015367f0069d6dfbdca072a", "a0e1a6c6c84bd9a3c5b4e07fc4b5a1b2", map[string]interface{}{"timeout": 30})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", path)
+	assert.Equal(t, map[string]interface{}{"timeout": float64(30)}, last().Data)
+
+	_, err = clt.ClicktocallAPI.UpdateClicktocall(ctx, "qe0ade400015367f0069d6dfbdca072a", "a0e1a6c6c84bd9a3c5b4e07fc4b5a1b2", &kazooapi.Clicktocall{Name: "Sales"})
+	assert.EqualError(t, err, "Extension is required field")
+}
@@ -56,6 +56,12 @@ type Configuration struct {
 	BasicAuth     BasicAuth
 	HTTPClient    *http.Client
 	ClientTimeout time.Duration
+	//TokenStore keeps the auth token of the client, an in-memory store is used if it's nil
+	TokenStore TokenStore
+	//RetryPolicy controls retries of failed requests, nil disables retries
+	RetryPolicy *RetryPolicy
+	//RateLimit throttles requests on the client side, nil disables throttling
+	RateLimit *RateLimit
 }
 
 //NewConfiguration prepares a default configuration structure for an APIClient
@@ -64,6 +70,7 @@ func NewConfiguration() *Configuration {
 		BasePath:      apiURL,
 		DefaultHeader: make(map[string]string),
 		UserAgent:     "kazoo-go/" + ver,
+		RetryPolicy:   DefaultRetryPolicy(),
 	}
 	return cfg
 }
@@ -2,8 +2,6 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 )
 
 type DevicesAPIService service
@@ -24,6 +22,7 @@ type (
 		SIP                             SIP         `json:"sip,omitempty"`
 		CallForward                     CallForward `json:"call_forward,omitempty"`
 		Media                           Media       `json:"media,omitempty"`
+		Metaflows                       *Metaflow   `json:"metaflows,omitempty"`
 	}
 
 	SIP struct {
@@ -35,7 +34,7 @@ type (
 		Number                  string `json:"number,omitempty"`
 		Method                  string `json:"method,omitempty"` //password or IP
 		IP                      string `json:"ip,omitempty"`
-		InviteFormat            string `json:"invite_forma,omitemptyt"` //npan 1npan e.164
+		InviteFormat            string `json:"invite_format,omitempty"` //npan, 1npan or e164, see number.Convert
 		IgnoreCompleteElsewhere bool   `json:"ignore_complete_elsewhere,omitempty"`
 		ExpireSeconds           int    `json:"expire_seconds,omitempty"`
 	}
@@ -65,11 +64,6 @@ type (
 )
 
 func (api *DevicesAPIService) CreateDevice(ctx context.Context, acc string, input *Device) (dev *Device, err error) {
-	var response struct {
-		Data Device `json:"data"`
-		ResponseEnvelope
-	}
-
 	if acc == "" {
 		return nil, reportError("account id is required field")
 	}
@@ -78,96 +72,118 @@ func (api *DevicesAPIService) CreateDevice(ctx context.Context, acc string, inpu
 		return nil, reportError("name of the device is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/devices",
-	}
+	dev, _, err = do[*Device](ctx, api.client, endpoint{
+		service:   "DevicesAPI",
+		operation: "CreateDevice",
+		method:    "PUT",
+		path:      path("accounts", acc, "devices"),
+		body:      input,
+	})
 
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
+	return dev, err
+}
+
+func (api *DevicesAPIService) ListDevices(ctx context.Context, acc string, disablePagination bool) (devices []Device, err error) {
+	devices, _, err = do[[]Device](ctx, api.client, endpoint{
+		service:   "DevicesAPI",
+		operation: "ListDevices",
+		method:    "GET",
+		path:      path("accounts", acc, "devices"),
+		query:     listQuery(disablePagination),
+	})
+
+	return devices, err
+}
 
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
+//ListDevicesPaginator returns a paginator over devices of the account,
+//zero pageSize means the server's default page size
+func (api *DevicesAPIService) ListDevicesPaginator(acc string, pageSize int64) *Paginator[Device] {
+	return listPaginator[Device](api.client, "DevicesAPI", "ListDevicesPaginator", path("accounts", acc, "devices"), pageSize)
+}
+
+//GetDevice returns the device document
+func (api *DevicesAPIService) GetDevice(ctx context.Context, acc, id string) (dev *Device, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
+	if id == "" {
+		return nil, reportError("device id is required field")
 	}
 
-	params.PostBody = body
+	dev, _, err = do[*Device](ctx, api.client, endpoint{
+		service:   "DevicesAPI",
+		operation: "GetDevice",
+		method:    "GET",
+		path:      path("accounts", acc, "devices", id),
+	})
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+	return dev, err
+}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+//UpdateDevice replaces the device document with input,
+//fields missing in input are removed from the document
+func (api *DevicesAPIService) UpdateDevice(ctx context.Context, acc, id string, input *Device) (dev *Device, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+	if id == "" {
+		return nil, reportError("device id is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if input.Name == "" {
+		return nil, reportError("name of the device is required field")
 	}
 
-	dev = &response.Data
+	dev, _, err = do[*Device](ctx, api.client, endpoint{
+		service:   "DevicesAPI",
+		operation: "UpdateDevice",
+		method:    "POST",
+		path:      path("accounts", acc, "devices", id),
+		body:      input,
+	})
 
-	return dev, nil
+	return dev, err
 }
 
-func (api *DevicesAPIService) ListDevices(ctx context.Context, acc string, disablePagination bool) (devices []Device, err error) {
-	var response struct {
-		Data []Device `json:"data"`
-		ResponseEnvelope
-	}
-
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/devices",
+//PatchDevice merges input into the device document leaving other fields untouched
+func (api *DevicesAPIService) PatchDevice(ctx context.Context, acc, id string, input map[string]interface{}) (dev *Device, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	if disablePagination {
-		params.QueryParams = map[string][]string{"paginate": []string{"false"}}
+	if id == "" {
+		return nil, reportError("device id is required field")
 	}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("can't prepare a request %s", err)
-	}
+	dev, _, err = do[*Device](ctx, api.client, endpoint{
+		service:   "DevicesAPI",
+		operation: "PatchDevice",
+		method:    "PATCH",
+		path:      path("accounts", acc, "devices", id),
+		body:      input,
+	})
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return dev, err
+}
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+//DeleteDevice removes the device and returns the deleted document
+func (api *DevicesAPIService) DeleteDevice(ctx context.Context, acc, id string) (dev *Device, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("can't decode response: %v", decErr)
+	if id == "" {
+		return nil, reportError("device id is required field")
 	}
 
-	devices = response.Data
+	dev, _, err = do[*Device](ctx, api.client, endpoint{
+		service:   "DevicesAPI",
+		operation: "DeleteDevice",
+		method:    "DELETE",
+		path:      path("accounts", acc, "devices", id),
+	})
 
-	return devices, nil
+	return dev, err
 }
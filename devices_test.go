@@ -0,0 +1,42 @@
+package kazooapi_test
+
+import (
+	"context"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevicesAPIService_CRUD(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11","name":"Reception","owner_id":"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4","device_type":"sip_device","sip":{"username":"reception"}}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/devices/3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11"
+
+	dev, err := clt.DevicesAPI.GetDevice(ctx, "qe0ade400015367f0069d6dfbdca072a", "3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11")
+	assert.NoError(t, err)
+	assert.Equal(t, "reception", dev.SIP.Username)
+	assertRequest(t, last(), "GET", path)
+
+	_, err = clt.DevicesAPI.UpdateDevice(ctx, "qe0ade400015367f0069d6dfbdca072a", "3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11", &kazooapi.Device{Name: "Reception", DeviceType: "sip_device"})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "POST", path)
+	assert.Equal(t, "Reception", last().Data.(map[string]interface{})["name"])
+
+	_, err = clt.DevicesAPI.PatchDevice(ctx, "qe0ade400015367f0069d6dfbdca072a", "3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11", map[string]interface{}{"enabled": false})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", path)
+	assert.Equal(t, map[string]interface{}{"enabled": false}, last().Data)
+
+	dev, err = clt.DevicesAPI.DeleteDevice(ctx, "qe0ade400015367f0069d6dfbdca072a", "3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11")
+	assert.NoError(t, err)
+	assert.Equal(t, "3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11", dev.ID)
+	assertRequest(t, last(), "DELETE", path)
+
+	_, err = clt.DevicesAPI.UpdateDevice(ctx, "qe0ade400015367f0069d6dfbdca072a", "3c0e5b5c8bcbd7e3e5b8d86f7a3b2a11", &kazooapi.Device{})
+	assert.EqualError(t, err, "name of the device is required field")
+}
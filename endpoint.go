@@ -0,0 +1,92 @@
+package kazooapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+//endpoint describes a single Kazoo API call made with do
+type endpoint struct {
+	service   string
+	operation string
+	method    string
+	//path is relative to BasePath and built with path(), e.g. path("accounts", acc, "users")
+	path  string
+	query url.Values
+	//body is sent as the "data" field of the request envelope, nil means no body
+	body interface{}
+	//causes are attached to *APIError returned for the given status codes,
+	//e.g. 404 -> ErrNumberNotFound
+	causes map[int]error
+	//noRetry disables automatic retries of the call, e.g. for call control
+	//actions which must not run twice if the first attempt reached Kazoo
+	noRetry bool
+}
+
+//do sends the call through the client's middleware chain and decodes
+//the "data" field of a successful response into T. Every service call goes
+//through it, so envelopes, errors and response bodies are handled the same way everywhere.
+//Responses >= 300 are returned as *APIError
+func do[T any](ctx context.Context, c *APIClient, e endpoint) (data T, meta ResponseMeta, err error) {
+	params := Request{
+		CTX:         ctx,
+		Service:     e.service,
+		Operation:   e.operation,
+		Method:      e.method,
+		Path:        c.cfg.BasePath + e.path,
+		QueryParams: e.query,
+		NoRetry:     e.noRetry,
+	}
+
+	if e.body != nil {
+		body, err := json.Marshal(RequestEnvelope{Data: e.body})
+		if err != nil {
+			return data, meta, reportError("can't marshall body for request: %v", err)
+		}
+
+		params.PostBody = body
+		params.HeaderParams = map[string]string{"Content-Type": "application/json"}
+	}
+
+	req, err := c.prepareRequest(&params)
+	if err != nil {
+		return data, meta, reportError("Can't prepare a request %s", err)
+	}
+
+	call, err := c.callAPI(ctx, req)
+	if err != nil {
+		return data, meta, err
+	}
+	defer call.Response.Body.Close()
+
+	meta = newResponseMeta(call)
+
+	if call.Response.StatusCode >= 300 {
+		apiErr := prepareAPIError(call.Response)
+		if cause, ok := e.causes[call.Response.StatusCode]; ok {
+			apiErr = apiErr.withCause(cause)
+		}
+		return data, meta, apiErr
+	}
+
+	if call.ResponseEnvelope == nil {
+		return data, meta, reportError("Can't decode response: not a Kazoo envelope")
+	}
+
+	if len(call.ResponseData) > 0 {
+		if err := json.Unmarshal(call.ResponseData, &data); err != nil {
+			return data, meta, reportError("Can't decode response: %v", err)
+		}
+	}
+
+	return data, meta, nil
+}
+
+//listQuery returns query parameters of a list request
+func listQuery(disablePagination bool) url.Values {
+	if disablePagination {
+		return url.Values{"paginate": []string{"false"}}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package kazooapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceCalls_QueryAndBody(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"application_name":"transfer","timeout":30}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	//The contact is escaped instead of being glued to the path
+	cer, err := clt.ClicktocallAPI.ExecuteClicktocall(ctx, "qe0ade400015367f0069d6dfbdca072a", "a0e1a6c6c84bd9a3c5b4e07fc4b5a1b2", "+7 (495) 555-55-55&x=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer", cer.ApplicationName)
+	assertRequest(t, last(), "GET", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/clicktocall/a0e1a6c6c84bd9a3c5b4e07fc4b5a1b2/connect")
+	assert.Equal(t, "contact=%2B7+%28495%29+555-55-55%26x%3D1", last().Query)
+
+	_, err = clt.PhoneNumbersAPI.DeletePhoneNumber(ctx, "qe0ade400015367f0069d6dfbdca072a", "+74955555555", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "hard=true", last().Query)
+
+	_, err = clt.UsersAPI.ListUsers(ctx, "qe0ade400015367f0069d6dfbdca072a", true)
+	assert.Error(t, err, "an object can't be decoded into a list")
+	assert.Equal(t, "paginate=false", last().Query)
+}
+
+func TestServiceCalls_ContentType(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		io.WriteString(w, `{"data":{"twoway_trunks":5},"status":"success"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	limits, err := newMockClient(t, srv).LimitsAPI.UpdateLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Limits{TwowayTrunks: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), limits.TwowayTrunks)
+}
+
+func TestServiceCalls_NotAnEnvelope(t *testing.T) {
+	ctx := context.Background()
+
+	status := 502
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "text/html")
+		w.WriteHeader(status)
+		io.WriteString(w, `<html><body>Bad Gateway</body></html>`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+	cfg.RetryPolicy = nil
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	//Errors sent by a proxy are still typed
+	_, err = clt.AccountsAPI.GetAccount(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	var apiErr *kazooapi.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, 502, apiErr.StatusCode)
+	}
+
+	status = 200
+	_, err = clt.AccountsAPI.GetAccount(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.EqualError(t, err, "Can't decode response: not a Kazoo envelope")
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type genericKazooError struct {
@@ -102,11 +105,171 @@ func UnmarshalKazooError(body io.ReadCloser) Error {
 		return NewError("ErrReadResponseBody", "", err)
 	}
 
-	e := &genericKazooError{}
-	jsonErr := json.Unmarshal(bodyBytes, e)
-	if err != nil {
+	e, jsonErr := newAPIError(0, "", bodyBytes)
+	if jsonErr != nil {
 		return NewError("UnmarshalError", string(bodyBytes), jsonErr)
 	}
 
-	return NewError(e.Status, e.Error, nil)
+	return e
+}
+
+//Classes of errors returned by Kazoo, use errors.Is to match an error against them
+var (
+	ErrNotFound     = NewError("NotFound", "document not found", nil)
+	ErrConflict     = NewError("Conflict", "document conflict", nil)
+	ErrUnauthorized = NewError("Unauthorized", "request isn't authorized", nil)
+	ErrValidation   = NewError("Validation", "document failed validation", nil)
+)
+
+//ValidationDetail describes a single failed validation rule of a field.
+//Kazoo puts the rule's parameter (e.g. the max length) into Target
+//and the rejected value into Value
+type ValidationDetail struct {
+	Message string      `json:"message"`
+	Target  interface{} `json:"target,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+//APIError represents an error response (i.e. code >= 300) received from Kazoo
+type APIError struct {
+	StatusCode   int    //HTTP status code
+	ErrorCode    string //Kazoo "error" field
+	ErrorMessage string //Kazoo "message" field, e.g. "bad_identifier" or "number_exists"
+	RequestID    string
+	//Validation is a map of field name to the rules it failed, e.g. {"name":{"required":{...}}}
+	Validation map[string]map[string]ValidationDetail
+	//Data is the raw "data" field of the response
+	Data json.RawMessage
+
+	cause error
+}
+
+//newAPIError decodes Kazoo's error envelope
+func newAPIError(statusCode int, status string, body []byte) (*APIError, error) {
+	e := &APIError{StatusCode: statusCode}
+
+	if len(body) > 0 {
+		ke := &struct {
+			genericKazooError
+			Data json.RawMessage `json:"data"`
+		}{}
+
+		if err := json.Unmarshal(body, ke); err != nil {
+			e.ErrorMessage = string(body)
+			return e, err
+		}
+
+		e.ErrorCode = ke.Error
+		e.ErrorMessage = ke.Message
+		e.RequestID = ke.RequestID
+		e.Data = ke.Data
+		e.Validation = parseValidation(ke.Data)
+
+		if e.StatusCode == 0 {
+			e.StatusCode, _ = strconv.Atoi(ke.Error)
+		}
+	}
+
+	if e.ErrorMessage == "" {
+		e.ErrorMessage = status
+	}
+
+	return e, nil
+}
+
+//parseValidation picks {"field":{"rule":{"message":...}}} entries out of the error data,
+//values of other shapes (e.g. {"not_found":"..."}) are skipped
+func parseValidation(data json.RawMessage) map[string]map[string]ValidationDetail {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	var v map[string]map[string]ValidationDetail
+	for field, raw := range fields {
+		var rules map[string]ValidationDetail
+		if err := json.Unmarshal(raw, &rules); err != nil || len(rules) == 0 {
+			continue
+		}
+
+		if v == nil {
+			v = make(map[string]map[string]ValidationDetail)
+		}
+		v[field] = rules
+	}
+
+	return v
+}
+
+//Error implements Error interface
+func (e *APIError) Error() string {
+	extra := ""
+	if e.RequestID != "" {
+		extra = "request_id: " + e.RequestID
+	}
+
+	fields := make([]string, 0, len(e.Validation))
+	for field := range e.Validation {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		rules := make([]string, 0, len(e.Validation[field]))
+		for rule := range e.Validation[field] {
+			rules = append(rules, rule)
+		}
+		sort.Strings(rules)
+
+		if extra != "" {
+			extra += "\n\t"
+		}
+		extra += field + ": " + strings.Join(rules, ", ")
+	}
+
+	return SprintError(e.Code(), e.ErrorMessage, extra, e.cause)
+}
+
+//Code returns Kazoo error code, falling back to the HTTP status code
+func (e *APIError) Code() string {
+	if e.ErrorCode != "" {
+		return e.ErrorCode
+	}
+	return strconv.Itoa(e.StatusCode)
+}
+
+//Message returns Kazoo error message
+func (e *APIError) Message() string {
+	return e.ErrorMessage
+}
+
+//Unwrap returns a service specific error (e.g. ErrNumberNotFound) if one was set
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+//Is enables errors.Is matching against the error classes
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == 404
+	case ErrConflict:
+		//412 is returned by proxies checking If-Match on their own
+		return e.StatusCode == 409 || e.StatusCode == 412
+	case ErrUnauthorized:
+		return e.StatusCode == 401 || e.StatusCode == 403
+	case ErrValidation:
+		return e.StatusCode == 400 && (len(e.Validation) > 0 || e.ErrorMessage == "validation error")
+	case ErrNumberExists:
+		return e.ErrorMessage == "number_exists"
+	case ErrInvalidStateTransition:
+		return e.ErrorMessage == "invalid_state_transition"
+	}
+	return false
+}
+
+//withCause attaches a service specific error which can be matched with errors.Is
+func (e *APIError) withCause(cause error) *APIError {
+	e.cause = cause
+	return e
 }
@@ -1,7 +1,99 @@
 package kazooapi
 
-import "testing"
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func errorResponse(code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Status:     http.StatusText(code),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
 
 func TestErrInvalidStateTransition(t *testing.T) {
+	err := prepareError(errorResponse(400, `{
+		"data": {"message": "invalid_state_transition", "cause": "+74955555555"},
+		"error": "400",
+		"message": "invalid_state_transition",
+		"status": "error",
+		"request_id": "be0631b976148f384f2d1310b21df3bd"
+	}`))
+
+	assert.True(t, errors.Is(err, ErrInvalidStateTransition))
+	assert.False(t, errors.Is(err, ErrValidation))
+	assert.False(t, errors.Is(err, ErrNumberExists))
+}
+
+func TestAPIError_Validation(t *testing.T) {
+	err := prepareError(errorResponse(400, `{
+		"data": {
+			"name": {
+				"required": {"message": "Field is required but missing"}
+			},
+			"realm": {
+				"unique": {"message": "Realm must be unique", "cause": "test.pbx.example.com"},
+				"maxLength": {"message": "String must not exceed 253 characters", "target": 253}
+			}
+		},
+		"error": "400",
+		"message": "validation error",
+		"status": "error",
+		"request_id": "3ae535b86688c03169207e2185d74aab"
+	}`))
+
+	assert.True(t, errors.Is(err, ErrValidation))
+	assert.False(t, errors.Is(err, ErrNotFound))
+
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "400", apiErr.Code())
+		assert.Equal(t, "validation error", apiErr.Message())
+		assert.Len(t, apiErr.Validation, 2)
+		assert.Equal(t, "Field is required but missing", apiErr.Validation["name"]["required"].Message)
+		assert.Equal(t, float64(253), apiErr.Validation["realm"]["maxLength"].Target)
+	}
+
+	assert.Equal(t, "400: validation error\n\trequest_id: 3ae535b86688c03169207e2185d74aab\n\tname: required\n\trealm: maxLength, unique", err.Error())
+}
+
+func TestAPIError_Classes(t *testing.T) {
+	cases := []struct {
+		code   int
+		body   string
+		target error
+	}{
+		{404, `{"data":{"message":"bad identifier","not_found":"The number could not be found"},"error":"404","message":"bad_identifier","status":"error"}`, ErrNotFound},
+		{409, `{"data":{"cause":"+74955555555","message":"number_exists"},"error":"409","message":"number_exists","status":"error"}`, ErrConflict},
+		{409, `{"data":{"cause":"+74955555555","message":"number_exists"},"error":"409","message":"number_exists","status":"error"}`, ErrNumberExists},
+		{401, `{"data":{},"error":"401","message":"invalid_credentials","status":"error"}`, ErrUnauthorized},
+		{502, `<html>Bad Gateway</html>`, nil},
+	}
+
+	for _, c := range cases {
+		err := prepareError(errorResponse(c.code, c.body))
+
+		var apiErr *APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, c.code, apiErr.StatusCode)
+
+		if c.target != nil {
+			assert.True(t, errors.Is(err, c.target), "%d should match %v", c.code, c.target)
+		}
+	}
+}
+
+func TestUnmarshalKazooError(t *testing.T) {
+	err := UnmarshalKazooError(ioutil.NopCloser(strings.NewReader(`not a json`)))
+	assert.Equal(t, "UnmarshalError", err.Code())
 
+	err = UnmarshalKazooError(ioutil.NopCloser(strings.NewReader(`{"error":"404","message":"bad_identifier"}`)))
+	assert.True(t, errors.Is(err, ErrNotFound))
 }
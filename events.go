@@ -0,0 +1,589 @@
+package kazooapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sashker/kazoo-go/internal/websocket"
+)
+
+//DefaultEventsPort is the port blackhole (the websocket API of Kazoo) listens on
+const DefaultEventsPort = "5555"
+
+//Default settings of EventsClient
+const (
+	DefaultEventsBackoff    = time.Second
+	DefaultEventsMaxBackoff = time.Minute
+	defaultEventsBuffer     = 64
+)
+
+//ErrEventsDropped is reported to EventsOptions.OnError when events start being dropped
+//because the events channel is full, EventsClient.Dropped returns how many of them are lost
+var ErrEventsDropped = NewError("EventsDropped", "events channel is full, events are dropped", nil)
+
+//Bindings of blackhole events, * matches any call or document
+const (
+	BindingChannelCreate  = "call.CHANNEL_CREATE.*"
+	BindingChannelAnswer  = "call.CHANNEL_ANSWER.*"
+	BindingChannelBridge  = "call.CHANNEL_BRIDGE.*"
+	BindingChannelDestroy = "call.CHANNEL_DESTROY.*"
+	BindingDocCreated     = "object.doc_created.*"
+	BindingDocEdited      = "object.doc_edited.*"
+	BindingDocDeleted     = "object.doc_deleted.*"
+)
+
+//EventsOptions controls EventsClient, zero values mean defaults
+type EventsOptions struct {
+	//URL of blackhole, e.g. wss://kazoo.example.com:5555, by default it's
+	//the host of Configuration.BasePath with DefaultEventsPort
+	URL       string
+	TLSConfig *tls.Config
+	//Backoff is the delay before the first reconnection, it doubles with every failed one up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	//Buffer is the capacity of the events channel. Events arriving while it's full are dropped,
+	//so a slow consumer doesn't hold up replies to Subscribe and Unsubscribe. The first event dropped
+	//after a delivered one is reported to OnError as ErrEventsDropped, see EventsClient.Dropped
+	Buffer int
+	//OnError is called when the connection is lost or can't be established, with the delay before the next attempt.
+	//Failed resubscriptions, dropped events and events which can't be decoded are reported with zero delay.
+	//It might be called from several goroutines
+	OnError func(err error, delay time.Duration)
+}
+
+//Event is a message of blackhole, Call or Object is set according to the kind of the event
+type Event struct {
+	Name string `json:"name"`
+	//Binding is the binding the event was subscribed with, e.g. call.CHANNEL_CREATE.*
+	Binding    string          `json:"subscribed_key"`
+	RoutingKey string          `json:"routing_key"`
+	Data       json.RawMessage `json:"data"`
+
+	Call   *CallEvent   `json:"-"`
+	Object *ObjectEvent `json:"-"`
+}
+
+//CallEvent is data of call.* events
+type CallEvent struct {
+	EventName         string             `json:"Event-Name"`
+	CallID            string             `json:"Call-ID"`
+	OtherLegCallID    string             `json:"Other-Leg-Call-ID,omitempty"`
+	CallDirection     string             `json:"Call-Direction,omitempty"`
+	CallerIDName      string             `json:"Caller-ID-Name,omitempty"`
+	CallerIDNumber    string             `json:"Caller-ID-Number,omitempty"`
+	CalleeIDName      string             `json:"Callee-ID-Name,omitempty"`
+	CalleeIDNumber    string             `json:"Callee-ID-Number,omitempty"`
+	From              string             `json:"From,omitempty"`
+	To                string             `json:"To,omitempty"`
+	Request           string             `json:"Request,omitempty"`
+	HangupCause       string             `json:"Hangup-Cause,omitempty"`
+	Timestamp         Timestamp          `json:"Timestamp"`
+	CustomChannelVars CallEventVariables `json:"Custom-Channel-Vars"`
+}
+
+//UnmarshalJSON decodes the timestamp leniently: it might be a number or a string of Gregorian seconds
+//with a fraction, an unparsable timestamp is left zero instead of failing the whole event
+func (e *CallEvent) UnmarshalJSON(data []byte) error {
+	type callEvent CallEvent
+	aux := struct {
+		*callEvent
+		Timestamp json.RawMessage `json:"Timestamp"`
+	}{callEvent: (*callEvent)(e)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	e.Timestamp = Timestamp{}
+	greg, err := strconv.ParseFloat(strings.Trim(string(aux.Timestamp), `"`), 64)
+	if err == nil && greg > 0 {
+		secs, frac := math.Modf(greg)
+		e.Timestamp = Timestamp(time.Unix(int64(secs)-gregorianSecondsSinceUnix, int64(frac*float64(time.Second))))
+	}
+
+	return nil
+}
+
+//CallEventVariables are custom channel variables Kazoo sets on calls
+type CallEventVariables struct {
+	AccountID       string `json:"Account-ID,omitempty"`
+	OwnerID         string `json:"Owner-ID,omitempty"`
+	AuthorizingID   string `json:"Authorizing-ID,omitempty"`
+	AuthorizingType string `json:"Authorizing-Type,omitempty"`
+}
+
+//ObjectEvent is data of object.* events, i.e. changes of documents
+type ObjectEvent struct {
+	EventName string `json:"Event-Name"` //doc_created, doc_edited or doc_deleted
+	ID        string `json:"ID"`
+	Type      string `json:"Type"` //type of the document, e.g. user or callflow
+	AccountID string `json:"Account-ID,omitempty"`
+	Database  string `json:"Database,omitempty"`
+	Rev       string `json:"Rev,omitempty"`
+}
+
+//EventsClient subscribes to events of accounts over the websocket API of Kazoo.
+//It authenticates with the token of the APIClient, reconnects when the connection
+//is lost and subscribes to the same bindings again
+type EventsClient struct {
+	client  *APIClient
+	url     string
+	opts    EventsOptions
+	events  chan Event
+	running int32
+	dropped uint64
+
+	//subsLock is held while subscriptions are sent, mu guards the fields below it
+	subsLock chan struct{}
+
+	mu      sync.Mutex
+	subs    map[string]map[string]bool //account id -> bindings
+	conn    *eventsConn
+	pending map[string]chan eventsMessage
+}
+
+//eventsConn is a single connection to blackhole, done is closed when reading from it fails
+type eventsConn struct {
+	ws   *websocket.Conn
+	done chan struct{}
+	err  error
+}
+
+//eventsMessage is a request, a reply or an event
+type eventsMessage struct {
+	Action    string          `json:"action"`
+	AuthToken string          `json:"auth_token,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Status    string          `json:"status,omitempty"`
+	Message   string          `json:"message,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+type eventsSubscription struct {
+	AccountID string   `json:"account_id"`
+	Bindings  []string `json:"bindings"`
+}
+
+//NewEventsClient returns a client of the websocket API, it connects with Run
+func (c *APIClient) NewEventsClient(opts *EventsOptions) (*EventsClient, error) {
+	ec := &EventsClient{
+		client:   c,
+		subsLock: make(chan struct{}, 1),
+		subs:     make(map[string]map[string]bool),
+		pending:  make(map[string]chan eventsMessage),
+	}
+	if opts != nil {
+		ec.opts = *opts
+	}
+
+	if ec.opts.Backoff <= 0 {
+		ec.opts.Backoff = DefaultEventsBackoff
+	}
+	if ec.opts.MaxBackoff <= 0 {
+		ec.opts.MaxBackoff = DefaultEventsMaxBackoff
+	}
+	if ec.opts.Buffer <= 0 {
+		ec.opts.Buffer = defaultEventsBuffer
+	}
+
+	ec.url = ec.opts.URL
+	if ec.url == "" {
+		u, err := url.Parse(c.cfg.BasePath)
+		if err != nil || u.Hostname() == "" {
+			return nil, reportError("can't make events URL of %q", c.cfg.BasePath)
+		}
+
+		scheme := "ws"
+		if u.Scheme == "https" {
+			scheme = "wss"
+		}
+		ec.url = scheme + "://" + net.JoinHostPort(u.Hostname(), DefaultEventsPort)
+	}
+
+	ec.events = make(chan Event, ec.opts.Buffer)
+
+	return ec, nil
+}
+
+//Events returns the channel events are delivered on, it's closed when Run returns
+func (ec *EventsClient) Events() <-chan Event {
+	return ec.events
+}
+
+//Dropped returns the number of events dropped because the events channel was full
+func (ec *EventsClient) Dropped() uint64 {
+	return atomic.LoadUint64(&ec.dropped)
+}
+
+//Subscribe adds bindings of the account. While the client is connected the subscription
+//is sent right away and its error is returned, otherwise it's sent once Run connects
+func (ec *EventsClient) Subscribe(ctx context.Context, acc string, bindings ...string) error {
+	if acc == "" {
+		return reportError("account id is required field")
+	}
+	if len(bindings) == 0 {
+		return reportError("at least one binding is required")
+	}
+
+	if err := ec.lockSubs(ctx); err != nil {
+		return err
+	}
+	defer ec.unlockSubs()
+
+	ec.mu.Lock()
+	if ec.subs[acc] == nil {
+		ec.subs[acc] = make(map[string]bool)
+	}
+	var added []string
+	for _, b := range bindings {
+		if !ec.subs[acc][b] {
+			ec.subs[acc][b] = true
+			added = append(added, b)
+		}
+	}
+	conn := ec.conn
+	ec.mu.Unlock()
+
+	if conn == nil || len(added) == 0 {
+		return nil
+	}
+
+	if err := ec.request(ctx, conn, "subscribe", acc, added); err != nil {
+		ec.forget(acc, added)
+		return err
+	}
+
+	return nil
+}
+
+//Unsubscribe removes bindings of the account, all of them if none are given.
+//While the client is connected the bindings are kept if blackhole doesn't confirm the unsubscription
+func (ec *EventsClient) Unsubscribe(ctx context.Context, acc string, bindings ...string) error {
+	if acc == "" {
+		return reportError("account id is required field")
+	}
+
+	if err := ec.lockSubs(ctx); err != nil {
+		return err
+	}
+	defer ec.unlockSubs()
+
+	ec.mu.Lock()
+	if len(bindings) == 0 {
+		for b := range ec.subs[acc] {
+			bindings = append(bindings, b)
+		}
+		sort.Strings(bindings)
+	}
+	conn := ec.conn
+	ec.mu.Unlock()
+
+	if conn != nil && len(bindings) > 0 {
+		if err := ec.request(ctx, conn, "unsubscribe", acc, bindings); err != nil {
+			return err
+		}
+	}
+
+	ec.forget(acc, bindings)
+	return nil
+}
+
+func (ec *EventsClient) forget(acc string, bindings []string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	for _, b := range bindings {
+		delete(ec.subs[acc], b)
+	}
+	if len(ec.subs[acc]) == 0 {
+		delete(ec.subs, acc)
+	}
+}
+
+//Run connects to blackhole and delivers events until ctx is done, then it returns ctx.Err().
+//Lost connections are re-established with exponential backoff.
+//A client runs only once, later calls return an error right away
+func (ec *EventsClient) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&ec.running, 0, 1) {
+		return reportError("events client has already been run")
+	}
+	defer close(ec.events)
+
+	failures := 0
+	for {
+		err := ec.serve(ctx, func() { failures = 0 })
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		delay := ec.opts.Backoff
+		for i := 0; i < failures && delay < ec.opts.MaxBackoff; i++ {
+			delay *= 2
+		}
+		if delay > ec.opts.MaxBackoff {
+			delay = ec.opts.MaxBackoff
+		}
+		failures++
+
+		if ec.opts.OnError != nil {
+			ec.opts.OnError(err, delay)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+//serve makes a single connection, subscribes to all bindings and reads events until
+//the connection is lost or ctx is done. connected is called after resubscription
+func (ec *EventsClient) serve(ctx context.Context, connected func()) error {
+	//Authenticate first, there is no point in connecting without a token
+	if _, err := ec.client.authToken(ctx); err != nil {
+		return err
+	}
+
+	header := make(http.Header)
+	header.Set("User-Agent", ec.client.cfg.UserAgent)
+
+	ws, err := websocket.Dial(ctx, ec.url, header, ec.opts.TLSConfig)
+	if err != nil {
+		return reportError("can't connect to %s: %v", ec.url, err)
+	}
+
+	conn := &eventsConn{ws: ws, done: make(chan struct{})}
+	go ec.read(conn)
+
+	defer func() {
+		ec.mu.Lock()
+		ec.conn = nil
+		ec.mu.Unlock()
+
+		ws.Close()
+		<-conn.done
+	}()
+
+	if err := ec.resubscribe(ctx, conn); err != nil {
+		return err
+	}
+	connected()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-conn.done:
+		return reportError("connection to %s is lost: %v", ec.url, conn.err)
+	}
+}
+
+//resubscribe sends all bindings to the new connection and publishes it. It holds the subscription lock,
+//so Subscribe and Unsubscribe made meanwhile wait and then go to the new connection: nothing is sent twice or lost
+func (ec *EventsClient) resubscribe(ctx context.Context, conn *eventsConn) error {
+	if err := ec.lockSubs(ctx); err != nil {
+		return err
+	}
+	defer ec.unlockSubs()
+
+	ec.mu.Lock()
+	subs := make(map[string][]string, len(ec.subs))
+	for acc, bindings := range ec.subs {
+		for b := range bindings {
+			subs[acc] = append(subs[acc], b)
+		}
+		sort.Strings(subs[acc])
+	}
+	ec.conn = conn
+	ec.mu.Unlock()
+
+	for acc, bindings := range subs {
+		if err := ec.request(ctx, conn, "subscribe", acc, bindings); err != nil && ec.opts.OnError != nil {
+			ec.opts.OnError(err, 0)
+		}
+	}
+
+	return nil
+}
+
+//lockSubs serializes changes of subscriptions, unlike a mutex it gives up when ctx is done
+func (ec *EventsClient) lockSubs(ctx context.Context) error {
+	select {
+	case ec.subsLock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ec *EventsClient) unlockSubs() {
+	<-ec.subsLock
+}
+
+//read dispatches replies to pending requests and delivers events. It never waits for the consumer
+//of events, otherwise replies would wait for it too: events which don't fit the channel are dropped
+//and the start of every run of dropped events is reported
+func (ec *EventsClient) read(conn *eventsConn) {
+	defer close(conn.done)
+
+	dropping := false
+	for {
+		raw, err := conn.ws.ReadMessage()
+		if err != nil {
+			conn.err = err
+			return
+		}
+
+		var msg eventsMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Action {
+		case "reply":
+			ec.mu.Lock()
+			reply, ok := ec.pending[msg.RequestID]
+			delete(ec.pending, msg.RequestID)
+			ec.mu.Unlock()
+
+			if ok {
+				reply <- msg
+			}
+		case "event":
+			var ev Event
+			if err := json.Unmarshal(raw, &ev); err != nil {
+				ec.reportEvent(reportError("can't decode event: %v", err))
+				continue
+			}
+			//The event is delivered even if its data doesn't fit the typed struct, it's left in Data then
+			ec.reportEvent(ev.decodeData())
+
+			select {
+			case ec.events <- ev:
+				dropping = false
+			default:
+				n := atomic.AddUint64(&ec.dropped, 1)
+				if !dropping {
+					dropping = true
+					ec.reportEvent(fmt.Errorf("%w: %d dropped so far", ErrEventsDropped, n))
+				}
+			}
+		}
+	}
+}
+
+//request sends a (un)subscription and waits for its reply.
+//A token rejected by blackhole is dropped and the request is sent once more with a new one
+func (ec *EventsClient) request(ctx context.Context, conn *eventsConn, action, acc string, bindings []string) error {
+	data, err := json.Marshal(eventsSubscription{AccountID: acc, Bindings: bindings})
+	if err != nil {
+		return reportError("can't encode %s request: %v", action, err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := ec.client.authToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		reply, err := ec.roundTrip(ctx, conn, eventsMessage{Action: action, AuthToken: token, RequestID: newEventsRequestID(), Data: data})
+		if err != nil {
+			return err
+		}
+		if reply.Status == "success" {
+			return nil
+		}
+
+		message := reply.Message
+		if message == "" {
+			message = string(reply.Data)
+		}
+		if attempt == 0 && strings.Contains(strings.ToLower(message), "token") {
+			if err := ec.client.tokens.ClearToken(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return reportError("can't %s to %s of %s: %s", action, strings.Join(bindings, ", "), acc, message)
+	}
+}
+
+func (ec *EventsClient) roundTrip(ctx context.Context, conn *eventsConn, msg eventsMessage) (eventsMessage, error) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return eventsMessage{}, reportError("can't encode %s request: %v", msg.Action, err)
+	}
+
+	reply := make(chan eventsMessage, 1)
+	ec.mu.Lock()
+	ec.pending[msg.RequestID] = reply
+	ec.mu.Unlock()
+
+	defer func() {
+		ec.mu.Lock()
+		delete(ec.pending, msg.RequestID)
+		ec.mu.Unlock()
+	}()
+
+	if err := conn.ws.WriteMessage(raw); err != nil {
+		return eventsMessage{}, reportError("can't send %s request: %v", msg.Action, err)
+	}
+
+	select {
+	case r := <-reply:
+		return r, nil
+	case <-conn.done:
+		return eventsMessage{}, reportError("connection is lost before %s reply", msg.Action)
+	case <-ctx.Done():
+		return eventsMessage{}, ctx.Err()
+	}
+}
+
+func (ec *EventsClient) reportEvent(err error) {
+	if err != nil && ec.opts.OnError != nil {
+		ec.opts.OnError(err, 0)
+	}
+}
+
+//decodeData decodes Data according to the kind of the event (the first part of the routing key)
+func (ev *Event) decodeData() error {
+	key := ev.RoutingKey
+	if key == "" {
+		key = ev.Binding
+	}
+
+	switch strings.SplitN(key, ".", 2)[0] {
+	case "call":
+		call := &CallEvent{}
+		if err := json.Unmarshal(ev.Data, call); err != nil {
+			return reportError("can't decode data of %s event: %v", ev.Name, err)
+		}
+		ev.Call = call
+	case "object":
+		object := &ObjectEvent{}
+		if err := json.Unmarshal(ev.Data, object); err != nil {
+			return reportError("can't decode data of %s event: %v", ev.Name, err)
+		}
+		ev.Object = object
+	}
+
+	return nil
+}
+
+func newEventsRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,340 @@
+package kazooapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/sashker/kazoo-go/internal/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+//BlackholeRequest is a (un)subscription seen by MockBlackholeServer
+type BlackholeRequest struct {
+	Connection int
+	Action     string `json:"action"`
+	AuthToken  string `json:"auth_token"`
+	RequestID  string `json:"request_id"`
+	Data       struct {
+		AccountID string   `json:"account_id"`
+		Bindings  []string `json:"bindings"`
+	} `json:"data"`
+}
+
+//MockBlackholeServer stands in for the websocket API on /socket.
+//Requests are passed to handle, which returns the reply status and events sent after the reply,
+//the connection is dropped when handle returns drop
+func MockBlackholeServer(t *testing.T, handle func(req BlackholeRequest) (status string, events []string, drop bool)) *httptest.Server {
+	var (
+		mu          sync.Mutex
+		connections int
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/socket", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		connections++
+		n := connections
+		mu.Unlock()
+
+		for {
+			raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			req := BlackholeRequest{Connection: n}
+			assert.NoError(t, json.Unmarshal(raw, &req))
+
+			status, events, drop := handle(req)
+			reply, _ := json.Marshal(map[string]interface{}{
+				"action":     "reply",
+				"request_id": req.RequestID,
+				"status":     status,
+				"data":       map[string]interface{}{"message": "invalid binding"},
+			})
+			conn.WriteMessage(reply)
+			for _, ev := range events {
+				conn.WriteMessage([]byte(ev))
+			}
+			if drop {
+				return
+			}
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func newMockEventsClient(t *testing.T, srv *httptest.Server, opts *kazooapi.EventsOptions) *kazooapi.EventsClient {
+	opts.URL = "ws" + strings.TrimPrefix(srv.URL, "http") + "/socket"
+
+	ec, err := newMockClient(t, srv).NewEventsClient(opts)
+	assert.NoError(t, err)
+	return ec
+}
+
+const (
+	channelCreateEvent = `{"action":"event","name":"CHANNEL_CREATE","subscribed_key":"call.CHANNEL_CREATE.*",
+		"routing_key":"call.CHANNEL_CREATE.3a1c6d2e-7b0f-11e9-8f2c-0242ac110002","data":{
+		"Event-Name":"CHANNEL_CREATE","Call-ID":"3a1c6d2e-7b0f-11e9-8f2c-0242ac110002","Call-Direction":"inbound",
+		"Caller-ID-Number":"2000","Callee-ID-Number":"2001","Timestamp":63727878993,
+		"Custom-Channel-Vars":{"Account-ID":"4dee5c1bef3ace50911c9917c50c9f80","Authorizing-Type":"device"}}}`
+	docEditedEvent = `{"action":"event","name":"doc_edited","subscribed_key":"object.doc_edited.*",
+		"routing_key":"object.doc_edited.user","data":{
+		"Event-Name":"doc_edited","ID":"d201633c77337fc469302947a56f4c44","Type":"user",
+		"Account-ID":"4dee5c1bef3ace50911c9917c50c9f80","Rev":"3-b1b5a3b1f2c1e7d3"}}`
+)
+
+func TestEventsClient_Run(t *testing.T) {
+	seen := make(chan BlackholeRequest, 10)
+	srv := MockBlackholeServer(t, func(req BlackholeRequest) (string, []string, bool) {
+		seen <- req
+		if req.Connection == 1 && req.Action == "subscribe" {
+			return "success", []string{channelCreateEvent, docEditedEvent}, true
+		}
+		return "success", nil, false
+	})
+	defer srv.Close()
+
+	var lost []error
+	ec := newMockEventsClient(t, srv, &kazooapi.EventsOptions{
+		Backoff: time.Millisecond,
+		OnError: func(err error, delay time.Duration) { lost = append(lost, err) },
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const acc = "4dee5c1bef3ace50911c9917c50c9f80"
+	assert.NoError(t, ec.Subscribe(ctx, acc, kazooapi.BindingChannelCreate, kazooapi.BindingDocEdited))
+
+	done := make(chan error)
+	go func() { done <- ec.Run(ctx) }()
+
+	req := <-seen
+	assert.Equal(t, 1, req.Connection)
+	assert.Equal(t, "subscribe", req.Action)
+	assert.Equal(t, "token", req.AuthToken)
+	assert.Equal(t, acc, req.Data.AccountID)
+	assert.ElementsMatch(t, []string{kazooapi.BindingChannelCreate, kazooapi.BindingDocEdited}, req.Data.Bindings)
+
+	ev := <-ec.Events()
+	assert.Equal(t, kazooapi.BindingChannelCreate, ev.Binding)
+	if assert.NotNil(t, ev.Call) {
+		assert.Equal(t, "CHANNEL_CREATE", ev.Call.EventName)
+		assert.Equal(t, "3a1c6d2e-7b0f-11e9-8f2c-0242ac110002", ev.Call.CallID)
+		assert.Equal(t, "2001", ev.Call.CalleeIDNumber)
+		assert.Equal(t, acc, ev.Call.CustomChannelVars.AccountID)
+		assert.Equal(t, time.Date(2019, 6, 16, 4, 36, 33, 0, time.UTC), ev.Call.Timestamp.Time().UTC())
+	}
+	assert.Nil(t, ev.Object)
+
+	ev = <-ec.Events()
+	if assert.NotNil(t, ev.Object) {
+		assert.Equal(t, "doc_edited", ev.Object.EventName)
+		assert.Equal(t, "user", ev.Object.Type)
+		assert.Equal(t, "d201633c77337fc469302947a56f4c44", ev.Object.ID)
+	}
+
+	//The server dropped the connection, bindings are subscribed again on the new one
+	req = <-seen
+	assert.Equal(t, 2, req.Connection)
+	assert.Equal(t, "subscribe", req.Action)
+	assert.ElementsMatch(t, []string{kazooapi.BindingChannelCreate, kazooapi.BindingDocEdited}, req.Data.Bindings)
+
+	assert.NoError(t, ec.Unsubscribe(ctx, acc, kazooapi.BindingDocEdited))
+	req = <-seen
+	assert.Equal(t, "unsubscribe", req.Action)
+	assert.Equal(t, []string{kazooapi.BindingDocEdited}, req.Data.Bindings)
+
+	assert.NoError(t, ec.Subscribe(ctx, acc, kazooapi.BindingChannelDestroy))
+	req = <-seen
+	assert.Equal(t, "subscribe", req.Action)
+	assert.Equal(t, []string{kazooapi.BindingChannelDestroy}, req.Data.Bindings)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	_, open := <-ec.Events()
+	assert.False(t, open)
+	assert.Len(t, lost, 1)
+
+	assert.Error(t, ec.Run(context.Background()))
+}
+
+func TestEventsClient_SubscribeError(t *testing.T) {
+	seen := make(chan BlackholeRequest, 10)
+	srv := MockBlackholeServer(t, func(req BlackholeRequest) (string, []string, bool) {
+		seen <- req
+		for _, b := range req.Data.Bindings {
+			if b == "call.NO_SUCH_EVENT.*" {
+				return "error", nil, false
+			}
+		}
+		return "success", nil, false
+	})
+	defer srv.Close()
+
+	ec := newMockEventsClient(t, srv, &kazooapi.EventsOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const acc = "4dee5c1bef3ace50911c9917c50c9f80"
+	assert.NoError(t, ec.Subscribe(ctx, acc, kazooapi.BindingChannelAnswer))
+	go ec.Run(ctx)
+	<-seen
+
+	err := ec.Subscribe(ctx, acc, "call.NO_SUCH_EVENT.*")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid binding")
+	}
+	<-seen
+
+	//The failed binding is forgotten, so unsubscribing from everything sends the first one only
+	assert.NoError(t, ec.Unsubscribe(ctx, acc))
+	req := <-seen
+	assert.Equal(t, "unsubscribe", req.Action)
+	assert.Equal(t, []string{kazooapi.BindingChannelAnswer}, req.Data.Bindings)
+
+	cancel()
+	for range ec.Events() {
+	}
+}
+
+func TestEventsClient_UnsubscribeError(t *testing.T) {
+	seen := make(chan BlackholeRequest, 10)
+	failing := int32(1)
+	srv := MockBlackholeServer(t, func(req BlackholeRequest) (string, []string, bool) {
+		seen <- req
+		if req.Action == "unsubscribe" && atomic.CompareAndSwapInt32(&failing, 1, 0) {
+			return "error", nil, false
+		}
+		return "success", nil, false
+	})
+	defer srv.Close()
+
+	ec := newMockEventsClient(t, srv, &kazooapi.EventsOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const acc = "4dee5c1bef3ace50911c9917c50c9f80"
+	assert.NoError(t, ec.Subscribe(ctx, acc, kazooapi.BindingChannelAnswer, kazooapi.BindingChannelDestroy))
+	go ec.Run(ctx)
+	<-seen
+
+	//The server refused, so the binding is still there and unsubscribing from everything sends it again
+	assert.Error(t, ec.Unsubscribe(ctx, acc, kazooapi.BindingChannelAnswer))
+	<-seen
+
+	assert.NoError(t, ec.Unsubscribe(ctx, acc))
+	req := <-seen
+	assert.Equal(t, "unsubscribe", req.Action)
+	assert.Equal(t, []string{kazooapi.BindingChannelAnswer, kazooapi.BindingChannelDestroy}, req.Data.Bindings)
+
+	cancel()
+	for range ec.Events() {
+	}
+}
+
+func TestEventsClient_SlowConsumer(t *testing.T) {
+	seen := make(chan BlackholeRequest, 10)
+	requests := 0
+	srv := MockBlackholeServer(t, func(req BlackholeRequest) (string, []string, bool) {
+		seen <- req
+		requests++
+		var events []string
+		if requests == 1 {
+			for i := 0; i < 20; i++ {
+				events = append(events, channelCreateEvent)
+			}
+		}
+		return "success", events, false
+	})
+	defer srv.Close()
+
+	var (
+		mu       sync.Mutex
+		reported []error
+	)
+	ec := newMockEventsClient(t, srv, &kazooapi.EventsOptions{
+		Buffer: 1,
+		OnError: func(err error, delay time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, err)
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const acc = "4dee5c1bef3ace50911c9917c50c9f80"
+	assert.NoError(t, ec.Subscribe(ctx, acc, kazooapi.BindingChannelCreate))
+
+	done := make(chan error)
+	go func() { done <- ec.Run(ctx) }()
+	<-seen
+
+	//Nobody reads events, the reply to the subscription still gets through
+	subCtx, subCancel := context.WithTimeout(ctx, time.Second)
+	defer subCancel()
+	assert.NoError(t, ec.Subscribe(subCtx, acc, kazooapi.BindingChannelDestroy))
+	req := <-seen
+	assert.Equal(t, []string{kazooapi.BindingChannelDestroy}, req.Data.Bindings)
+
+	//The reply follows the events, so all of them have been read by now
+	assert.Equal(t, uint64(19), ec.Dropped())
+	assert.Len(t, ec.Events(), 1)
+
+	//The run of dropped events is reported once
+	mu.Lock()
+	if assert.Len(t, reported, 1) {
+		assert.True(t, errors.Is(reported[0], kazooapi.ErrEventsDropped))
+		assert.Contains(t, reported[0].Error(), "1 dropped so far")
+	}
+	mu.Unlock()
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestCallEvent_Timestamp(t *testing.T) {
+	for data, expected := range map[string]time.Time{
+		`{"Call-ID":"3a1c6d2e","Timestamp":63727878993}`:   time.Date(2019, 6, 16, 4, 36, 33, 0, time.UTC),
+		`{"Call-ID":"3a1c6d2e","Timestamp":"63727878993"}`: time.Date(2019, 6, 16, 4, 36, 33, 0, time.UTC),
+		`{"Call-ID":"3a1c6d2e","Timestamp":63727878993.5}`: time.Date(2019, 6, 16, 4, 36, 33, 5e8, time.UTC),
+		`{"Call-ID":"3a1c6d2e","Timestamp":"yesterday"}`:   {},
+		`{"Call-ID":"3a1c6d2e"}`:                           {},
+	} {
+		var ev kazooapi.CallEvent
+		if assert.NoError(t, json.Unmarshal([]byte(data), &ev), data) {
+			assert.Equal(t, "3a1c6d2e", ev.CallID, data)
+			if expected.IsZero() {
+				assert.True(t, ev.Timestamp.Time().IsZero(), data)
+			} else {
+				assert.Equal(t, expected, ev.Timestamp.Time().UTC(), data)
+			}
+		}
+	}
+}
@@ -0,0 +1,444 @@
+//Package websocket is a minimal RFC 6455 implementation, just enough for
+//the blackhole events API of Kazoo: text messages, fragmentation, ping/pong and close.
+//Extensions and subprotocols aren't supported
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//acceptGUID is mixed into the handshake key, see RFC 6455 section 1.3
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+//maxMessageSize limits messages read from the peer
+const maxMessageSize = 16 << 20
+
+//maxControlPayload is the longest payload of a control frame, see RFC 6455 section 5.5
+const maxControlPayload = 125
+
+//Close codes sent when the peer breaks the protocol, see RFC 6455 section 7.4.1
+const (
+	closeProtocolError = 1002
+	closeInvalidData   = 1007
+)
+
+//Frame opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+var (
+	//ErrClosed is returned by ReadMessage after the peer closed the connection
+	ErrClosed = errors.New("websocket: connection closed")
+	//ErrProtocol is matched by errors of ReadMessage when the peer breaks RFC 6455,
+	//the connection is closed with 1002 (protocol error) or 1007 (invalid UTF-8 text) then
+	ErrProtocol = errors.New("websocket: protocol error")
+)
+
+//Conn is a websocket connection, reads must not be concurrent, writes might be
+type Conn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+//Dial opens a connection to a ws:// or wss:// URL. tlsConfig is used for wss, it might be nil
+func Dial(ctx context.Context, rawurl string, header http.Header, tlsConfig *tls.Config) (*Conn, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Host
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	case "wss":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "wss" {
+		cfg := &tls.Config{}
+		if tlsConfig != nil {
+			cfg = tlsConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	//The handshake is bound by ctx, the connection itself outlives it
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	c, err := handshake(conn, u, header)
+	if !stop() {
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func handshake(conn net.Conn, u *url.URL, header http.Header) (*Conn, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     "GET",
+		URL:        &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	if req.URL.Path == "" {
+		req.URL.Path = "/"
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket: handshake failed with status %s", resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") || !hasToken(resp.Header, "Connection", "upgrade") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, errors.New("websocket: server sent an invalid handshake response")
+	}
+	//Neither extensions nor subprotocols are asked for, so the server must not choose any
+	if resp.Header.Get("Sec-WebSocket-Extensions") != "" || resp.Header.Get("Sec-WebSocket-Protocol") != "" {
+		return nil, errors.New("websocket: server chose an extension or a subprotocol which wasn't asked for")
+	}
+
+	return &Conn{conn: conn, br: br, client: true}, nil
+}
+
+//Upgrade takes over the HTTP connection of a websocket handshake request, it's the server side of Dial
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != "GET" || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "websocket handshake expected", http.StatusBadRequest)
+		return nil, errors.New("websocket: not a handshake request")
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket isn't supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response can't be hijacked")
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", acceptKey(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: conn, br: rw.Reader}, nil
+}
+
+//hasToken reports whether a comma separated header lists the token, e.g. Connection: keep-alive, Upgrade
+func hasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+//ReadMessage returns the next text or binary message, ping frames are answered on the way.
+//ErrClosed is returned once the peer closes the connection
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	var text bool
+	started := false
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			if err := checkClose(payload); err != nil {
+				return nil, c.fail(closeProtocolError, err.Error())
+			}
+			c.writeFrame(opClose, payload)
+			c.conn.Close()
+			return nil, ErrClosed
+		case opText, opBinary:
+			if started {
+				return nil, c.fail(closeProtocolError, "new message inside a fragmented one")
+			}
+			started = true
+			text = op == opText
+		case opContinuation:
+			if !started {
+				return nil, c.fail(closeProtocolError, "continuation frame without a message")
+			}
+		default:
+			return nil, c.fail(closeProtocolError, fmt.Sprintf("unknown opcode %d", op))
+		}
+
+		if len(msg)+len(payload) > maxMessageSize {
+			return nil, errors.New("websocket: message is too big")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			if text && !utf8.Valid(msg) {
+				return nil, c.fail(closeInvalidData, "text message isn't valid UTF-8")
+			}
+			return msg, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+
+	//RSV bits are for extensions and none are negotiated
+	if head[0]&0x70 != 0 {
+		err = c.fail(closeProtocolError, fmt.Sprintf("reserved bits %#x are set", head[0]&0x70))
+		return
+	}
+	//Clients mask every frame they send, servers never do
+	if masked == c.client {
+		if c.client {
+			err = c.fail(closeProtocolError, "masked frame from the server")
+		} else {
+			err = c.fail(closeProtocolError, "unmasked frame from the client")
+		}
+		return
+	}
+
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxMessageSize {
+		err = errors.New("websocket: frame is too big")
+		return
+	}
+
+	//Control frames are answered in the middle of fragmented messages, so they can't be fragmented themselves
+	if op&0x8 != 0 {
+		if !fin {
+			err = c.fail(closeProtocolError, fmt.Sprintf("fragmented control frame %d", op))
+			return
+		}
+		if length > maxControlPayload {
+			err = c.fail(closeProtocolError, fmt.Sprintf("control frame %d is longer than %d bytes", op, maxControlPayload))
+			return
+		}
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return
+}
+
+//WriteMessage sends data as a single text frame
+func (c *Conn) WriteMessage(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if op == opClose {
+		c.closed = true
+	}
+
+	frame := []byte{0x80 | op}
+	var maskBit byte
+	if c.client {
+		//Frames sent by clients are always masked
+		maskBit = 0x80
+	}
+
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+//checkClose validates the payload of a close frame: it's empty or
+//a status code which might be sent over the wire followed by a UTF-8 reason
+func checkClose(payload []byte) error {
+	if len(payload) == 0 {
+		return nil
+	}
+	if len(payload) == 1 {
+		return errors.New("close frame with a truncated status code")
+	}
+
+	code := binary.BigEndian.Uint16(payload)
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014, code >= 3000 && code <= 4999:
+	default:
+		return fmt.Errorf("close frame with invalid status code %d", code)
+	}
+
+	if !utf8.Valid(payload[2:]) {
+		return errors.New("close reason isn't valid UTF-8")
+	}
+	return nil
+}
+
+//fail closes the connection with the given code after a frame RFC 6455 forbids
+func (c *Conn) fail(code uint16, reason string) error {
+	c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, code))
+	c.conn.Close()
+	return fmt.Errorf("%w: %s", ErrProtocol, reason)
+}
+
+//Close sends a close frame and closes the connection without waiting for the peer's answer
+func (c *Conn) Close() error {
+	c.writeFrame(opClose, []byte{0x03, 0xe8}) //1000, normal closure
+	return c.conn.Close()
+}
@@ -0,0 +1,192 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConn_Echo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		//A fragmented message, a ping in the middle of it must be answered
+		conn.writeFrame(opText, nil)
+		conn.wmu.Lock()
+		conn.conn.Write([]byte{0x01, 0x03, 'o', 'n', 'e'})
+		conn.conn.Write([]byte{0x89, 0x02, 'h', 'i'})
+		conn.conn.Write([]byte{0x80, 0x04, ' ', 't', 'w', 'o'})
+		conn.wmu.Unlock()
+
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(msg)
+		}
+	}))
+	defer srv.Close()
+
+	conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")+"/socket", nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(msg))
+
+	msg, err = conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "one two", string(msg))
+
+	for _, size := range []int{5, 200, 70000} {
+		data := bytes.Repeat([]byte("x"), size)
+		assert.NoError(t, conn.WriteMessage(data))
+
+		msg, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, data, msg)
+	}
+
+	assert.NoError(t, conn.Close())
+	assert.Equal(t, ErrClosed, conn.WriteMessage([]byte("late")))
+}
+
+func TestDial_NotWebsocket(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+	assert.Error(t, err)
+
+	_, err = Dial(context.Background(), srv.URL, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestConn_InvalidControlFrames(t *testing.T) {
+	for name, frame := range map[string][]byte{
+		"fragmented ping": {0x09, 0x02, 'h', 'i'},
+		"long ping":       append([]byte{0x89, 126, 0x00, 126}, bytes.Repeat([]byte("x"), 126)...),
+		"long close":      append([]byte{0x88, 126, 0x00, 200}, bytes.Repeat([]byte("x"), 200)...),
+	} {
+		closed := make(chan []byte, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := Upgrade(w, r)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.conn.Close()
+
+			conn.wmu.Lock()
+			conn.conn.Write(frame)
+			conn.wmu.Unlock()
+
+			//The client answers with a close frame of its own
+			_, op, payload, err := conn.readFrame()
+			if assert.NoError(t, err, name) {
+				assert.Equal(t, byte(opClose), op, name)
+			}
+			closed <- payload
+		}))
+
+		conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+		if assert.NoError(t, err, name) {
+			_, err = conn.ReadMessage()
+			assert.True(t, errors.Is(err, ErrProtocol), name)
+			assert.Equal(t, []byte{0x03, 0xea}, <-closed, name)
+		}
+		srv.Close()
+	}
+}
+
+func TestConn_InvalidFrames(t *testing.T) {
+	for name, tc := range map[string]struct {
+		frame []byte
+		code  []byte
+	}{
+		"reserved bits":        {[]byte{0xc1, 0x01, 'x'}, []byte{0x03, 0xea}},
+		"masked by the server": {[]byte{0x81, 0x81, 0x00, 0x00, 0x00, 0x00, 'x'}, []byte{0x03, 0xea}},
+		"invalid UTF-8 text":   {[]byte{0x81, 0x02, 0xc3, 0x28}, []byte{0x03, 0xef}},
+		"truncated close code": {[]byte{0x88, 0x01, 0x03}, []byte{0x03, 0xea}},
+		"reserved close code":  {[]byte{0x88, 0x02, 0x03, 0xed}, []byte{0x03, 0xea}},
+		"invalid close reason": {[]byte{0x88, 0x04, 0x03, 0xe8, 0xc3, 0x28}, []byte{0x03, 0xea}},
+	} {
+		closed := make(chan []byte, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := Upgrade(w, r)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.conn.Close()
+
+			conn.wmu.Lock()
+			conn.conn.Write(tc.frame)
+			conn.wmu.Unlock()
+
+			_, op, payload, err := conn.readFrame()
+			if assert.NoError(t, err, name) {
+				assert.Equal(t, byte(opClose), op, name)
+			}
+			closed <- payload
+		}))
+
+		conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+		if assert.NoError(t, err, name) {
+			_, err = conn.ReadMessage()
+			assert.True(t, errors.Is(err, ErrProtocol), name)
+			assert.Equal(t, tc.code, <-closed, name)
+		}
+		srv.Close()
+	}
+}
+
+func TestDial_InvalidHandshake(t *testing.T) {
+	for name, headers := range map[string]string{
+		"no connection upgrade": "Upgrade: websocket\r\nConnection: keep-alive\r\n",
+		"unasked extension":     "Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Extensions: permessage-deflate\r\n",
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" + headers +
+				"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+			rw.Flush()
+		}))
+
+		_, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+		assert.Error(t, err, name)
+		srv.Close()
+	}
+
+	//Connection might list other tokens along with Upgrade
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, _ := w.(http.Hijacker).Hijack()
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: keep-alive, upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+	}))
+	defer srv.Close()
+
+	conn, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
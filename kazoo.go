@@ -14,6 +14,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net"
 	"net/http"
@@ -29,7 +30,6 @@ import (
 
 var (
 	apiURL = "http://localhost:8000/v2"
-	token  = ""
 	ver    = "1.0.1"
 )
 
@@ -50,16 +50,18 @@ type RequestEnvelope struct {
 //for each request contains body: POST,PUT
 type ResponseEnvelope struct {
 	//Data      interface{} `json:"data"`
-	AuthToken string `json:"auth_token,omitempty"`
-	Status    string `json:"status,omitempty"`     //one of "success", "error" or "fatal"
-	Message   string `json:"message,omitempty"`    //optional message that's should clarify
-	Error     string `json:"error,omitempty"`      //error code
-	RequestID string `json:"request_id,omitempty"` //for debugging purposes
-	PageSize  int    `json:"page_size,omitempty"`
-	Revision  string `json:"revision"`
-	Timestamp string `json:"timestamp"`
-	Version   string `json:"version"`
-	Node      string `json:"node"`
+	AuthToken    string  `json:"auth_token,omitempty"`
+	Status       string  `json:"status,omitempty"`     //one of "success", "error" or "fatal"
+	Message      string  `json:"message,omitempty"`    //optional message that's should clarify
+	Error        string  `json:"error,omitempty"`      //error code
+	RequestID    string  `json:"request_id,omitempty"` //for debugging purposes
+	PageSize     int     `json:"page_size,omitempty"`
+	StartKey     PageKey `json:"start_key,omitempty"`      //key of the current page
+	NextStartKey PageKey `json:"next_start_key,omitempty"` //key of the next page, empty on the last one
+	Revision     string  `json:"revision"`
+	Timestamp    string  `json:"timestamp"`
+	Version      string  `json:"version"`
+	Node         string  `json:"node"`
 }
 
 //ErrorResponseEnvelope represents Error data recieved from Kazoo (in case if response code >= 300)
@@ -81,22 +83,14 @@ type AuthResponse struct {
 	AuthToken string                 `json:"auth_token"`
 }
 
-//Paginator is structure which contains data and pagination features
-type Paginator struct {
-	PageSize int64
-	HasNext  bool
-	HasPrev  bool
-}
-
-/*func (p *Paginator) Next() {
-
-}*/
-
 // APIClient manages communication with a Kazoo API server
 // In most cases there should be only one, shared, APIClient.
 type APIClient struct {
-	cfg    *Configuration
-	common service // Reuse a single struct instead of allocating one for each service on the heap.
+	cfg        *Configuration
+	tokens     TokenStore
+	limiter    *rateLimiter
+	middleware []Middleware
+	common     service // Reuse a single struct instead of allocating one for each service on the heap.
 
 	// API Services
 	AccountsAPI     *AccountsAPIService
@@ -111,6 +105,7 @@ type APIClient struct {
 	StorageAPI     *StorageAPIService
 	LimitsAPI      *LimitsAPIService
 	ClicktocallAPI *ClicktocallAPIService
+	MetaflowsAPI   *MetaflowsAPIService
 }
 
 type service struct {
@@ -129,6 +124,16 @@ func (t *Timestamp) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+//MarshalJSON encodes the time as Gregorian seconds, the way Kazoo sends it
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(time.Time(t).Unix()+gregorianSecondsSinceUnix, 10)), nil
+}
+
+//Time returns the timestamp as time.Time
+func (t Timestamp) Time() time.Time {
+	return time.Time(t)
+}
+
 // NewAPIClient creates a new API client.
 //Requires an API key or a set of username/password credentials
 //Requires a userAgent string describing your application.
@@ -164,6 +169,15 @@ func NewAPIClient(cfg *Configuration) (api *APIClient, err error) {
 	c.cfg = cfg
 	c.common.client = c
 
+	//Each client owns its token, so clients talking to
+	//different clusters or accounts don't clobber each other's sessions
+	c.tokens = cfg.TokenStore
+	if c.tokens == nil {
+		c.tokens = NewMemoryTokenStore()
+	}
+
+	c.limiter = newRateLimiter(cfg.RateLimit)
+
 	// API Services
 	c.AccountsAPI = (*AccountsAPIService)(&c.common)
 	c.AppsStoreAPI = (*AppsStoreAPIService)(&c.common)
@@ -176,6 +190,7 @@ func NewAPIClient(cfg *Configuration) (api *APIClient, err error) {
 	c.StorageAPI = (*StorageAPIService)(&c.common)
 	c.LimitsAPI = (*LimitsAPIService)(&c.common)
 	c.ClicktocallAPI = (*ClicktocallAPIService)(&c.common)
+	c.MetaflowsAPI = (*MetaflowsAPIService)(&c.common)
 
 	return c, nil
 }
@@ -229,57 +244,173 @@ func readBody(resp *http.Response, v interface{}) error {
 	return resp.Body.Close()
 }
 
+//path joins escaped segments into a path relative to BasePath,
+//e.g. path("accounts", acc, "phone_numbers", "+74955555555")
 func path(segments ...string) string {
 	r := ""
 	for _, seg := range segments {
 		r += "/"
-		r += url.QueryEscape(seg)
+		r += url.PathEscape(seg)
 	}
 	return r
 }
 
-// callAPI do the request.
-func (c *APIClient) callAPI(ctx context.Context, request *http.Request) (resp *http.Response, err error) {
+// callAPI sends the request through the middleware chain.
+//The returned call always has a Response
+func (c *APIClient) callAPI(ctx context.Context, request *http.Request) (call *Call, err error) {
+	if ctx == nil {
+		ctx = request.Context()
+	}
+
+	call = newCall(request)
 
-	if !checkAuthState() {
-		if err := c.Authenticate(ctx); err != nil {
-			return nil, err
+	h := c.send
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	if err := h(ctx, call); err != nil {
+		if call.Response != nil {
+			discardResponse(call.Response)
 		}
-		request.Header.Add("X-Auth-Token", token)
-		resp, err = c.cfg.HTTPClient.Do(request)
+		return nil, err
+	}
+
+	if call.Response == nil {
+		return nil, reportError("no response for %s %s", request.Method, request.URL.Path)
+	}
+
+	fillResponseMeta(ctx, call)
+
+	return call, nil
+}
+
+//send is the innermost Handler of the middleware chain
+func (c *APIClient) send(ctx context.Context, call *Call) error {
+	resp, err := c.roundTrip(ctx, call.Request)
+	if err != nil {
+		return err
+	}
+	call.Response = resp
+
+	return decodeResponse(call, len(c.middleware) > 0)
+}
+
+//roundTrip sends the request applying rate limits and the retry policy
+func (c *APIClient) roundTrip(ctx context.Context, request *http.Request) (resp *http.Response, err error) {
+	policy := c.cfg.RetryPolicy
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if err := rewindBody(request); err != nil {
+				return nil, err
+			}
+		}
+
+		release, err := c.limiter.acquire(ctx, request)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		request.Header.Add("X-Auth-Token", token)
-		resp, err = c.cfg.HTTPClient.Do(request)
-		if err != nil {
+
+		resp, err = c.doAuthorized(ctx, request)
+		c.limiter.observe(request, resp)
+		if err != nil || resp == nil {
+			release()
+		} else {
+			resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+		}
+
+		if !policy.retryable(ctx, attempt, request, resp, err) {
+			return resp, err
+		}
+
+		wait, ok := policy.delay(attempt, resp)
+		if !ok {
+			//The server asked to come back later than we're ready to wait
+			return resp, err
+		}
+		discardResponse(resp)
+
+		if err := sleepContext(ctx, wait); err != nil {
 			return nil, err
 		}
 	}
+}
+
+//doAuthorized makes a single attempt of the request with the client's token
+func (c *APIClient) doAuthorized(ctx context.Context, request *http.Request) (resp *http.Response, err error) {
+	authToken, err := c.authToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("X-Auth-Token", authToken)
+	resp, err = c.cfg.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
 
 	if resp != nil {
 		switch resp.StatusCode {
 		case 401:
-			err := c.Authenticate(ctx)
+			//The token was revoked or expired earlier than we expected
+			discardResponse(resp)
+			if err := c.tokens.ClearToken(ctx); err != nil {
+				return nil, err
+			}
+
+			authToken, err := c.authToken(ctx)
 			if err != nil {
 				return nil, err
 			}
-			request.Header.Add("X-Auth-Token", token)
+			if err := rewindBody(request); err != nil {
+				return nil, err
+			}
+			request.Header.Set("X-Auth-Token", authToken)
 			resp, err = c.cfg.HTTPClient.Do(request)
 			if err != nil {
 				return nil, err
 			}
+			return resp, nil
 		case 0:
 			return nil, errors.New("have not recieved a response from the server")
 		default:
 			return resp, nil
 		}
-	} else {
-		return nil, NewError("ServerError", "nil response", nil)
 	}
 
-	return nil, err
+	return nil, NewError("ServerError", "nil response", nil)
+}
+
+//authToken returns a valid token from the client's TokenStore,
+//authenticating on the server if there is no such token
+func (c *APIClient) authToken(ctx context.Context) (string, error) {
+	t, err := c.tokens.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !t.Valid(time.Now().Add(tokenExpirySkew)) {
+		if err := c.Authenticate(ctx); err != nil {
+			return "", err
+		}
+
+		t, err = c.tokens.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+		if t == nil {
+			return "", NewError("AuthenticationError", "token store returned no token after authentication", nil)
+		}
+	}
+
+	return t.Value, nil
+}
+
+//Token returns the auth token currently stored by the client.
+//Nil is returned if the client hasn't authenticated yet
+func (c *APIClient) Token(ctx context.Context) (*Token, error) {
+	return c.tokens.Token(ctx)
 }
 
 //ChangeBasePath enables switching to mocks
@@ -291,6 +422,8 @@ func (c *APIClient) ChangeBasePath(path string) {
 //a new request
 type Request struct {
 	CTX          context.Context
+	Service      string //name of the API service for the middleware, e.g. "AccountsAPI"
+	Operation    string //name of the service method for the middleware, e.g. "GetAccount"
 	Path         string
 	Method       string
 	PostBody     interface{}
@@ -298,6 +431,7 @@ type Request struct {
 	QueryParams  url.Values
 	FileName     string
 	FileBytes    []byte
+	NoRetry      bool //disables automatic retries of the request
 }
 
 // prepareRequest build the request
@@ -305,8 +439,9 @@ func (c *APIClient) prepareRequest(req *Request) (httpRequest *http.Request, err
 
 	var body *bytes.Buffer
 
-	//Initialize empty map of headers
-	req.HeaderParams = make(map[string]string)
+	if req.HeaderParams == nil {
+		req.HeaderParams = make(map[string]string)
+	}
 
 	// Detect postBody type and post.
 	if req.PostBody != nil {
@@ -341,7 +476,8 @@ func (c *APIClient) prepareRequest(req *Request) (httpRequest *http.Request, err
 
 	// Generate a new request
 	if body != nil {
-		httpRequest, err = http.NewRequest(req.Method, reqURL.String(), body)
+		//bytes.Reader lets http.NewRequest set GetBody, so the body might be replayed on retries
+		httpRequest, err = http.NewRequest(req.Method, reqURL.String(), bytes.NewReader(body.Bytes()))
 	} else {
 		httpRequest, err = http.NewRequest(req.Method, reqURL.String(), nil)
 	}
@@ -367,11 +503,21 @@ func (c *APIClient) prepareRequest(req *Request) (httpRequest *http.Request, err
 	httpRequest.Header.Add("User-Agent", c.cfg.UserAgent)
 
 	ctx := req.CTX
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
-	if ctx != nil {
-		// add context to the request
-		httpRequest = httpRequest.WithContext(ctx)
+	info := &callInfo{
+		service:   req.Service,
+		operation: req.Operation,
+		noRetry:   req.NoRetry,
+	}
+	if body != nil {
+		info.body = body.Bytes()
+	}
+	httpRequest = httpRequest.WithContext(withCallInfo(ctx, info))
 
+	if req.CTX != nil {
 		// Walk through any authentication.
 
 		// Basic HTTP Authentication
@@ -389,6 +535,10 @@ func (c *APIClient) prepareRequest(req *Request) (httpRequest *http.Request, err
 		httpRequest.Header.Add(header, value)
 	}
 
+	if rev := revisionFromContext(ctx); rev != "" && conditional(httpRequest.Method, httpRequest.URL.Path) {
+		httpRequest.Header.Set("If-Match", rev)
+	}
+
 	return httpRequest, nil
 }
 
@@ -447,14 +597,21 @@ func reportError(format string, a ...interface{}) error {
 
 //If we get response with error code (i.e. >=300) we can easily report error
 func prepareError(resp *http.Response) error {
-	var errData ErrorResponseEnvelope
+	return prepareAPIError(resp)
+}
 
-	err := readBody(resp, &errData)
+//prepareAPIError reads Kazoo's error envelope into *APIError
+func prepareAPIError(resp *http.Response) *APIError {
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return reportError("Can't decode error response: %v", err)
+		return (&APIError{StatusCode: resp.StatusCode, ErrorMessage: resp.Status}).withCause(err)
 	}
 
-	return reportError("Code: %v, Message: %s, Problem: %#v", resp.Status, errData.Message, errData.Data)
+	//Not every error is sent by crossbar itself (e.g. a proxy in front of it),
+	//so the body isn't required to be Kazoo's envelope
+	e, _ := newAPIError(resp.StatusCode, resp.Status, body)
+
+	return e
 }
 
 // Add a file to the multipart request
@@ -545,7 +702,7 @@ func (c *APIClient) Authenticate(ctx context.Context) error {
 	}
 
 	switch authResponse.StatusCode {
-	case 201:
+	case 200, 201:
 		//Succesfull authentication
 		authdata := AuthResponse{}
 		err := readBody(authResponse, &authdata)
@@ -553,41 +710,31 @@ func (c *APIClient) Authenticate(ctx context.Context) error {
 			return NewError("BodyError", "", err)
 		}
 
-		token = authdata.AuthToken
-		go authTokenExpire()
+		if authdata.AuthToken == "" {
+			return NewError("AuthenticationError", "server returned an empty auth_token", nil)
+		}
 
-		return nil
+		return c.tokens.SetToken(ctx, &Token{
+			Value:     authdata.AuthToken,
+			ExpiresAt: tokenExpiration(authdata.AuthToken),
+		})
 
 	default:
-		return NewError("AuthenticationError", fmt.Sprint(authResponse.StatusCode), nil)
+		return prepareError(authResponse)
 	}
-
-	//return nil
 }
 
-func authTokenExpire() {
-	timer := time.NewTimer(60 * time.Minute)
-	<-timer.C
-	token = ""
-}
-
-func checkAuthState() bool {
-	if len(token) == 0 {
-		return false
-	}
-	return true
-}
+//gregorianSecondsSinceUnix is the number of seconds between year 0 and the Unix epoch
+const gregorianSecondsSinceUnix int64 = 62167219200
 
 func gregorianToUnixString(greg string) (unix *time.Time, err error) {
-	var gregSecondsSinceUnix int64 = 62167219200
-
 	gDate, err := strconv.ParseInt(greg, 10, 64)
 	if err != nil {
 		return nil, errors.New("can't parse given string to int64")
 	}
 
 	//If we want to know how many seconds passed, then we have to minus amount of seconds passed till the Unix epoch
-	unixTimestamp := gDate - gregSecondsSinceUnix
+	unixTimestamp := gDate - gregorianSecondsSinceUnix
 
 	ts := time.Unix(unixTimestamp, 0)
 
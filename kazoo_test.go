@@ -1,9 +1,12 @@
 package kazooapi_test
 
 import (
+	"encoding/json"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	kazooapi "github.com/sashker/kazoo-go"
@@ -113,7 +116,7 @@ func MockKazooServer(t *testing.T, body string) *httptest.Server {
 		io.WriteString(w, body)
 	})
 
-	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/phone_numbers", func(w http.ResponseWriter, r *http.Request) {
+	phoneNumbersHandler := func(w http.ResponseWriter, r *http.Request) {
 		t.Log("Hit phone_numbers")
 
 		w.Header().Add("Server", "Cowboy")
@@ -143,13 +146,84 @@ func MockKazooServer(t *testing.T, body string) *httptest.Server {
 		}
 
 		io.WriteString(w, body)
-	})
+	}
+
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/phone_numbers", phoneNumbersHandler)
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/phone_numbers/", phoneNumbersHandler)
 
 	srv := httptest.NewServer(mux)
 
 	return srv
 }
 
+//DocumentRequest is a request seen by MockDocumentServer
+type DocumentRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Data   interface{}
+}
+
+//MockDocumentServer answers every request under /v2/accounts with data as the "data"
+//field of a successful response and records what the client has sent
+func MockDocumentServer(t *testing.T, data string) (*httptest.Server, func() DocumentRequest) {
+	var (
+		mu   sync.Mutex
+		last DocumentRequest
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		seen := DocumentRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery}
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		if len(body) > 0 {
+			var env struct {
+				Data interface{} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(body, &env))
+			seen.Data = env.Data
+		}
+
+		mu.Lock()
+		last = seen
+		mu.Unlock()
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, `{"data":`+data+`,"revision":"2-c2d9ea5f0e4bd1b53bd0d57d4b1cf6e4","request_id":"4a1c8f2be0a4f5d2f6b9a39e9b6f7e10","status":"success"}`)
+	})
+
+	return httptest.NewServer(mux), func() DocumentRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+}
+
+//newMockClient returns a client talking to the mock server
+func newMockClient(t *testing.T, srv *httptest.Server) *kazooapi.APIClient {
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+	return clt
+}
+
+//assertRequest checks method and path of a request seen by MockDocumentServer
+func assertRequest(t *testing.T, req DocumentRequest, method, path string) {
+	t.Helper()
+	assert.Equal(t, method, req.Method)
+	assert.Equal(t, path, req.Path)
+}
+
 func TestMakingNewAPIClient(t *testing.T) {
 	cfg := kazooapi.NewConfiguration()
 
@@ -2,19 +2,19 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 )
 
 type LimitsAPIService service
 
 type (
 	Limits struct {
+		AllowPostpay           bool     `json:"allow_postpay,omitempty"`
 		AllowPrepay            bool     `json:"allow_prepay,omitempty"`
 		AuthzResourceTypes     []string `json:"authz_resource_types,omitempty"`
 		BurstTrunks            int64    `json:"burst_trunks,omitempty"`
 		Calls                  int64    `json:"calls,omitempty"`
 		InboundTrunks          int64    `json:"inbound_trunks,omitempty"`
+		MaxPostpayAmount       float64  `json:"max_postpay_amount,omitempty"`
 		OutboundTrunks         int64    `json:"outbound_trunks,omitempty"`
 		ResourceConsumingCalls int64    `json:"resource_consuming_calls,omitempty"`
 		TwowayTrunks           int64    `json:"twoway_trunks,omitempty"`
@@ -22,101 +22,57 @@ type (
 )
 
 func (api *LimitsAPIService) GetLimits(ctx context.Context, acc string) (limits *Limits, err error) {
-	var response struct {
-		Data Limits `json:"data"`
-		ResponseEnvelope
-	}
-
 	if acc == "" {
 		return nil, reportError("account id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/limits",
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
-	}
+	limits, _, err = do[*Limits](ctx, api.client, endpoint{
+		service:   "LimitsAPI",
+		operation: "GetLimits",
+		method:    "GET",
+		path:      path("accounts", acc, "limits"),
+	})
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
-	}
-
-	limits = &response.Data
-
-	return limits, nil
+	return limits, err
 }
 
 func (api *LimitsAPIService) UpdateLimits(ctx context.Context, acc string, input *Limits) (limits *Limits, err error) {
-	var response struct {
-		Data Limits `json:"data"`
-		ResponseEnvelope
-	}
-
-	params := Request{
-		CTX:    ctx,
-		Method: "POST",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/limits",
-	}
-
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
-
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
-	}
-
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
-	}
-
-	params.PostBody = body
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("can't prepare a request %s", err)
-	}
+	limits, _, err = do[*Limits](ctx, api.client, endpoint{
+		service:   "LimitsAPI",
+		operation: "UpdateLimits",
+		method:    "POST",
+		path:      path("accounts", acc, "limits"),
+		body:      input,
+	})
+
+	return limits, err
+}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+//PatchLimits merges input into limits of the account leaving other fields untouched
+func (api *LimitsAPIService) PatchLimits(ctx context.Context, acc string, input map[string]interface{}) (limits *Limits, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		//bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("error status code: %d", resp.StatusCode)
-	}
+	limits, _, err = do[*Limits](ctx, api.client, endpoint{
+		service:   "LimitsAPI",
+		operation: "PatchLimits",
+		method:    "PATCH",
+		path:      path("accounts", acc, "limits"),
+		body:      input,
+	})
 
-	decoder := json.NewDecoder(resp.Body)
+	return limits, err
+}
 
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+//ModifyLimits fetches limits of the account, lets mutate change them and saves them
+//only if they haven't been changed since they were fetched, fields Limits doesn't model are kept.
+//On conflict the whole round is repeated with the fresh document up to DefaultConflictAttempts times.
+//Limits fetched without a revision aren't saved, ErrNoRevision is returned
+func (api *LimitsAPIService) ModifyLimits(ctx context.Context, acc string, mutate func(limits *Limits) error) (limits *Limits, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	limits = &response.Data
-
-	return limits, nil
-}
\ No newline at end of file
+	return modifyDocument(ctx, api.client, "LimitsAPI", "ModifyLimits", path("accounts", acc, "limits"), mutate)
+}
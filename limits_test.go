@@ -154,3 +154,17 @@ func TestLimitsAPIService_GetLimits(t *testing.T) {
 
 	assert.Equal(t, int64(1000), resp.TwowayTrunks, "ID's should be equal")
 }
+
+func TestLimitsAPIService_PatchLimits(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"twoway_trunks":1000,"inbound_trunks":20,"id":"limits"}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	limits, err := clt.LimitsAPI.PatchLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", map[string]interface{}{"inbound_trunks": 20})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), limits.InboundTrunks)
+	assertRequest(t, last(), "PATCH", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits")
+	assert.Equal(t, map[string]interface{}{"inbound_trunks": float64(20)}, last().Data)
+}
@@ -0,0 +1,257 @@
+package kazooapi
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type MetaflowsAPIService service
+
+//Modules of metaflow actions, MetaflowAction.Module
+const (
+	MetaflowTransfer   = "transfer"
+	MetaflowHold       = "hold"
+	MetaflowPark       = "park"
+	MetaflowRecordCall = "record_call"
+	MetaflowIntercept  = "intercept"
+	MetaflowHangup     = "hangup"
+)
+
+//Data of metaflow actions. park and record_call actions use ParkModule
+//and RecordCallModule, the latter accepts toggle action as well, hangup has no data
+type (
+	TransferMetaflow struct {
+		Target       string `json:"target,omitempty"`        //number the call is transferred to
+		TakebackDTMF string `json:"takeback_dtmf,omitempty"` //keys returning the call to the transferor
+		MOH          string `json:"moh,omitempty"`           //media played to the transferee
+		Ringback     string `json:"ringback,omitempty"`
+		TransferType string `json:"Transfer-Type,omitempty"` //attended - is default value, blind
+	}
+
+	HoldMetaflow struct {
+		MOHALeg   string `json:"moh_aleg,omitempty"`
+		MOHBLeg   string `json:"moh_bleg,omitempty"`
+		UnholdKey string `json:"unhold_key,omitempty"`
+	}
+
+	InterceptMetaflow struct {
+		TargetType    string `json:"target_type"` //device, user or number
+		TargetID      string `json:"target_id"`
+		UnbridgedOnly bool   `json:"unbridged_only,omitempty"`
+	}
+)
+
+//metaflowModules returns new typed data of metaflow actions
+var metaflowModules = map[string]func() interface{}{
+	MetaflowTransfer:   func() interface{} { return &TransferMetaflow{} },
+	MetaflowHold:       func() interface{} { return &HoldMetaflow{} },
+	MetaflowPark:       func() interface{} { return &ParkModule{} },
+	MetaflowRecordCall: func() interface{} { return &RecordCallModule{} },
+	MetaflowIntercept:  func() interface{} { return &InterceptMetaflow{} },
+}
+
+//NewMetaflowAction returns an action of the module, nil data is sent as an empty object
+func NewMetaflowAction(module string, data interface{}) MetaflowAction {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	return MetaflowAction{CallflowAction{
+		Module:   module,
+		Children: map[string]interface{}{},
+		Data:     data,
+	}}
+}
+
+//TypedData decodes data of known actions into their typed struct (e.g. *TransferMetaflow),
+//data of other actions is returned as json.RawMessage
+func (a MetaflowAction) TypedData() (interface{}, error) {
+	raw, err := json.Marshal(a.Data)
+	if err != nil {
+		return nil, reportError("can't encode metaflow data: %v", err)
+	}
+
+	newData, ok := metaflowModules[a.Module]
+	if !ok {
+		return json.RawMessage(raw), nil
+	}
+
+	data := newData()
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, reportError("can't decode %s metaflow data: %v", a.Module, err)
+	}
+
+	return data, nil
+}
+
+//GetMetaflows returns metaflows of the account
+func (api *MetaflowsAPIService) GetMetaflows(ctx context.Context, acc string) (mf *Metaflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	mf, _, err = do[*Metaflow](ctx, api.client, endpoint{
+		service:   "MetaflowsAPI",
+		operation: "GetMetaflows",
+		method:    "GET",
+		path:      path("accounts", acc, "metaflows"),
+	})
+
+	return mf, err
+}
+
+//UpdateMetaflows replaces metaflows of the account with input
+func (api *MetaflowsAPIService) UpdateMetaflows(ctx context.Context, acc string, input *Metaflow) (mf *Metaflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	mf, _, err = do[*Metaflow](ctx, api.client, endpoint{
+		service:   "MetaflowsAPI",
+		operation: "UpdateMetaflows",
+		method:    "POST",
+		path:      path("accounts", acc, "metaflows"),
+		body:      input,
+	})
+
+	return mf, err
+}
+
+//DeleteMetaflows removes metaflows of the account
+func (api *MetaflowsAPIService) DeleteMetaflows(ctx context.Context, acc string) (mf *Metaflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	mf, _, err = do[*Metaflow](ctx, api.client, endpoint{
+		service:   "MetaflowsAPI",
+		operation: "DeleteMetaflows",
+		method:    "DELETE",
+		path:      path("accounts", acc, "metaflows"),
+	})
+
+	return mf, err
+}
+
+//GetUserMetaflows returns metaflows of the user, nil if the user has none
+func (api *MetaflowsAPIService) GetUserMetaflows(ctx context.Context, acc, id string) (*Metaflow, error) {
+	if id == "" {
+		return nil, reportError("user id is required field")
+	}
+
+	return api.getOwnerMetaflows(ctx, "GetUserMetaflows", acc, "users", id)
+}
+
+//UpdateUserMetaflows replaces metaflows of the user, nil input removes them.
+//The rest of the user document is saved as is
+func (api *MetaflowsAPIService) UpdateUserMetaflows(ctx context.Context, acc, id string, input *Metaflow) (*Metaflow, error) {
+	if id == "" {
+		return nil, reportError("user id is required field")
+	}
+
+	return api.updateOwnerMetaflows(ctx, "UpdateUserMetaflows", acc, "users", id, input)
+}
+
+//GetDeviceMetaflows returns metaflows of the device, nil if the device has none
+func (api *MetaflowsAPIService) GetDeviceMetaflows(ctx context.Context, acc, id string) (*Metaflow, error) {
+	if id == "" {
+		return nil, reportError("device id is required field")
+	}
+
+	return api.getOwnerMetaflows(ctx, "GetDeviceMetaflows", acc, "devices", id)
+}
+
+//UpdateDeviceMetaflows replaces metaflows of the device, nil input removes them.
+//The rest of the device document is saved as is
+func (api *MetaflowsAPIService) UpdateDeviceMetaflows(ctx context.Context, acc, id string, input *Metaflow) (*Metaflow, error) {
+	if id == "" {
+		return nil, reportError("device id is required field")
+	}
+
+	return api.updateOwnerMetaflows(ctx, "UpdateDeviceMetaflows", acc, "devices", id, input)
+}
+
+//ownerDocument is a user or device document kept raw, so fields unknown to User and Device survive saving
+type ownerDocument map[string]json.RawMessage
+
+func (doc ownerDocument) metaflows() (*Metaflow, error) {
+	raw, ok := doc["metaflows"]
+	if !ok {
+		return nil, nil
+	}
+
+	var mf *Metaflow
+	if err := json.Unmarshal(raw, &mf); err != nil {
+		return nil, reportError("can't decode metaflows: %v", err)
+	}
+
+	return mf, nil
+}
+
+func (api *MetaflowsAPIService) getOwnerMetaflows(ctx context.Context, operation, acc, collection, id string) (*Metaflow, error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	doc, _, err := do[ownerDocument](ctx, api.client, endpoint{
+		service:   "MetaflowsAPI",
+		operation: operation,
+		method:    "GET",
+		path:      path("accounts", acc, collection, id),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.metaflows()
+}
+
+//updateOwnerMetaflows saves the whole document with new metaflows, conflicting changes are retried
+func (api *MetaflowsAPIService) updateOwnerMetaflows(ctx context.Context, operation, acc, collection, id string, input *Metaflow) (mf *Metaflow, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	err = RetryOnConflict(ctx, DefaultConflictAttempts, func(ctx context.Context) error {
+		var meta ResponseMeta
+
+		doc, _, err := do[ownerDocument](WithResponseMeta(ctx, &meta), api.client, endpoint{
+			service:   "MetaflowsAPI",
+			operation: operation,
+			method:    "GET",
+			path:      path("accounts", acc, collection, id),
+		})
+		if err != nil {
+			return err
+		}
+
+		delete(doc, "metaflows")
+		if input != nil {
+			raw, err := json.Marshal(input)
+			if err != nil {
+				return reportError("can't encode metaflows: %v", err)
+			}
+			doc["metaflows"] = raw
+		}
+
+		doc, _, err = do[ownerDocument](WithRevision(ctx, meta.Revision), api.client, endpoint{
+			service:   "MetaflowsAPI",
+			operation: operation,
+			method:    "POST",
+			path:      path("accounts", acc, collection, id),
+			body:      doc,
+		})
+		if err != nil {
+			return err
+		}
+
+		mf, err = doc.metaflows()
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mf, nil
+}
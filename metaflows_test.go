@@ -0,0 +1,123 @@
+package kazooapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const accountMetaflows = `{
+	"binding_digit": "*",
+	"digit_timeout": 3000,
+	"listen_on": "both",
+	"numbers": {
+		"2": {"module": "transfer", "data": {"takeback_dtmf": "*1", "Transfer-Type": "blind"}, "children": {}},
+		"9": {"module": "hangup", "data": {}, "children": {}}
+	},
+	"patterns": {
+		"^2(\\d+)$": {"module": "transfer", "data": {"takeback_dtmf": "*1"}, "children": {}},
+		"^7(\\d+)$": {"module": "intercept", "data": {"target_type": "device", "target_id": "a1b2c3d4e5f60718293a4b5c6d7e8f90"}, "children": {}}
+	}
+}`
+
+func TestMetaflowsAPIService_AccountMetaflows(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, accountMetaflows)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	mf, err := clt.MetaflowsAPI.GetMetaflows(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/metaflows")
+	assert.Equal(t, "*", mf.BindingDigit)
+	assert.Equal(t, 3000, mf.DigitTimeout)
+	assert.Len(t, mf.Numbers, 2)
+
+	data, err := mf.Numbers["2"].TypedData()
+	assert.NoError(t, err)
+	assert.Equal(t, &kazooapi.TransferMetaflow{TakebackDTMF: "*1", TransferType: "blind"}, data)
+
+	data, err = mf.Patterns[`^7(\d+)$`].TypedData()
+	assert.NoError(t, err)
+	assert.Equal(t, &kazooapi.InterceptMetaflow{TargetType: "device", TargetID: "a1b2c3d4e5f60718293a4b5c6d7e8f90"}, data)
+
+	data, err = mf.Numbers["9"].TypedData()
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`{}`), data)
+
+	_, err = clt.MetaflowsAPI.UpdateMetaflows(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Metaflow{
+		BindingDigit: "*",
+		Numbers: map[string]kazooapi.MetaflowAction{
+			"1": kazooapi.NewMetaflowAction(kazooapi.MetaflowHold, &kazooapi.HoldMetaflow{UnholdKey: "1"}),
+			"3": kazooapi.NewMetaflowAction(kazooapi.MetaflowRecordCall, &kazooapi.RecordCallModule{Action: "toggle", Format: "mp3"}),
+			"9": kazooapi.NewMetaflowAction(kazooapi.MetaflowHangup, nil),
+		},
+	})
+	assert.NoError(t, err)
+	req := last()
+	assertRequest(t, req, "POST", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/metaflows")
+	assert.Equal(t, map[string]interface{}{
+		"binding_digit": "*",
+		"numbers": map[string]interface{}{
+			"1": map[string]interface{}{"module": "hold", "data": map[string]interface{}{"unhold_key": "1"}, "children": map[string]interface{}{}},
+			"3": map[string]interface{}{"module": "record_call", "data": map[string]interface{}{"action": "toggle", "format": "mp3"}, "children": map[string]interface{}{}},
+			"9": map[string]interface{}{"module": "hangup", "data": map[string]interface{}{}, "children": map[string]interface{}{}},
+		},
+	}, req.Data)
+
+	deleted, err := clt.MetaflowsAPI.DeleteMetaflows(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	assert.Equal(t, "*", deleted.BindingDigit)
+	assertRequest(t, last(), "DELETE", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/metaflows")
+}
+
+func TestMetaflowsAPIService_UserMetaflows(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{
+		"id": "d201633c77337fc469302947a56f4c44",
+		"first_name": "John",
+		"caller_id": {"internal": {"number": "2001"}},
+		"metaflows": {"numbers": {"9": {"module": "hangup", "data": {}, "children": {}}}}
+	}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	mf, err := acc.Metaflows.GetUser(ctx, "d201633c77337fc469302947a56f4c44")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/users/d201633c77337fc469302947a56f4c44")
+	assert.Equal(t, "hangup", mf.Numbers["9"].Module)
+
+	_, err = acc.Metaflows.UpdateDevice(ctx, "a1b2c3d4e5f60718293a4b5c6d7e8f90", &kazooapi.Metaflow{
+		Numbers: map[string]kazooapi.MetaflowAction{
+			"6": kazooapi.NewMetaflowAction(kazooapi.MetaflowPark, &kazooapi.ParkModule{Slot: "101"}),
+		},
+	})
+	assert.NoError(t, err)
+
+	//Fields unknown to Device are saved back untouched
+	req := last()
+	assertRequest(t, req, "POST", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/devices/a1b2c3d4e5f60718293a4b5c6d7e8f90")
+	doc := req.Data.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"internal": map[string]interface{}{"number": "2001"}}, doc["caller_id"])
+	assert.Equal(t, map[string]interface{}{
+		"numbers": map[string]interface{}{
+			"6": map[string]interface{}{"module": "park", "data": map[string]interface{}{"slot": "101"}, "children": map[string]interface{}{}},
+		},
+	}, doc["metaflows"])
+
+	_, err = clt.MetaflowsAPI.UpdateUserMetaflows(ctx, "4dee5c1bef3ace50911c9917c50c9f80", "d201633c77337fc469302947a56f4c44", nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, last().Data, "metaflows")
+	assert.Contains(t, last().Data, "caller_id")
+
+	_, err = clt.MetaflowsAPI.GetDeviceMetaflows(ctx, "4dee5c1bef3ace50911c9917c50c9f80", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package kazooapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+//Call describes a single service call passing through the middleware chain
+type Call struct {
+	//Service is the name of the API service, e.g. "CallflowsAPI"
+	Service string
+	//Operation is the name of the service method, e.g. "CreateCallflow"
+	Operation string
+	//AccountID is the account the call is made for, empty for calls outside of /accounts
+	AccountID string
+
+	//Request is the HTTP request to be sent, middleware might modify it (e.g. add headers)
+	//before passing the call further down the chain
+	Request *http.Request
+	//RequestEnvelope is the decoded request body, nil for requests without a JSON body
+	RequestEnvelope *RequestEnvelope
+
+	//Response is the HTTP response, it's set when the next handler returns.
+	//A JSON body has been read already and might be read again, other bodies (e.g. media) are streamed
+	Response *http.Response
+	//ResponseEnvelope is the decoded response envelope, nil if the response isn't JSON
+	ResponseEnvelope *ResponseEnvelope
+	//ResponseData is the raw "data" field of the response
+	ResponseData json.RawMessage
+}
+
+//Handler processes a call
+type Handler func(ctx context.Context, call *Call) error
+
+//Middleware wraps a Handler to observe or modify calls made by an APIClient
+type Middleware func(next Handler) Handler
+
+//Use appends middleware to the client's chain. The first added middleware
+//is the outermost one, i.e. it sees the call first and the response last.
+//It isn't safe to call Use concurrently with requests
+func (c *APIClient) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+//callInfo is attached to the request's context by prepareRequest,
+//so callAPI knows which service call is in flight
+type callInfo struct {
+	service   string
+	operation string
+	body      []byte
+	noRetry   bool
+}
+
+type callInfoKey struct{}
+
+func withCallInfo(ctx context.Context, info *callInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+//newCall describes the request for the middleware chain
+func newCall(request *http.Request) *Call {
+	call := &Call{
+		Request:   request,
+		AccountID: accountFromPath(request.URL.Path),
+	}
+
+	info, _ := request.Context().Value(callInfoKey{}).(*callInfo)
+	if info != nil {
+		call.Service = info.service
+		call.Operation = info.operation
+
+		if len(info.body) > 0 {
+			env := &RequestEnvelope{}
+			if err := json.Unmarshal(info.body, env); err == nil {
+				call.RequestEnvelope = env
+			}
+		}
+	}
+
+	if call.Service == "" {
+		call.Service = serviceFromPath(request.URL.Path)
+	}
+	if call.Operation == "" {
+		call.Operation = request.Method
+	}
+
+	return call
+}
+
+//serviceFromPath guesses the service by the collection name, e.g. /v2/accounts/{id}/users -> "users"
+func serviceFromPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] == "accounts" {
+			if i+2 < len(segments) {
+				return segments[i+2]
+			}
+			return "accounts"
+		}
+	}
+
+	if len(segments) > 0 {
+		return segments[len(segments)-1]
+	}
+	return ""
+}
+
+//decodeResponse decodes Kazoo's envelope out of a JSON response, other bodies (e.g. recordings or media)
+//are left untouched to be streamed. The body is buffered only if it's read once more: by middleware
+//when keepBody is set or by prepareAPIError for errors
+func decodeResponse(call *Call, keepBody bool) error {
+	resp := call.Response
+	if resp == nil || resp.Body == nil || !isJSON(resp.Header) {
+		return nil
+	}
+
+	var env struct {
+		Data json.RawMessage `json:"data"`
+		ResponseEnvelope
+	}
+
+	if !keepBody && resp.StatusCode < 300 {
+		err := json.NewDecoder(resp.Body).Decode(&env)
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case err == nil:
+			call.ResponseEnvelope = &env.ResponseEnvelope
+			call.ResponseData = env.Data
+		case errors.As(err, &syntaxErr), errors.As(err, &typeErr), err == io.EOF:
+			//Not an envelope, do reports it
+		default:
+			return err
+		}
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := json.Unmarshal(body, &env); err == nil {
+		call.ResponseEnvelope = &env.ResponseEnvelope
+		call.ResponseData = env.Data
+	}
+
+	return nil
+}
+
+//isJSON reports whether the body might be JSON by its Content-Type.
+//Bodies without the type or with a text one are tried too, as proxies and mocks don't always set it
+func isJSON(header http.Header) bool {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json") || strings.HasPrefix(mediaType, "text/")
+}
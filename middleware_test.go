@@ -0,0 +1,170 @@
+package kazooapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIClient_Middleware(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/users", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "staging", r.Header.Get("X-Kazoo-Cluster-ID"))
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{"id":"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4","first_name":"John","last_name":"Doe"},"revision":"1-7a2d6d2b0b5e0e2a0d2b8f0e8f2c1a3b","request_id":"3ae535b86688c03169207e2185d74aab","node":"o51LI7TmYyGMzigLbo-upw","status":"success"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	var order []string
+	var seen *kazooapi.Call
+
+	clt.Use(
+		func(next kazooapi.Handler) kazooapi.Handler {
+			return func(ctx context.Context, call *kazooapi.Call) error {
+				order = append(order, "audit:before")
+				err := next(ctx, call)
+				order = append(order, "audit:after")
+				seen = call
+				return err
+			}
+		},
+		func(next kazooapi.Handler) kazooapi.Handler {
+			return func(ctx context.Context, call *kazooapi.Call) error {
+				order = append(order, "cluster:before")
+				call.Request.Header.Set("X-Kazoo-Cluster-ID", "staging")
+				err := next(ctx, call)
+				order = append(order, "cluster:after")
+				return err
+			}
+		},
+	)
+
+	usr, err := clt.UsersAPI.CreateUser(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.User{FirstName: "John", LastName: "Doe"})
+	assert.NoError(t, err)
+	assert.Equal(t, "4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4", usr.ID, "response body should be still readable")
+
+	assert.Equal(t, []string{"audit:before", "cluster:before", "cluster:after", "audit:after"}, order)
+
+	if assert.NotNil(t, seen) {
+		assert.Equal(t, "UsersAPI", seen.Service)
+		assert.Equal(t, "CreateUser", seen.Operation)
+		assert.Equal(t, "qe0ade400015367f0069d6dfbdca072a", seen.AccountID)
+		assert.Equal(t, "John", seen.RequestEnvelope.Data.(map[string]interface{})["first_name"])
+		assert.Equal(t, 201, seen.Response.StatusCode)
+		assert.Equal(t, "3ae535b86688c03169207e2185d74aab", seen.ResponseEnvelope.RequestID)
+		assert.Equal(t, "1-7a2d6d2b0b5e0e2a0d2b8f0e8f2c1a3b", seen.ResponseEnvelope.Revision)
+		assert.JSONEq(t, `{"id":"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4","first_name":"John","last_name":"Doe"}`, string(seen.ResponseData))
+	}
+}
+
+func TestAPIClient_MiddlewareShortCircuit(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	errReadOnly := errors.New("read-only mode")
+	clt.Use(func(next kazooapi.Handler) kazooapi.Handler {
+		return func(ctx context.Context, call *kazooapi.Call) error {
+			if call.Request.Method != "GET" {
+				return errReadOnly
+			}
+			return next(ctx, call)
+		}
+	})
+
+	err = clt.AccountsAPI.DeleteAccount(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.Equal(t, errReadOnly, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+}
+
+func TestAPIClient_MiddlewareStreamsMedia(t *testing.T) {
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	streamed := make(chan bool, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/recordings/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "audio/mpeg")
+		io.WriteString(w, "ID3")
+		w.(http.Flusher).Flush()
+
+		//The rest is sent only after the client got the response
+		select {
+		case <-release:
+			streamed <- true
+		case <-time.After(2 * time.Second):
+			streamed <- false
+		}
+		io.WriteString(w, "frames")
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	clt := newMockClient(t, srv)
+
+	var media []byte
+	clt.Use(func(next kazooapi.Handler) kazooapi.Handler {
+		return func(ctx context.Context, call *kazooapi.Call) error {
+			if err := next(ctx, call); err != nil {
+				return err
+			}
+			close(release)
+
+			assert.Nil(t, call.ResponseEnvelope)
+			media, _ = ioutil.ReadAll(call.Response.Body)
+			return nil
+		}
+	})
+
+	_, err := clt.RecordingsAPI.GetRecording(ctx, "qe0ade400015367f0069d6dfbdca072a", "2c5a3e8b1d6f4c9a8e7b0d2f4a6c8e0b")
+	assert.Error(t, err)
+	assert.Equal(t, "ID3frames", string(media))
+	assert.True(t, <-streamed, "media shouldn't be buffered before the middleware gets it")
+}
@@ -0,0 +1,212 @@
+package number
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//Classes of numbers of Kazoo's default classifiers
+const (
+	ClassTollfreeUS    = "tollfree_us"
+	ClassTollUS        = "toll_us"
+	ClassEmergency     = "emergency"
+	ClassCaribbean     = "caribbean"
+	ClassDIDUS         = "did_us"
+	ClassInternational = "international"
+	ClassUnknown       = "unknown"
+)
+
+//Classifier is a class of numbers, e.g. toll-free ones
+type Classifier struct {
+	Name string
+	//Regex is matched against the number as it's given and converted to E.164
+	Regex        *regexp.Regexp
+	FriendlyName string
+	//PrettyPrint is the format numbers of the class are shown in, see PrettyPrint
+	PrettyPrint string
+	Emergency   bool
+}
+
+//classifierDoc is a classifier the way it's stored in Kazoo config
+type classifierDoc struct {
+	Regex        string `json:"regex"`
+	FriendlyName string `json:"friendly_name,omitempty"`
+	PrettyPrint  string `json:"pretty_print,omitempty"`
+	Emergency    bool   `json:"emergency,omitempty"`
+}
+
+//Classifiers are classes of numbers tried in order, the first one matching a number is its class
+type Classifiers []Classifier
+
+//DefaultClassifiers returns the classifiers Kazoo uses unless they are configured
+func DefaultClassifiers() Classifiers {
+	return Classifiers{
+		{Name: ClassTollfreeUS, Regex: regexp.MustCompile(`^\+1((?:800|888|877|866|855|844|833)[0-9]{7})$`), FriendlyName: "US TollFree"},
+		{Name: ClassTollUS, Regex: regexp.MustCompile(`^\+1(900[0-9]{7})$`), FriendlyName: "US Toll"},
+		{Name: ClassEmergency, Regex: regexp.MustCompile(`^(911)$`), FriendlyName: "Emergency Dispatcher", Emergency: true},
+		{Name: ClassCaribbean, Regex: regexp.MustCompile(`^\+?1((?:684|264|268|242|246|441|284|345|767|809|829|849|473|671|876|664|670|787|939|869|758|784|721|868|649|340)[0-9]{7})$`), FriendlyName: "Caribbean"},
+		{Name: ClassDIDUS, Regex: regexp.MustCompile(`^\+?1?([2-9][0-9]{2}[2-9][0-9]{6})$`), FriendlyName: "US DID", PrettyPrint: "SS(###) ### - ####"},
+		{Name: ClassInternational, Regex: regexp.MustCompile(`^(011[0-9]*)$|^(00[0-9]*)$|^\+([2-9][0-9]{7,})$`), FriendlyName: "International", PrettyPrint: "S011*"},
+		{Name: ClassUnknown, Regex: regexp.MustCompile(`^(.*)$`), FriendlyName: "Unknown"},
+	}
+}
+
+//Lookup returns the first classifier matching the number as it's given or converted to E.164,
+//so e.g. 011 prefixed numbers are international ones and 10-digit numbers are US DIDs
+func (cs Classifiers) Lookup(num string) (Classifier, bool) {
+	e164 := ToE164(num)
+	for _, c := range cs {
+		if c.Regex != nil && (c.Regex.MatchString(num) || c.Regex.MatchString(e164)) {
+			return c, true
+		}
+	}
+	return Classifier{}, false
+}
+
+//Classify returns the name of the number's class, ClassUnknown if no classifier matches it
+func (cs Classifiers) Classify(num string) string {
+	if c, ok := cs.Lookup(num); ok {
+		return c.Name
+	}
+	return ClassUnknown
+}
+
+//ParseClassifiers reads classifiers from the "classifiers" object of number_manager config, e.g.
+//{"tollfree_us": {"regex": "^\\+1(800\\d{7})$", "friendly_name": "US TollFree"}}.
+//The order of keys is kept, since the first matching classifier wins
+func ParseClassifiers(data []byte) (Classifiers, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("classifiers must be an object")
+	}
+
+	var cs Classifiers
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("can't read classifiers: %v", err)
+		}
+		name := tok.(string)
+
+		var doc classifierDoc
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("can't read classifier %s: %v", name, err)
+		}
+
+		//Kazoo regexes are PCRE, the ones using features RE2 lacks (e.g. lookarounds) are rejected
+		re, err := regexp.Compile(doc.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("classifier %s has invalid regex: %v", name, err)
+		}
+
+		cs = append(cs, Classifier{
+			Name:         name,
+			Regex:        re,
+			FriendlyName: doc.FriendlyName,
+			PrettyPrint:  doc.PrettyPrint,
+			Emergency:    doc.Emergency,
+		})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("can't read classifiers: %v", err)
+	}
+
+	return cs, nil
+}
+
+//UnmarshalJSON implements json.Unmarshaler with ParseClassifiers
+func (cs *Classifiers) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseClassifiers(data)
+	if err != nil {
+		return err
+	}
+	*cs = parsed
+	return nil
+}
+
+//MarshalJSON encodes classifiers the way Kazoo config keeps them, in order
+func (cs Classifiers) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, c := range cs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		name, err := json.Marshal(c.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		doc := classifierDoc{FriendlyName: c.FriendlyName, PrettyPrint: c.PrettyPrint, Emergency: c.Emergency}
+		if c.Regex != nil {
+			doc.Regex = c.Regex.String()
+		}
+		value, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
+
+//PrettyPrint formats the number the way Kazoo's pretty_print does: S skips a character of the number,
+//# copies one, * copies the rest, \ makes the next character of the format literal,
+//other characters of the format are copied as is
+func PrettyPrint(format, num string) string {
+	var b strings.Builder
+	digits := []rune(num)
+	pos := 0
+
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case 'S':
+			pos++
+		case '#':
+			if pos < len(digits) {
+				b.WriteRune(digits[pos])
+			}
+			pos++
+		case '*':
+			if pos < len(digits) {
+				b.WriteString(string(digits[pos:]))
+			}
+			pos = len(digits)
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteRune(runes[i])
+			}
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+
+	return b.String()
+}
+
+//Format returns the number in the classifier's PrettyPrint format. The format is applied to the E.164 form
+//of the number if the classifier matches it and to the number as it's given otherwise.
+//Numbers of classifiers without the format are returned unchanged
+func (c Classifier) Format(num string) string {
+	if c.PrettyPrint == "" {
+		return num
+	}
+
+	if e164 := ToE164(num); c.Regex == nil || c.Regex.MatchString(e164) {
+		num = e164
+	}
+	return PrettyPrint(c.PrettyPrint, num)
+}
@@ -0,0 +1,107 @@
+//Package number converts phone numbers between the formats Kazoo uses
+//and classifies them the way Kazoo does, e.g.
+//
+//	number.ToE164("4155550100")                       // +14155550100
+//	number.Convert("+14155550100", number.FormatNPAN) // 4155550100
+//	number.DefaultClassifiers().Classify("8005550100") // tollfree_us
+//
+//Conversions follow the default rules of Kazoo's number manager: numbers
+//the rules don't match are returned unchanged
+package number
+
+import (
+	"regexp"
+	"strings"
+)
+
+//Formats of numbers, they are the values of invite_format of devices
+const (
+	FormatE164  = "e164"
+	FormatNPAN  = "npan"
+	Format1NPAN = "1npan"
+)
+
+var (
+	//npanPattern matches a North American number with optional +1 or 1, the capture is the NPAN
+	npanPattern = regexp.MustCompile(`^\+?1?([2-9][0-9]{2}[2-9][0-9]{6})$`)
+	//internationalPattern matches numbers dialed with an international prefix, the capture is the number
+	internationalPattern = regexp.MustCompile(`^(?:011|00)([0-9]+)$`)
+	//reconcilePattern is the default reconcile_regex of Kazoo, numbers it doesn't match can't be added to accounts
+	reconcilePattern = regexp.MustCompile(`^\+?1?[0-9]{10}$|^\+[2-9][0-9]{7,}$|^011[0-9]*$|^00[0-9]*$`)
+)
+
+//ToE164 converts the number to E.164: North American numbers (NPAN or 1NPAN) get +1,
+//the 011 and 00 international prefixes are replaced with +
+func ToE164(num string) string {
+	if strings.HasPrefix(num, "+") {
+		return num
+	}
+
+	if m := npanPattern.FindStringSubmatch(num); m != nil {
+		return "+1" + m[1]
+	}
+
+	if m := internationalPattern.FindStringSubmatch(num); m != nil {
+		return "+" + m[1]
+	}
+
+	return num
+}
+
+//ToNPAN converts a North American number to its 10 digits, e.g. +14155550100 to 4155550100
+func ToNPAN(num string) string {
+	if m := npanPattern.FindStringSubmatch(num); m != nil {
+		return m[1]
+	}
+	return num
+}
+
+//To1NPAN converts a North American number to 11 digits starting with 1, e.g. +14155550100 to 14155550100
+func To1NPAN(num string) string {
+	if m := npanPattern.FindStringSubmatch(num); m != nil {
+		return "1" + m[1]
+	}
+	return num
+}
+
+//Convert converts the number to the format, numbers are returned unchanged for unknown formats
+func Convert(num, format string) string {
+	switch format {
+	case FormatE164:
+		return ToE164(num)
+	case FormatNPAN:
+		return ToNPAN(num)
+	case Format1NPAN:
+		return To1NPAN(num)
+	}
+	return num
+}
+
+//Strip removes formatting people put into numbers: spaces, dashes, dots and parentheses
+func Strip(num string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, num)
+}
+
+//IsReconcilable reports whether Kazoo accepts the number into accounts with its default reconcile_regex
+func IsReconcilable(num string) bool {
+	return reconcilePattern.MatchString(num)
+}
+
+//IsE164 reports whether the number is a valid E.164 number: + and 8 to 15 digits, the first one isn't 0
+func IsE164(num string) bool {
+	if len(num) < 9 || len(num) > 16 || num[0] != '+' || num[1] == '0' {
+		return false
+	}
+	for _, r := range num[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
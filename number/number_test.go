@@ -0,0 +1,128 @@
+package number_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sashker/kazoo-go/number"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	for _, tc := range []struct {
+		num, e164, npan, onenpan string
+	}{
+		{"4155550100", "+14155550100", "4155550100", "14155550100"},
+		{"14155550100", "+14155550100", "4155550100", "14155550100"},
+		{"+14155550100", "+14155550100", "4155550100", "14155550100"},
+		{"011442079460000", "+442079460000", "011442079460000", "011442079460000"},
+		{"00442079460000", "+442079460000", "00442079460000", "00442079460000"},
+		{"+442079460000", "+442079460000", "+442079460000", "+442079460000"},
+		{"911", "911", "911", "911"},
+	} {
+		assert.Equal(t, tc.e164, number.ToE164(tc.num), tc.num)
+		assert.Equal(t, tc.npan, number.ToNPAN(tc.num), tc.num)
+		assert.Equal(t, tc.onenpan, number.To1NPAN(tc.num), tc.num)
+
+		assert.Equal(t, tc.e164, number.Convert(tc.num, number.FormatE164), tc.num)
+		assert.Equal(t, tc.npan, number.Convert(tc.num, number.FormatNPAN), tc.num)
+		assert.Equal(t, tc.onenpan, number.Convert(tc.num, number.Format1NPAN), tc.num)
+	}
+
+	assert.Equal(t, "4155550100", number.Convert("4155550100", "username"))
+	assert.Equal(t, "+14155550100", number.ToE164(number.Strip("+1 (415) 555-0100")))
+}
+
+func TestValidate(t *testing.T) {
+	assert.True(t, number.IsE164("+14155550100"))
+	assert.True(t, number.IsE164("+442079460000"))
+	assert.False(t, number.IsE164("4155550100"))
+	assert.False(t, number.IsE164("+0155550100"))
+	assert.False(t, number.IsE164("+1415"))
+	assert.False(t, number.IsE164("+1415555010012345"))
+	assert.False(t, number.IsE164("+1415555O100"))
+
+	assert.True(t, number.IsReconcilable("4155550100"))
+	assert.True(t, number.IsReconcilable("+14155550100"))
+	assert.True(t, number.IsReconcilable("+442079460000"))
+	assert.True(t, number.IsReconcilable("011442079460000"))
+	assert.False(t, number.IsReconcilable("5550100"))
+	assert.False(t, number.IsReconcilable("911"))
+}
+
+func TestClassifiers_Classify(t *testing.T) {
+	classifiers := number.DefaultClassifiers()
+
+	for num, class := range map[string]string{
+		"8005550100":      number.ClassTollfreeUS,
+		"+18885550100":    number.ClassTollfreeUS,
+		"+19005550100":    number.ClassTollUS,
+		"911":             number.ClassEmergency,
+		"8765550100":      number.ClassCaribbean,
+		"4155550100":      number.ClassDIDUS,
+		"+14155550100":    number.ClassDIDUS,
+		"011442079460000": number.ClassInternational,
+		"00442079460000":  number.ClassInternational,
+		"+442071234567":   number.ClassInternational,
+		"+4420":           number.ClassUnknown,
+		"5550100":         number.ClassUnknown,
+	} {
+		assert.Equal(t, class, classifiers.Classify(num), num)
+	}
+
+	c, ok := classifiers.Lookup("911")
+	assert.True(t, ok)
+	assert.True(t, c.Emergency)
+
+	assert.Equal(t, number.ClassUnknown, number.Classifiers{}.Classify("4155550100"))
+}
+
+func TestParseClassifiers(t *testing.T) {
+	data := []byte(`{
+		"zeta":{"regex":"^\\+1(800[0-9]{7})$","friendly_name":"Zeta"},
+		"alpha":{"regex":"^(.*)$"}
+	}`)
+
+	classifiers, err := number.ParseClassifiers(data)
+	assert.NoError(t, err)
+	if assert.Len(t, classifiers, 2) {
+		//Order of the config is kept, so zeta wins over the catch-all
+		assert.Equal(t, "zeta", classifiers[0].Name)
+		assert.Equal(t, "Zeta", classifiers[0].FriendlyName)
+		assert.Equal(t, "zeta", classifiers.Classify("8005550100"))
+		assert.Equal(t, "alpha", classifiers.Classify("911"))
+	}
+
+	encoded, err := json.Marshal(classifiers)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"zeta":{"regex":"^\\+1(800[0-9]{7})$","friendly_name":"Zeta"},"alpha":{"regex":"^(.*)$"}}`, string(encoded))
+
+	var decoded number.Classifiers
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, classifiers, decoded)
+
+	_, err = number.ParseClassifiers([]byte(`{"bad":{"regex":"^(?!911)"}}`))
+	assert.Error(t, err)
+
+	_, err = number.ParseClassifiers([]byte(`[]`))
+	assert.EqualError(t, err, "classifiers must be an object")
+}
+
+func TestPrettyPrint(t *testing.T) {
+	classifiers := number.DefaultClassifiers()
+
+	did, _ := classifiers.Lookup("4155550100")
+	assert.Equal(t, "(415) 555 - 0100", did.Format("4155550100"))
+	assert.Equal(t, "(415) 555 - 0100", did.Format("+14155550100"))
+
+	intl, _ := classifiers.Lookup("011442079460000")
+	assert.Equal(t, "011442079460000", intl.Format("011442079460000"))
+	assert.Equal(t, "011442079460000", intl.Format("00442079460000"))
+	assert.Equal(t, "011442071234567", intl.Format("+442071234567"))
+
+	tollfree, _ := classifiers.Lookup("8005550100")
+	assert.Equal(t, "8005550100", tollfree.Format("8005550100"))
+
+	assert.Equal(t, "#415-555", number.PrettyPrint(`SS\####-###`, "+14155550100"))
+	assert.Equal(t, "+1", number.PrettyPrint("##*", "+1"))
+}
@@ -0,0 +1,410 @@
+package kazooapi
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sashker/kazoo-go/number"
+)
+
+//DefaultImportChunkSize is the number of numbers added to an account by a single request
+const DefaultImportChunkSize = 100
+
+//NumberImportStatus is the outcome of a row of a number import
+type NumberImportStatus string
+
+const (
+	//ImportCreated numbers were added to the account
+	ImportCreated NumberImportStatus = "created"
+	//ImportFeaturesFailed numbers were added, but their features weren't saved
+	ImportFeaturesFailed NumberImportStatus = "created_without_features"
+	//ImportExists numbers were in Kazoo already
+	ImportExists NumberImportStatus = "exists"
+	ImportFailed NumberImportStatus = "failed"
+	//ImportInvalid rows have a number which can't be normalized, no account or invalid features
+	ImportInvalid NumberImportStatus = "invalid"
+	//ImportDuplicate rows repeat a number of an earlier row, for the same account or another one
+	ImportDuplicate NumberImportStatus = "duplicate"
+	//ImportReady numbers would be created by the import, it's the status of dry runs
+	ImportReady NumberImportStatus = "ready"
+)
+
+//NumberImportRow is a row of an import file. Columns are matched by the header case-insensitively:
+//number (required), account, cnam_display_name, cnam_inbound_lookup, prepend_name, prepend_number,
+//failover_e164, failover_sip, force_outbound and e911_ prefixed fields of NumberE911
+//(e911_street_address, e911_extended_address, e911_locality, e911_region,
+//e911_postal_code, e911_plus_four, e911_caller_name). Other columns are ignored
+type NumberImportRow struct {
+	Line    int    //line of the file, the header is line 1
+	Number  string //number as it's written in the file
+	E164    string //normalized number, empty if Number isn't valid
+	Account string
+	//Features are set once any feature column of the row has a value
+	Features *NumberFeatures
+	//Err is why the row can't be imported, e.g. an invalid number
+	Err error
+}
+
+//NumberImportResult is the outcome of a row
+type NumberImportResult struct {
+	Row    NumberImportRow
+	Status NumberImportStatus
+	//Err is the reason of failed, invalid and duplicate rows (e.g. *APIError or an error matching ErrNumberExists)
+	//and of features which weren't saved
+	Err error
+}
+
+//NumberImportOptions controls ImportNumbers
+type NumberImportOptions struct {
+	//Account is used for rows with empty account column
+	Account string
+	//DryRun only checks rows: numbers are normalized, deduplicated and looked up one by one,
+	//so numbers which exist in any account are reported, nothing is changed
+	DryRun bool
+	//ChunkSize is the number of numbers added by a single request, DefaultImportChunkSize if it's zero
+	ChunkSize int
+}
+
+//importFields map columns to fields of rows and their features
+var importFields = map[string]func(row *NumberImportRow, f *NumberFeatures) *string{
+	"number":                func(row *NumberImportRow, f *NumberFeatures) *string { return &row.Number },
+	"account":               func(row *NumberImportRow, f *NumberFeatures) *string { return &row.Account },
+	"cnam_display_name":     func(row *NumberImportRow, f *NumberFeatures) *string { return &cnamOf(f).DisplayName },
+	"prepend_name":          func(row *NumberImportRow, f *NumberFeatures) *string { return &prependOf(f).Name },
+	"prepend_number":        func(row *NumberImportRow, f *NumberFeatures) *string { return &prependOf(f).Number },
+	"failover_e164":         func(row *NumberImportRow, f *NumberFeatures) *string { return &failoverOf(f).E164 },
+	"failover_sip":          func(row *NumberImportRow, f *NumberFeatures) *string { return &failoverOf(f).SIP },
+	"e911_street_address":   func(row *NumberImportRow, f *NumberFeatures) *string { return &e911Of(f).StreetAddress },
+	"e911_extended_address": func(row *NumberImportRow, f *NumberFeatures) *string { return &e911Of(f).ExtendedAddress },
+	"e911_locality":         func(row *NumberImportRow, f *NumberFeatures) *string { return &e911Of(f).Locality },
+	"e911_region":           func(row *NumberImportRow, f *NumberFeatures) *string { return &e911Of(f).Region },
+	"e911_postal_code":      func(row *NumberImportRow, f *NumberFeatures) *string { return &e911Of(f).PostalCode },
+	"e911_plus_four":        func(row *NumberImportRow, f *NumberFeatures) *string { return &e911Of(f).PlusFour },
+	"e911_caller_name":      func(row *NumberImportRow, f *NumberFeatures) *string { return &e911Of(f).CallerName },
+}
+
+//importFlags map boolean columns to fields of features
+var importFlags = map[string]func(f *NumberFeatures) *bool{
+	"cnam_inbound_lookup": func(f *NumberFeatures) *bool { return &cnamOf(f).InboundLookup },
+	"force_outbound": func(f *NumberFeatures) *bool {
+		if f.ForceOutbound == nil {
+			f.ForceOutbound = new(bool)
+		}
+		return f.ForceOutbound
+	},
+}
+
+func cnamOf(f *NumberFeatures) *NumberCNAM {
+	if f.CNAM == nil {
+		f.CNAM = &NumberCNAM{}
+	}
+	return f.CNAM
+}
+
+func prependOf(f *NumberFeatures) *NumberPrepend {
+	if f.Prepend == nil {
+		f.Prepend = &NumberPrepend{Enabled: true}
+	}
+	return f.Prepend
+}
+
+func failoverOf(f *NumberFeatures) *NumberFailover {
+	if f.Failover == nil {
+		f.Failover = &NumberFailover{}
+	}
+	return f.Failover
+}
+
+func e911Of(f *NumberFeatures) *NumberE911 {
+	if f.E911 == nil {
+		f.E911 = &NumberE911{}
+	}
+	return f.E911
+}
+
+//ReadNumberImport reads rows of a CSV file with a header, see NumberImportRow for columns.
+//Numbers are normalized to E.164, rows which can't be imported have Err set.
+//An error is returned only if the file can't be read
+func ReadNumberImport(r io.Reader) ([]NumberImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, reportError("import file is empty")
+	}
+	if err != nil {
+		return nil, reportError("can't read import file: %v", err)
+	}
+
+	hasNumber := false
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+		if header[i] == "account_id" {
+			header[i] = "account"
+		}
+		hasNumber = hasNumber || header[i] == "number"
+	}
+	if !hasNumber {
+		return nil, reportError("import file has no number column")
+	}
+
+	var rows []NumberImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, reportError("can't read import file: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := NumberImportRow{Line: line}
+		features := &NumberFeatures{}
+		hasFeatures := false
+
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if i >= len(header) || value == "" {
+				continue
+			}
+			column := header[i]
+
+			if field, ok := importFields[column]; ok {
+				*field(&row, features) = value
+				hasFeatures = hasFeatures || (column != "number" && column != "account")
+			}
+
+			if flag, ok := importFlags[column]; ok {
+				b, err := strconv.ParseBool(value)
+				if err != nil && row.Err == nil {
+					row.Err = reportError("%s: %q isn't a boolean", column, value)
+				}
+				*flag(features) = b
+				hasFeatures = true
+			}
+		}
+		if hasFeatures {
+			row.Features = features
+		}
+
+		if row.Number == "" && row.Account == "" && !hasFeatures {
+			//Blank lines of spreadsheets
+			continue
+		}
+
+		//Numbers are written the common ways, e.g. (415) 555-0100 or 00 44 20 7946 0000
+		if e164 := number.ToE164(number.Strip(row.Number)); number.IsE164(e164) {
+			row.E164 = e164
+		} else if row.Err == nil {
+			row.Err = reportError("%q can't be converted to E.164", row.Number)
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+//ImportNumbers adds numbers of rows to their accounts and saves their features.
+//Rows are checked first: invalid rows, duplicates and (in dry runs) numbers which exist
+//already are reported without adding anything. Numbers are added in chunks with the numbers
+//collection, numbers Kazoo refused don't fail the rest of the chunk.
+//Results follow the order of rows, an error is returned only if ctx is done
+func (api *PhoneNumbersAPIService) ImportNumbers(ctx context.Context, rows []NumberImportRow, opts *NumberImportOptions) ([]NumberImportResult, error) {
+	if opts == nil {
+		opts = &NumberImportOptions{}
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultImportChunkSize
+	}
+
+	results := make([]NumberImportResult, len(rows))
+	//seen are indexes of rows by number, a number belongs to a single account in Kazoo
+	seen := make(map[string]int)
+
+	//pending are indexes of rows to import grouped by account in the order accounts appear
+	pending := make(map[string][]int)
+	var accounts []string
+
+	for i, row := range rows {
+		if row.Account == "" {
+			row.Account = opts.Account
+		}
+		results[i] = NumberImportResult{Row: row, Status: ImportInvalid, Err: row.Err}
+
+		switch {
+		case row.Err != nil:
+			continue
+		case row.Account == "":
+			results[i].Err = reportError("account is required field")
+			continue
+		case !accountIDPattern.MatchString(row.Account):
+			results[i].Err = ErrInvalidAccountID
+			continue
+		}
+		if row.Features != nil {
+			if err := row.Features.Validate(); err != nil {
+				results[i].Err = err
+				continue
+			}
+		}
+
+		if first, ok := seen[row.E164]; ok {
+			results[i].Status = ImportDuplicate
+			if other := results[first].Row.Account; other != row.Account {
+				results[i].Err = reportError("%s is listed for account %s on line %d already", row.E164, other, rows[first].Line)
+				continue
+			}
+			results[i].Err = reportError("%s is listed on line %d already", row.E164, rows[first].Line)
+			continue
+		}
+		seen[row.E164] = i
+
+		results[i].Status = ImportReady
+		if _, ok := pending[row.Account]; !ok {
+			accounts = append(accounts, row.Account)
+		}
+		pending[row.Account] = append(pending[row.Account], i)
+	}
+
+	for _, acc := range accounts {
+		if opts.DryRun {
+			if err := api.checkImport(ctx, acc, pending[acc], results); err != nil {
+				return results, err
+			}
+			continue
+		}
+
+		indexes := pending[acc]
+		for len(indexes) > 0 {
+			n := chunkSize
+			if n > len(indexes) {
+				n = len(indexes)
+			}
+			if err := api.importChunk(ctx, acc, indexes[:n], results); err != nil {
+				return results, err
+			}
+			indexes = indexes[n:]
+		}
+	}
+
+	return results, nil
+}
+
+//checkImport looks the numbers up and marks existing ones, both in the account and in other accounts,
+//the way adding them would: Kazoo refuses to show numbers of other accounts with 403.
+//Existing numbers get *APIError matching ErrNumberExists either way
+func (api *PhoneNumbersAPIService) checkImport(ctx context.Context, acc string, indexes []int, results []NumberImportResult) error {
+	for _, i := range indexes {
+		row := &results[i]
+
+		var meta ResponseMeta
+		_, err := api.GetPhoneNumber(WithResponseMeta(ctx, &meta), acc, row.Row.E164)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			//The error Kazoo responds with when the number is added again
+			row.Status = ImportExists
+			row.Err = (&APIError{
+				StatusCode:   409,
+				ErrorCode:    "409",
+				ErrorMessage: "number_exists",
+				RequestID:    meta.RequestID,
+			}).withCause(ErrNumberExists)
+		case errors.Is(err, ErrNotFound):
+			//The number is free, the row stays ready
+		case errors.As(err, &apiErr) && apiErr.StatusCode == 403:
+			row.Status = ImportExists
+			row.Err = apiErr.withCause(ErrNumberExists)
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			row.Status = ImportFailed
+			row.Err = err
+		}
+	}
+
+	return nil
+}
+
+//importChunk adds numbers of a chunk to the account and saves their features
+func (api *PhoneNumbersAPIService) importChunk(ctx context.Context, acc string, indexes []int, results []NumberImportResult) error {
+	nums := make([]string, len(indexes))
+	for j, i := range indexes {
+		nums[j] = results[i].Row.E164
+	}
+
+	failed := make(map[string]error)
+	if len(nums) == 1 {
+		//A single number doesn't need the collection
+		if _, err := api.CreatePhoneNumber(ctx, acc, nums[0]); err != nil {
+			failed[nums[0]] = err
+		}
+	} else {
+		collection, err := api.AddPhoneNumbers(ctx, acc, nums)
+		if err != nil {
+			for _, num := range nums {
+				failed[num] = err
+			}
+		} else {
+			for num := range collection.Error {
+				failed[num] = collection.Err(num)
+			}
+		}
+	}
+
+	for _, i := range indexes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		row := &results[i]
+		if err, ok := failed[row.Row.E164]; ok {
+			row.Status = ImportFailed
+			row.Err = err
+			if errors.Is(err, ErrNumberExists) {
+				row.Status = ImportExists
+			}
+			continue
+		}
+
+		row.Status = ImportCreated
+		if row.Row.Features != nil {
+			if _, err := api.UpdatePhoneNumberFeatures(ctx, acc, row.Row.E164, row.Row.Features); err != nil {
+				row.Status = ImportFeaturesFailed
+				row.Err = err
+			}
+		}
+	}
+
+	return ctx.Err()
+}
+
+//WriteNumberImportResults writes results as CSV with line, number, e164, account, status and error columns,
+//so they can be matched with rows of the import file
+func WriteNumberImportResults(w io.Writer, results []NumberImportResult) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"line", "number", "e164", "account", "status", "error"})
+
+	for _, r := range results {
+		//Errors of Kazoo are written as their short message, e.g. number_exists
+		message := ""
+		var kazooErr Error
+		switch {
+		case errors.As(r.Err, &kazooErr):
+			message = kazooErr.Message()
+		case r.Err != nil:
+			message = r.Err.Error()
+		}
+		writer.Write([]string{strconv.Itoa(r.Row.Line), r.Row.Number, r.Row.E164, r.Row.Account, string(r.Status), message})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
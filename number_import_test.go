@@ -0,0 +1,252 @@
+package kazooapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const importFile = `Number,Account,CNAM_Display_Name,E911_Street_Address,E911_Locality,E911_Region,E911_Postal_Code,Notes
+(415) 555-0100,,ACME,140 Main St,San Francisco,CA,94105,HQ
+1-415-555-0101,,,,,,,
+
++1 415 555 0100,,,,,,,listed twice
+00 44 20 7946 0000,,,,,,,London
+555-0102,,,,,,,no area code
+4155550103,,,,,,,
+4155550104,,,140 Main St,San Francisco,California,94105,bad region
+`
+
+//MockNumbersServer stands in for numbers of a single account: exists are numbers
+//the account has, elsewhere are numbers of other accounts, which are hidden with 403.
+//collections records the numbers of every collection request
+func MockNumbersServer(t *testing.T, exists, elsewhere []string) (*httptest.Server, func() [][]string) {
+	var (
+		mu          sync.Mutex
+		collections [][]string
+		created     = make(map[string]bool)
+	)
+
+	has := make(map[string]bool)
+	for _, num := range append(exists, elsewhere...) {
+		has[num] = true
+	}
+	hidden := make(map[string]bool)
+	for _, num := range elsewhere {
+		hidden[num] = true
+	}
+
+	const numbers = "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc(numbers+"/collection", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Data struct {
+				Numbers []string `json:"numbers"`
+			} `json:"data"`
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &req))
+
+		mu.Lock()
+		defer mu.Unlock()
+		collections = append(collections, req.Data.Numbers)
+
+		success := make(map[string]interface{})
+		failed := make(map[string]interface{})
+		for _, num := range req.Data.Numbers {
+			if has[num] {
+				failed[num] = map[string]interface{}{"code": 409, "error": "number_exists", "message": "number_exists", "cause": num}
+				continue
+			}
+			created[num] = true
+			success[num] = map[string]interface{}{"state": "reserved"}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":       map[string]interface{}{"success": success, "error": failed},
+			"request_id": "7c1f3e9a2b4d4f6e8a0c2e4f6a8b0c2d",
+			"status":     "success",
+		})
+	})
+	mux.HandleFunc(numbers+"/", func(w http.ResponseWriter, r *http.Request) {
+		num := strings.TrimPrefix(r.URL.Path, numbers+"/")
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == "PUT" && has[num]:
+			w.WriteHeader(409)
+			io.WriteString(w, `{"data":{"message":"number_exists","cause":"`+num+`"},"error":"409","message":"number_exists","status":"error"}`)
+			return
+		case r.Method == "PUT":
+			created[num] = true
+		case hidden[num]:
+			w.WriteHeader(403)
+			io.WriteString(w, `{"data":{"message":"unauthorized"},"error":"403","message":"forbidden","status":"error","request_id":"9b2e4d6f8a0c4e2a6c8e0a2c4e6f8a0b"}`)
+			return
+		case !has[num] && !created[num]:
+			w.WriteHeader(404)
+			io.WriteString(w, `{"data":{"message":"bad identifier","not_found":"The number could not be found"},"error":"404","message":"bad_identifier","status":"error"}`)
+			return
+		}
+		io.WriteString(w, `{"data":{"id":"`+num+`","state":"reserved"},"revision":"1-a","status":"success"}`)
+	})
+
+	return httptest.NewServer(mux), func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return collections
+	}
+}
+
+func TestReadNumberImport(t *testing.T) {
+	rows, err := kazooapi.ReadNumberImport(strings.NewReader(importFile))
+	assert.NoError(t, err)
+
+	if !assert.Len(t, rows, 7) {
+		return
+	}
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, "(415) 555-0100", rows[0].Number)
+	assert.Equal(t, "+14155550100", rows[0].E164)
+	if assert.NotNil(t, rows[0].Features) {
+		assert.Equal(t, "ACME", rows[0].Features.CNAM.DisplayName)
+		assert.Equal(t, "94105", rows[0].Features.E911.PostalCode)
+		assert.Nil(t, rows[0].Features.Prepend)
+	}
+
+	assert.Equal(t, "+14155550101", rows[1].E164)
+	assert.Nil(t, rows[1].Features)
+
+	//The blank line is skipped, but lines are counted
+	assert.Equal(t, 5, rows[2].Line)
+	assert.Equal(t, "+14155550100", rows[2].E164)
+	assert.Equal(t, "+442079460000", rows[3].E164)
+
+	assert.Error(t, rows[4].Err)
+	assert.Equal(t, "", rows[4].E164)
+
+	_, err = kazooapi.ReadNumberImport(strings.NewReader("did,account\n4155550100,\n"))
+	assert.EqualError(t, err, "import file has no number column")
+
+	rows, err = kazooapi.ReadNumberImport(strings.NewReader("number,force_outbound\n4155550100,maybe\n"))
+	assert.NoError(t, err)
+	assert.EqualError(t, rows[0].Err, `force_outbound: "maybe" isn't a boolean`)
+}
+
+func TestPhoneNumbersService_ImportNumbers(t *testing.T) {
+	ctx := context.Background()
+
+	//The London number belongs to another account, so the account can't even see it
+	srv, collections := MockNumbersServer(t, []string{"+14155550101"}, []string{"+442079460000"})
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	rows, err := kazooapi.ReadNumberImport(strings.NewReader(importFile))
+	assert.NoError(t, err)
+
+	statuses := func(results []kazooapi.NumberImportResult) (s []kazooapi.NumberImportStatus) {
+		for _, r := range results {
+			s = append(s, r.Status)
+		}
+		return s
+	}
+
+	results, err := acc.PhoneNumbers.Import(ctx, rows, &kazooapi.NumberImportOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Empty(t, collections())
+	assert.Equal(t, []kazooapi.NumberImportStatus{
+		kazooapi.ImportReady,
+		kazooapi.ImportExists,
+		kazooapi.ImportDuplicate,
+		kazooapi.ImportExists,
+		kazooapi.ImportInvalid,
+		kazooapi.ImportReady,
+		kazooapi.ImportInvalid,
+	}, statuses(results))
+	assert.EqualError(t, results[2].Err, "+14155550100 is listed on line 2 already")
+	assert.True(t, errors.Is(results[6].Err, kazooapi.ErrValidation))
+
+	//Numbers of the account and of other accounts are API errors alike
+	var apiErr *kazooapi.APIError
+	assert.True(t, errors.Is(results[1].Err, kazooapi.ErrNumberExists))
+	if assert.True(t, errors.As(results[1].Err, &apiErr)) {
+		assert.Equal(t, 409, apiErr.StatusCode)
+		assert.Equal(t, "number_exists", apiErr.Message())
+	}
+	assert.True(t, errors.Is(results[3].Err, kazooapi.ErrNumberExists))
+	if assert.True(t, errors.As(results[3].Err, &apiErr)) {
+		assert.Equal(t, 403, apiErr.StatusCode)
+	}
+
+	results, err = acc.PhoneNumbers.Import(ctx, rows, &kazooapi.NumberImportOptions{ChunkSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"+14155550100", "+14155550101"}, {"+442079460000", "+14155550103"}}, collections())
+	assert.Equal(t, []kazooapi.NumberImportStatus{
+		kazooapi.ImportCreated,
+		kazooapi.ImportExists,
+		kazooapi.ImportDuplicate,
+		kazooapi.ImportExists,
+		kazooapi.ImportInvalid,
+		kazooapi.ImportCreated,
+		kazooapi.ImportInvalid,
+	}, statuses(results))
+	assert.True(t, errors.Is(results[1].Err, kazooapi.ErrNumberExists))
+
+	//Failures of numbers in a collection are API errors of the bulk request
+	if assert.True(t, errors.As(results[1].Err, &apiErr)) {
+		assert.Equal(t, 409, apiErr.StatusCode)
+		assert.Equal(t, "number_exists", apiErr.Message())
+		assert.Equal(t, "7c1f3e9a2b4d4f6e8a0c2e4f6a8b0c2d", apiErr.RequestID)
+	}
+	assert.Equal(t, "4dee5c1bef3ace50911c9917c50c9f80", results[0].Row.Account)
+
+	//Chunks of a single number are created one by one
+	results, err = acc.PhoneNumbers.Import(ctx, rows[:2], &kazooapi.NumberImportOptions{ChunkSize: 1})
+	assert.NoError(t, err)
+	assert.Len(t, collections(), 2)
+	assert.Equal(t, []kazooapi.NumberImportStatus{kazooapi.ImportCreated, kazooapi.ImportExists}, statuses(results))
+	assert.True(t, errors.Is(results[1].Err, kazooapi.ErrNumberExists))
+
+	var out bytes.Buffer
+	assert.NoError(t, kazooapi.WriteNumberImportResults(&out, results))
+	assert.Equal(t, "line,number,e164,account,status,error\n"+
+		"2,(415) 555-0100,+14155550100,4dee5c1bef3ace50911c9917c50c9f80,created,\n"+
+		"3,1-415-555-0101,+14155550101,4dee5c1bef3ace50911c9917c50c9f80,exists,number_exists\n", out.String())
+
+	//A number belongs to a single account, listing it for another one is a conflict
+	conflict := append([]kazooapi.NumberImportRow{}, rows[:2]...)
+	conflict[1].E164 = conflict[0].E164
+	conflict[1].Account = "7a3e9c1d5b2f4e6a8c0d2e4f6a8b0c1d"
+	results, err = acc.PhoneNumbers.Import(ctx, conflict, &kazooapi.NumberImportOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []kazooapi.NumberImportStatus{kazooapi.ImportExists, kazooapi.ImportDuplicate}, statuses(results))
+	assert.EqualError(t, results[1].Err,
+		"+14155550100 is listed for account 4dee5c1bef3ace50911c9917c50c9f80 on line 2 already")
+
+	//Rows need an account
+	results, err = clt.PhoneNumbersAPI.ImportNumbers(ctx, rows[:1], nil)
+	assert.NoError(t, err)
+	assert.Equal(t, kazooapi.ImportInvalid, results[0].Status)
+	assert.EqualError(t, results[0].Err, "account is required field")
+}
@@ -0,0 +1,145 @@
+package kazooapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+//PageKey is a start key of a page returned by Kazoo in "start_key"
+//and "next_start_key" fields. Most of the endpoints use strings, though
+//some views are keyed by numbers or arrays, so we keep the raw JSON for them
+type PageKey string
+
+//UnmarshalJSON implements json.Unmarshaler interface
+func (k *PageKey) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*k = ""
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*k = PageKey(s)
+		return nil
+	}
+
+	*k = PageKey(b)
+	return nil
+}
+
+//Paginator is structure which contains data and pagination features.
+//It follows Kazoo's start_key/next_start_key chain and fetches
+//pages only when they're requested
+type Paginator[T any] struct {
+	PageSize int64
+	HasNext  bool
+	HasPrev  bool
+
+	startKey PageKey
+	fetch    pageFetcher[T]
+}
+
+//pageFetcher fetches a single page which starts from the given key
+type pageFetcher[T any] func(ctx context.Context, startKey PageKey, pageSize int64) (items []T, next PageKey, err error)
+
+func newPaginator[T any](pageSize int64, fetch pageFetcher[T]) *Paginator[T] {
+	return &Paginator[T]{
+		PageSize: pageSize,
+		HasNext:  true,
+		fetch:    fetch,
+	}
+}
+
+//Next fetches the next page. It returns nil slice without an error
+//when there are no more pages
+func (p *Paginator[T]) Next(ctx context.Context) ([]T, error) {
+	if !p.HasNext {
+		return nil, nil
+	}
+
+	items, next, err := p.fetch(ctx, p.startKey, p.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	p.HasPrev = p.startKey != ""
+	p.HasNext = next != "" && next != p.startKey
+	p.startKey = next
+
+	return items, nil
+}
+
+//All returns an iterator over all remaining items. Pages are fetched
+//lazily, so breaking out of the loop stops making requests.
+//The iteration stops after the first error
+func (p *Paginator[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for p.HasNext {
+			items, err := p.Next(ctx)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+//Collect fetches all remaining pages and returns their items in one slice
+func (p *Paginator[T]) Collect(ctx context.Context) (items []T, err error) {
+	for item, err := range p.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+//fetchPage requests a single page of a Kazoo collection and decodes its "data" into T
+func fetchPage[T any](ctx context.Context, c *APIClient, service, operation, path string, startKey PageKey, pageSize int64) (data T, next PageKey, err error) {
+	query := url.Values{}
+
+	if pageSize > 0 {
+		query.Set("page_size", strconv.FormatInt(pageSize, 10))
+	}
+
+	if startKey != "" {
+		query.Set("start_key", string(startKey))
+	}
+
+	data, meta, err := do[T](ctx, c, endpoint{
+		service:   service,
+		operation: operation,
+		method:    "GET",
+		path:      path,
+		query:     query,
+	})
+	if err != nil {
+		return data, "", err
+	}
+
+	return data, meta.NextStartKey, nil
+}
+
+//listPaginator builds a paginator over a Kazoo collection which returns a list in "data"
+func listPaginator[T any](c *APIClient, service, operation, path string, pageSize int64) *Paginator[T] {
+	return newPaginator(pageSize, func(ctx context.Context, startKey PageKey, pageSize int64) ([]T, PageKey, error) {
+		items, next, err := fetchPage[[]T](ctx, c, service, operation, path, startKey, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return items, next, nil
+	})
+}
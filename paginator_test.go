@@ -0,0 +1,138 @@
+package kazooapi_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+//MockPagedServer serves 5 callflows split into pages according to page_size
+func MockPagedServer(t *testing.T, pages *int32) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/callflows", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(pages, 1)
+
+		start := 0
+		fmt.Sscanf(r.URL.Query().Get("start_key"), "key%d", &start)
+		size := 2
+		fmt.Sscanf(r.URL.Query().Get("page_size"), "%d", &size)
+
+		data := ""
+		end := start
+		for ; end < 5 && end < start+size; end++ {
+			if data != "" {
+				data += ","
+			}
+			data += fmt.Sprintf(`{"id":"cf%d","numbers":["10%d"],"flow":{"module":"user"}}`, end, end)
+		}
+
+		next := ""
+		if end < 5 {
+			next = fmt.Sprintf(`"next_start_key":"key%d",`, end)
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, fmt.Sprintf(`{"page_size":%d,"start_key":"key%d",%s"data":[%s],"status":"success"}`, end-start, start, next, data))
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestPaginator_All(t *testing.T) {
+	ctx := context.Background()
+
+	var pages int32
+	srv := MockPagedServer(t, &pages)
+	defer srv.Close()
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	var ids []string
+	for cf, err := range clt.CallflowsAPI.ListCallflowsPaginator("qe0ade400015367f0069d6dfbdca072a", 2).All(ctx) {
+		assert.NoError(t, err)
+		ids = append(ids, cf.ID)
+	}
+
+	assert.Equal(t, []string{"cf0", "cf1", "cf2", "cf3", "cf4"}, ids)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&pages))
+}
+
+func TestPaginator_StopEarly(t *testing.T) {
+	ctx := context.Background()
+
+	var pages int32
+	srv := MockPagedServer(t, &pages)
+	defer srv.Close()
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	p := clt.CallflowsAPI.ListCallflowsPaginator("qe0ade400015367f0069d6dfbdca072a", 2)
+	for cf, err := range p.All(ctx) {
+		assert.NoError(t, err)
+		if cf.ID == "cf2" {
+			break
+		}
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&pages), "third page should never be requested")
+	assert.True(t, p.HasNext)
+	assert.True(t, p.HasPrev)
+
+	//Paging might be continued manually from where the loop stopped
+	page, err := p.Next(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "cf4", page[0].ID)
+	assert.False(t, p.HasNext)
+
+	page, err = p.Next(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, page)
+}
+
+func TestPaginator_Collect(t *testing.T) {
+	ctx := context.Background()
+
+	var pages int32
+	srv := MockPagedServer(t, &pages)
+	defer srv.Close()
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	cfs, err := clt.CallflowsAPI.ListCallflowsPaginator("qe0ade400015367f0069d6dfbdca072a", 10).Collect(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, cfs, 5)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&pages))
+}
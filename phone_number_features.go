@@ -0,0 +1,194 @@
+package kazooapi
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	usRegionPattern   = regexp.MustCompile(`^[A-Z]{2}$`)
+	zipPattern        = regexp.MustCompile(`^[0-9]{5}$`)
+	zipPlus4Pattern   = regexp.MustCompile(`^[0-9]{4}$`)
+	numberFeatureKeys = []string{"e911", "cnam", "prepend", "failover", "force_outbound"}
+)
+
+type (
+	//NumberFeatures are configurable features of a phone number document
+	NumberFeatures struct {
+		E911     *NumberE911     `json:"e911,omitempty"`
+		CNAM     *NumberCNAM     `json:"cnam,omitempty"`
+		Prepend  *NumberPrepend  `json:"prepend,omitempty"`
+		Failover *NumberFailover `json:"failover,omitempty"`
+		//ForceOutbound sends calls to the number through carriers even if it's served by the same cluster
+		ForceOutbound *bool `json:"force_outbound,omitempty"`
+	}
+
+	//NumberE911 is the address emergency services are sent to
+	NumberE911 struct {
+		CallerName                string   `json:"caller_name,omitempty"`
+		StreetAddress             string   `json:"street_address"`
+		ExtendedAddress           string   `json:"extended_address,omitempty"` //e.g. suite or floor
+		Locality                  string   `json:"locality"`                   //city
+		Region                    string   `json:"region"`                     //two-letter state code
+		PostalCode                string   `json:"postal_code"`
+		PlusFour                  string   `json:"plus_four,omitempty"`
+		NotificationContactEmails []string `json:"notification_contact_emails,omitempty"`
+	}
+
+	//NumberCNAM is the caller name shown on outbound calls and the lookup of callers' names on inbound ones
+	NumberCNAM struct {
+		DisplayName   string `json:"display_name,omitempty"`
+		InboundLookup bool   `json:"inbound_lookup,omitempty"`
+	}
+
+	//NumberPrepend adds a prefix to the caller id name of inbound calls, e.g. "Sales"
+	NumberPrepend struct {
+		Enabled bool   `json:"enabled"`
+		Name    string `json:"name,omitempty"`
+		Number  string `json:"number,omitempty"`
+	}
+
+	//NumberFailover is where calls go when no endpoint of the number's callflow answers
+	NumberFailover struct {
+		E164 string `json:"e164,omitempty"`
+		SIP  string `json:"sip,omitempty"`
+	}
+
+	//numberDocument is a phone number document kept raw, so fields unknown to NumberFeatures survive saving
+	numberDocument map[string]json.RawMessage
+)
+
+//Validate checks the E911 address before it's sent to Kazoo, the returned error matches ErrValidation
+func (e *NumberE911) Validate() error {
+	var issues []string
+
+	required := func(name, value string) bool {
+		if strings.TrimSpace(value) == "" {
+			issues = append(issues, name+" is required")
+			return false
+		}
+		return true
+	}
+
+	required("street_address", e.StreetAddress)
+	required("locality", e.Locality)
+	if required("region", e.Region) && !usRegionPattern.MatchString(e.Region) {
+		issues = append(issues, "region must be a two-letter state code, e.g. CA")
+	}
+	if required("postal_code", e.PostalCode) && !zipPattern.MatchString(e.PostalCode) {
+		issues = append(issues, "postal_code must be 5 digits")
+	}
+	if e.PlusFour != "" && !zipPlus4Pattern.MatchString(e.PlusFour) {
+		issues = append(issues, "plus_four must be 4 digits")
+	}
+	for _, email := range e.NotificationContactEmails {
+		if at := strings.Index(email, "@"); at <= 0 || at == len(email)-1 {
+			issues = append(issues, "notification contact "+email+" isn't an email")
+		}
+	}
+
+	if len(issues) > 0 {
+		return NewError(ErrValidation.Code(), "invalid e911 address: "+strings.Join(issues, ", "), ErrValidation)
+	}
+	return nil
+}
+
+//Validate checks features before they are sent to Kazoo, the returned error matches ErrValidation
+func (f *NumberFeatures) Validate() error {
+	if f.E911 != nil {
+		return f.E911.Validate()
+	}
+	return nil
+}
+
+//GetPhoneNumberFeatures returns features of the phone number
+func (api *PhoneNumbersAPIService) GetPhoneNumberFeatures(ctx context.Context, acc, num string) (features *NumberFeatures, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	if num == "" {
+		return nil, reportError("number is required field")
+	}
+
+	features, _, err = do[*NumberFeatures](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "GetPhoneNumberFeatures",
+		method:    "GET",
+		path:      path("accounts", acc, "phone_numbers", num),
+	})
+
+	return features, err
+}
+
+//UpdatePhoneNumberFeatures replaces features of the phone number with input, features left nil are removed.
+//Input is validated first, the rest of the number document is saved as is
+func (api *PhoneNumbersAPIService) UpdatePhoneNumberFeatures(ctx context.Context, acc, num string, input *NumberFeatures) (features *NumberFeatures, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	if num == "" {
+		return nil, reportError("number is required field")
+	}
+
+	if input == nil {
+		input = &NumberFeatures{}
+	}
+
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, reportError("can't encode number features: %v", err)
+	}
+	var changes numberDocument
+	if err := json.Unmarshal(raw, &changes); err != nil {
+		return nil, reportError("can't encode number features: %v", err)
+	}
+
+	err = RetryOnConflict(ctx, DefaultConflictAttempts, func(ctx context.Context) error {
+		var meta ResponseMeta
+
+		doc, _, err := do[numberDocument](WithResponseMeta(ctx, &meta), api.client, endpoint{
+			service:   "PhoneNumbersAPI",
+			operation: "UpdatePhoneNumberFeatures",
+			method:    "GET",
+			path:      path("accounts", acc, "phone_numbers", num),
+		})
+		if err != nil {
+			return err
+		}
+
+		//The document might come as "data": null
+		if doc == nil {
+			doc = make(numberDocument)
+		}
+
+		for _, key := range numberFeatureKeys {
+			delete(doc, key)
+			if value, ok := changes[key]; ok {
+				doc[key] = value
+			}
+		}
+
+		features, _, err = do[*NumberFeatures](WithRevision(ctx, meta.Revision), api.client, endpoint{
+			service:   "PhoneNumbersAPI",
+			operation: "UpdatePhoneNumberFeatures",
+			method:    "POST",
+			path:      path("accounts", acc, "phone_numbers", num),
+			body:      doc,
+		})
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return features, nil
+}
@@ -0,0 +1,59 @@
+package kazooapi
+
+import (
+	"fmt"
+)
+
+//NumberState is the lifecycle state of a phone number
+type NumberState string
+
+//States of phone numbers
+const (
+	//NumberDiscovery is a number found by a carrier search and not reserved yet
+	NumberDiscovery NumberState = "discovery"
+	NumberReserved  NumberState = "reserved"
+	NumberInService NumberState = "in_service"
+	//NumberPortIn is a number which is being ported from another carrier
+	NumberPortIn   NumberState = "port_in"
+	NumberReleased NumberState = "released"
+	NumberDeleted  NumberState = "deleted"
+	//NumberAging is a number released recently, it can't be reused for a while
+	NumberAging NumberState = "aging"
+)
+
+//numberTransitions lists states a number can move to from each state, the way Kazoo allows it
+var numberTransitions = map[NumberState][]NumberState{
+	NumberDiscovery: {NumberReserved, NumberInService, NumberDeleted},
+	NumberReserved:  {NumberReserved, NumberInService, NumberReleased, NumberDeleted},
+	NumberInService: {NumberInService, NumberReleased, NumberAging, NumberDeleted},
+	NumberPortIn:    {NumberPortIn, NumberInService, NumberReleased, NumberDeleted},
+	NumberReleased:  {NumberReleased, NumberReserved, NumberInService, NumberAging, NumberDeleted},
+	NumberAging:     {NumberAging, NumberReleased, NumberInService, NumberDeleted},
+	NumberDeleted:   {},
+}
+
+//Known reports whether the state is one of NumberState constants
+func (s NumberState) Known() bool {
+	_, ok := numberTransitions[s]
+	return ok
+}
+
+//CanTransitionTo reports whether a number in the state might be moved to the state to
+func (s NumberState) CanTransitionTo(to NumberState) bool {
+	for _, allowed := range numberTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+//ValidateNumberTransition returns an error matching ErrInvalidStateTransition
+//if a number can't move from one state to the other
+func ValidateNumberTransition(from, to NumberState) error {
+	if from.CanTransitionTo(to) {
+		return nil
+	}
+
+	return NewError(ErrPhoneNumbers, fmt.Sprintf("number can't move from %q to %q state", from, to), ErrInvalidStateTransition)
+}
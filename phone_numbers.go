@@ -3,7 +3,12 @@ package kazooapi
 import (
 	"context"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/sashker/kazoo-go/number"
 )
 
 type PhoneNumbersAPIService service
@@ -21,17 +26,17 @@ var (
 
 type (
 	PhoneNumber struct {
-		ID         string    `json:"id"`
-		State      string    `json:"state"`
-		Features   []string  `json:"features"`
-		AssignedTo string    `json:"assigned_to"`
-		Created    Timestamp `json:"created"`
-		Updated    Timestamp `json:"updated"`
-		readOnly   struct {
-			state    string   `json:"state,omitempty"`
-			created  string   `json:"created,omitempty"`
-			modified string   `json:"modified,omitempty"`
-			features []string `json:"features,omitempty"`
+		ID         string      `json:"id"`
+		State      NumberState `json:"state"`
+		Features   []string    `json:"features"`
+		AssignedTo string      `json:"assigned_to"`
+		Created    Timestamp   `json:"created"`
+		Updated    Timestamp   `json:"updated"`
+		ReadOnly   struct {
+			State    NumberState `json:"state,omitempty"`
+			Created  int64       `json:"created,omitempty"`
+			Modified int64       `json:"modified,omitempty"`
+			Features []string    `json:"features,omitempty"`
 		} `json:"_read_only,omitempty"`
 	}
 
@@ -39,198 +44,410 @@ type (
 		CascadeQuantity int64                  `json:"cascade_quantity"`
 		Numbers         map[string]PhoneNumber `json:"numbers"`
 	}
+
+	//NumberSearch is a carrier search of numbers available for purchase
+	NumberSearch struct {
+		Prefix   string //e.g. an area code like 415
+		Quantity int64  //how many numbers to return, zero means the server's default
+		Offset   int64
+		Country  string //two-letter country code, the server's default is US
+	}
+
+	//NumberSearchResult is a number found by a carrier search
+	NumberSearchResult struct {
+		Number           string  `json:"number"`
+		E164             string  `json:"e164,omitempty"`
+		FormattedNumber  string  `json:"formatted_number,omitempty"`
+		NPANXX           string  `json:"npa_nxx,omitempty"`
+		Status           string  `json:"status,omitempty"`
+		ActivationCharge float64 `json:"activation_charge,omitempty"`
+		RateCenter       struct {
+			LATA  string `json:"lata,omitempty"`
+			Name  string `json:"name,omitempty"`
+			State string `json:"state,omitempty"`
+		} `json:"rate_center,omitempty"`
+	}
+
+	//PhoneNumbersCollection is the result of a bulk operation, numbers are split into succeeded and failed ones
+	PhoneNumbersCollection struct {
+		Success map[string]PhoneNumber      `json:"success,omitempty"`
+		Error   map[string]PhoneNumberError `json:"error,omitempty"`
+
+		requestID string
+	}
+
+	//PhoneNumberError is the reason a number failed in a bulk operation
+	PhoneNumberError struct {
+		Code    int64       `json:"code,omitempty"`
+		Error   string      `json:"error,omitempty"`
+		Message string      `json:"message,omitempty"` //e.g. number_exists or invalid_state_transition
+		Cause   interface{} `json:"cause,omitempty"`
+	}
+
+	numbersList struct {
+		Numbers []string `json:"numbers"`
+	}
 )
 
 func (api *PhoneNumbersAPIService) CreatePhoneNumber(ctx context.Context, acc string, num string) (number *PhoneNumber, err error) {
-	var response struct {
-		Data PhoneNumber `json:"data"`
-		ResponseEnvelope
+	if num == "" {
+		return nil, reportError("number is required field")
 	}
 
+	number, _, err = do[*PhoneNumber](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "CreatePhoneNumber",
+		method:    "PUT",
+		path:      path("accounts", acc, "phone_numbers", num),
+		causes:    map[int]error{409: ErrNumberExists},
+	})
+
+	return number, err
+}
+
+//DeletePhoneNumber deletes a phone number from a specified account and returns a PhoneNumber object in response
+func (api *PhoneNumbersAPIService) DeletePhoneNumber(ctx context.Context, acc string, num string, hard bool) (number *PhoneNumber, err error) {
 	if num == "" {
 		return nil, reportError("number is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/phone_numbers/" + num,
+	e := endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "DeletePhoneNumber",
+		method:    "DELETE",
+		path:      path("accounts", acc, "phone_numbers", num),
+		causes:    map[int]error{404: ErrNumberNotFound},
 	}
 
-	/*	reqBody := RequestEnvelope{
-			Data: input,
-		}
+	if hard {
+		e.query = url.Values{"hard": []string{"true"}}
+	}
+
+	number, _, err = do[*PhoneNumber](ctx, api.client, e)
 
-		jsonString, err := json.Marshal(reqBody)
+	return number, err
+}
+
+func (api *PhoneNumbersAPIService) ListPhoneNumbers(ctx context.Context, acc string, disablePagination bool) (numbers []PhoneNumber, err error) {
+	data, _, err := do[AccountPhoneNumbersResponse](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "ListPhoneNumbers",
+		method:    "GET",
+		path:      path("accounts", acc, "phone_numbers"),
+		query:     listQuery(disablePagination),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key, number := range data.Numbers {
+		number.ID = key
+		numbers = append(numbers, number)
+	}
+
+	return numbers, nil
+}
+
+//ListPhoneNumbersPaginator returns a paginator over phone numbers of the account,
+//zero pageSize means the server's default page size
+func (api *PhoneNumbersAPIService) ListPhoneNumbersPaginator(acc string, pageSize int64) *Paginator[PhoneNumber] {
+	collection := path("accounts", acc, "phone_numbers")
+
+	return newPaginator(pageSize, func(ctx context.Context, startKey PageKey, pageSize int64) ([]PhoneNumber, PageKey, error) {
+		data, next, err := fetchPage[AccountPhoneNumbersResponse](ctx, api.client, "PhoneNumbersAPI", "ListPhoneNumbersPaginator", collection, startKey, pageSize)
 		if err != nil {
-			return nil, reportError("can't marshall body for request")
+			return nil, "", err
 		}
 
-		body, err := setBody(jsonString, "json")
-		if err != nil {
-			return nil, reportError("can't prepare body for the request")
+		//Numbers come as a map, so sort them to keep the order of a page stable
+		ids := make([]string, 0, len(data.Numbers))
+		for id := range data.Numbers {
+			ids = append(ids, id)
 		}
+		sort.Strings(ids)
 
-		params.PostBody = body*/
+		numbers := make([]PhoneNumber, 0, len(ids))
+		for _, id := range ids {
+			number := data.Numbers[id]
+			number.ID = id
+			numbers = append(numbers, number)
+		}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
+		return numbers, next, nil
+	})
+}
+
+//GetPhoneNumber returns the phone number document
+func (api *PhoneNumbersAPIService) GetPhoneNumber(ctx context.Context, acc, num string) (number *PhoneNumber, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+	if num == "" {
+		return nil, reportError("number is required field")
 	}
-	defer resp.Body.Close()
 
-	switch resp.StatusCode {
-	case 201:
-		decoder := json.NewDecoder(resp.Body)
+	number, _, err = do[*PhoneNumber](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "GetPhoneNumber",
+		method:    "GET",
+		path:      path("accounts", acc, "phone_numbers", num),
+	})
 
-		decErr := decoder.Decode(&response)
-		if decErr != nil {
-			return nil, reportError(decErr.Error())
-		}
+	return number, err
+}
 
-		number = &response.Data
+//UpdatePhoneNumber replaces the phone number document with input.
+//Number documents carry arbitrary feature objects (e911, cnam...), so input is a raw document
+func (api *PhoneNumbersAPIService) UpdatePhoneNumber(ctx context.Context, acc, num string, input map[string]interface{}) (number *PhoneNumber, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
 
-		return number, nil
-	case 400:
-		return nil, UnmarshalKazooError(resp.Body)
-	case 409:
-		return nil, ErrNumberExists
-	default:
-		return nil, UnmarshalKazooError(resp.Body)
+	if num == "" {
+		return nil, reportError("number is required field")
 	}
 
+	number, _, err = do[*PhoneNumber](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "UpdatePhoneNumber",
+		method:    "POST",
+		path:      path("accounts", acc, "phone_numbers", num),
+		body:      input,
+	})
 
-	return number, nil
+	return number, err
 }
 
-//DeletePhoneNumber deletes a phone number from a specified account and returns a PhoneNumber object in response
-func (api *PhoneNumbersAPIService) DeletePhoneNumber(ctx context.Context, acc string, num string, hard bool) (number *PhoneNumber, err error) {
-	var response struct {
-		Data PhoneNumber `json:"data"`
-		ResponseEnvelope
+//PatchPhoneNumber merges input into the phone number document leaving other fields untouched
+func (api *PhoneNumbersAPIService) PatchPhoneNumber(ctx context.Context, acc, num string, input map[string]interface{}) (number *PhoneNumber, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
 	if num == "" {
 		return nil, reportError("number is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "DELETE",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/phone_numbers/" + num,
+	number, _, err = do[*PhoneNumber](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "PatchPhoneNumber",
+		method:    "PATCH",
+		path:      path("accounts", acc, "phone_numbers", num),
+		body:      input,
+	})
+
+	return number, err
+}
+
+//SearchPhoneNumbers searches carriers for numbers available for purchase.
+//Empty acc searches with carriers of the authenticated account
+func (api *PhoneNumbersAPIService) SearchPhoneNumbers(ctx context.Context, acc string, search *NumberSearch) (numbers []NumberSearchResult, err error) {
+	if search == nil || search.Prefix == "" {
+		return nil, reportError("prefix is required field")
 	}
 
-	if hard {
-		params.Path = api.client.cfg.BasePath + "/accounts/" + acc + "/phone_numbers/" + num + "?hard=true"
+	query := url.Values{"prefix": []string{search.Prefix}}
+	if search.Quantity > 0 {
+		query.Set("quantity", strconv.FormatInt(search.Quantity, 10))
+	}
+	if search.Offset > 0 {
+		query.Set("offset", strconv.FormatInt(search.Offset, 10))
+	}
+	if search.Country != "" {
+		query.Set("country", search.Country)
 	}
 
-	/*	reqBody := RequestEnvelope{
-			Data: input,
-		}
+	collection := path("phone_numbers")
+	if acc != "" {
+		collection = path("accounts", acc, "phone_numbers")
+	}
 
-		jsonString, err := json.Marshal(reqBody)
-		if err != nil {
-			return nil, reportError("can't marshall body for request")
-		}
+	numbers, _, err = do[[]NumberSearchResult](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "SearchPhoneNumbers",
+		method:    "GET",
+		path:      collection,
+		query:     query,
+	})
 
-		body, err := setBody(jsonString, "json")
-		if err != nil {
-			return nil, reportError("can't prepare body for the request")
-		}
+	return numbers, err
+}
 
-		params.PostBody = body*/
+//ReservePhoneNumber reserves the number for the account.
+//The current state of the number is checked with ValidateNumberTransition before the number is reserved
+func (api *PhoneNumbersAPIService) ReservePhoneNumber(ctx context.Context, acc, num string) (*PhoneNumber, error) {
+	return api.transitPhoneNumber(ctx, "ReservePhoneNumber", acc, num, NumberReserved, "reserve")
+}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
+//ActivatePhoneNumber puts the number in service in the account (buying it from the carrier if needed).
+//The current state of the number is checked with ValidateNumberTransition before the number is activated
+func (api *PhoneNumbersAPIService) ActivatePhoneNumber(ctx context.Context, acc, num string) (*PhoneNumber, error) {
+	return api.transitPhoneNumber(ctx, "ActivatePhoneNumber", acc, num, NumberInService, "activate")
+}
+
+//transitPhoneNumber fetches the number to validate the transition locally and then asks Kazoo to make it.
+//Numbers the account doesn't have yet are in discovery state, numbers in states
+//unknown to NumberState are left for the server to check
+func (api *PhoneNumbersAPIService) transitPhoneNumber(ctx context.Context, operation, acc, num string, to NumberState, action string) (number *PhoneNumber, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+	if num == "" {
+		return nil, reportError("number is required field")
 	}
-	defer resp.Body.Close()
 
-	switch resp.StatusCode {
-	case 200:
-		decoder := json.NewDecoder(resp.Body)
+	current, err := api.GetPhoneNumber(ctx, acc, num)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		current = &PhoneNumber{State: NumberDiscovery}
+	case err != nil:
+		return nil, err
+	}
 
-		decErr := decoder.Decode(&response)
-		if decErr != nil {
-			return nil, reportError(decErr.Error())
+	if current.State.Known() {
+		if err := ValidateNumberTransition(current.State, to); err != nil {
+			return nil, err
 		}
+	}
 
-		number = &response.Data
+	number, _, err = do[*PhoneNumber](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: operation,
+		method:    "PUT",
+		path:      path("accounts", acc, "phone_numbers", num, action),
+		noRetry:   true,
+	})
 
-		return number, nil
-	case 400:
-		decoder := json.NewDecoder(resp.Body)
-		kazooErr := &genericKazooError{}
+	return number, err
+}
 
-		decErr := decoder.Decode(kazooErr)
-		if decErr != nil {
-			return nil, UnmarshalKazooError(resp.Body)
-		}
-		if kazooErr.Message == "invalid_state_transition" {
-			return nil, ErrInvalidStateTransition
-		}
-		return nil, ErrUnknownException
-	case 404:
-		return nil, ErrNumberNotFound
-	default:
-		return nil, UnmarshalKazooError(resp.Body)
+//CheckPhoneNumbers asks carriers whether the numbers are still available,
+//the result maps every number to its status, e.g. success or error
+func (api *PhoneNumbersAPIService) CheckPhoneNumbers(ctx context.Context, acc string, nums []string) (statuses map[string]string, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	if len(nums) == 0 {
+		return nil, reportError("numbers are required field")
 	}
 
+	statuses, _, err = do[map[string]string](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "CheckPhoneNumbers",
+		method:    "POST",
+		path:      path("accounts", acc, "phone_numbers", "check"),
+		body:      numbersList{Numbers: nums},
+	})
+
+	return statuses, err
 }
 
-func (api *PhoneNumbersAPIService) ListPhoneNumbers(ctx context.Context, acc string, disablePagination bool) (numbers []PhoneNumber, err error) {
-	var response struct {
-		Data AccountPhoneNumbersResponse `json:"data"`
-		ResponseEnvelope
+//AddPhoneNumbers adds the numbers to the account in a single request.
+//Numbers which failed don't fail the call, they are reported in the Error field of the result
+func (api *PhoneNumbersAPIService) AddPhoneNumbers(ctx context.Context, acc string, nums []string) (*PhoneNumbersCollection, error) {
+	return api.numbersCollection(ctx, "AddPhoneNumbers", "PUT", acc, nums)
+}
+
+//Err returns the failure of the number as *APIError, so it's handled like errors of single number calls:
+//it carries the number's status code and the request_id of the bulk request and matches errors
+//of its class, e.g. ErrNumberExists. Nil is returned if the number didn't fail
+func (c *PhoneNumbersCollection) Err(num string) error {
+	e, ok := c.Error[num]
+	if !ok {
+		return nil
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/phone_numbers",
+	apiErr := &APIError{
+		StatusCode:   int(e.Code),
+		ErrorCode:    e.Error,
+		ErrorMessage: e.Message,
+		RequestID:    c.requestID,
+	}
+	if apiErr.ErrorMessage == "" {
+		apiErr.ErrorMessage = e.Error
+	}
+	if data, err := json.Marshal(e); err == nil {
+		apiErr.Data = data
 	}
 
-	if disablePagination {
-		params.QueryParams = map[string][]string{"paginate": []string{"false"}}
+	switch apiErr.ErrorMessage {
+	case "number_exists":
+		return apiErr.withCause(ErrNumberExists)
+	case "invalid_state_transition":
+		return apiErr.withCause(ErrInvalidStateTransition)
+	case "not_found", "bad_identifier":
+		return apiErr.withCause(ErrNumberNotFound)
 	}
+	return apiErr.withCause(ErrUnknownException)
+}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
+//DeletePhoneNumbers removes the numbers from the account in a single request.
+//Numbers which failed don't fail the call, they are reported in the Error field of the result
+func (api *PhoneNumbersAPIService) DeletePhoneNumbers(ctx context.Context, acc string, nums []string) (*PhoneNumbersCollection, error) {
+	return api.numbersCollection(ctx, "DeletePhoneNumbers", "DELETE", acc, nums)
+}
+
+func (api *PhoneNumbersAPIService) numbersCollection(ctx context.Context, operation, method, acc string, nums []string) (result *PhoneNumbersCollection, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
+	}
+
+	if len(nums) == 0 {
+		return nil, reportError("numbers are required field")
 	}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
+	result, meta, err := do[*PhoneNumbersCollection](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: operation,
+		method:    method,
+		path:      path("accounts", acc, "phone_numbers", "collection"),
+		body:      numbersList{Numbers: nums},
+		noRetry:   true,
+	})
+	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	result.requestID = meta.RequestID
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+	for num, number := range result.Success {
+		number.ID = num
+		result.Success[num] = number
 	}
 
-	decoder := json.NewDecoder(resp.Body)
+	return result, nil
+}
 
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+//GetNumberClassifiers returns classifiers of numbers set in number_manager config of the account,
+//number.DefaultClassifiers if the account doesn't override them
+func (api *PhoneNumbersAPIService) GetNumberClassifiers(ctx context.Context, acc string) (number.Classifiers, error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	for key, number := range response.Data.Numbers {
-		number.ID = key
-		numbers = append(numbers, number)
+	config, _, err := do[struct {
+		Classifiers json.RawMessage `json:"classifiers"`
+	}](ctx, api.client, endpoint{
+		service:   "PhoneNumbersAPI",
+		operation: "GetNumberClassifiers",
+		method:    "GET",
+		path:      path("accounts", acc, "configs", "number_manager"),
+	})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return number.DefaultClassifiers(), nil
+	case err != nil:
+		return nil, err
+	case len(config.Classifiers) == 0:
+		return number.DefaultClassifiers(), nil
 	}
 
-	return numbers, nil
+	classifiers, err := number.ParseClassifiers(config.Classifiers)
+	if err != nil {
+		return nil, reportError("can't decode classifiers of %s: %v", acc, err)
+	}
+
+	return classifiers, nil
 }
@@ -2,6 +2,7 @@ package kazooapi_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	kazooapi "github.com/sashker/kazoo-go"
@@ -26,10 +27,300 @@ func TestPhoneNumbersService_DeletePhoneNumber(t *testing.T) {
 	//input := &kazooapi.Account{ID: "qe0ade400015367f0069d6dfbdca072a"}
 
 	resp, err := clt.PhoneNumbersAPI.DeletePhoneNumber(ctx, "qe0ade400015367f0069d6dfbdca072a", "+74955555555", false)
-	if err != nil {
-		t.Error(err)
-	}
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, kazooapi.ErrNumberNotFound), "should be ErrNumberNotFound")
+	assert.True(t, errors.Is(err, kazooapi.ErrNotFound), "should be ErrNotFound")
 
-	assert.Equal(t, "+74955555555", resp.ID, "ID's should be equal")
+	var apiErr *kazooapi.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, 404, apiErr.StatusCode)
+		assert.Equal(t, "bad_identifier", apiErr.Message())
+		assert.Equal(t, "be0631b976148f384f2d1310b21df3bd", apiErr.RequestID)
+	}
 	//assert.ElementsMatch(t, []string{}, resp[0].Numbers, "Should be empty list")
 }
+
+func TestPhoneNumbersService_CRUD(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"+74955555555","state":"in_service","cnam":{"display_name":"ACME"}}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/phone_numbers/+74955555555"
+
+	num, err := clt.PhoneNumbersAPI.GetPhoneNumber(ctx, "qe0ade400015367f0069d6dfbdca072a", "+74955555555")
+	assert.NoError(t, err)
+	assert.Equal(t, kazooapi.NumberInService, num.State)
+	assertRequest(t, last(), "GET", path)
+
+	doc := map[string]interface{}{"cnam": map[string]interface{}{"display_name": "ACME"}}
+	_, err = clt.PhoneNumbersAPI.UpdatePhoneNumber(ctx, "qe0ade400015367f0069d6dfbdca072a", "+74955555555", doc)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "POST", path)
+	assert.Equal(t, doc, last().Data)
+
+	_, err = clt.PhoneNumbersAPI.PatchPhoneNumber(ctx, "qe0ade400015367f0069d6dfbdca072a", "+74955555555", doc)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", path)
+
+	_, err = clt.PhoneNumbersAPI.GetPhoneNumber(ctx, "qe0ade400015367f0069d6dfbdca072a", "")
+	assert.EqualError(t, err, "number is required field")
+}
+
+func TestNumberState_CanTransitionTo(t *testing.T) {
+	cases := []struct {
+		from, to kazooapi.NumberState
+		allowed  bool
+	}{
+		{kazooapi.NumberDiscovery, kazooapi.NumberReserved, true},
+		{kazooapi.NumberDiscovery, kazooapi.NumberInService, true},
+		{kazooapi.NumberReserved, kazooapi.NumberInService, true},
+		{kazooapi.NumberPortIn, kazooapi.NumberInService, true},
+		{kazooapi.NumberInService, kazooapi.NumberAging, true},
+		{kazooapi.NumberInService, kazooapi.NumberReserved, false},
+		{kazooapi.NumberPortIn, kazooapi.NumberReserved, false},
+		{kazooapi.NumberDiscovery, kazooapi.NumberPortIn, false},
+		{kazooapi.NumberDeleted, kazooapi.NumberInService, false},
+		{"available", kazooapi.NumberReserved, false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.allowed, c.from.CanTransitionTo(c.to), "%s -> %s", c.from, c.to)
+
+		err := kazooapi.ValidateNumberTransition(c.from, c.to)
+		assert.Equal(t, !c.allowed, errors.Is(err, kazooapi.ErrInvalidStateTransition), "%s -> %s", c.from, c.to)
+	}
+}
+
+func TestPhoneNumbersService_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		acc  = "4dee5c1bef3ace50911c9917c50c9f80"
+		path = "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers/+14155550100"
+	)
+
+	srv, last := MockDocumentServer(t, `{"id":"+14155550100","state":"reserved"}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	num, err := clt.PhoneNumbersAPI.ActivatePhoneNumber(ctx, acc, "+14155550100")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PUT", path+"/activate")
+	assert.Equal(t, kazooapi.NumberReserved, num.State)
+
+	_, err = clt.PhoneNumbersAPI.ReservePhoneNumber(ctx, acc, "+14155550100")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PUT", path+"/reserve")
+
+	srv, last = MockDocumentServer(t, `{"id":"+14155550100","state":"in_service"}`)
+	defer srv.Close()
+	clt = newMockClient(t, srv)
+
+	//The number is in service already, so it isn't sent to reserve
+	_, err = clt.PhoneNumbersAPI.ReservePhoneNumber(ctx, acc, "+14155550100")
+	assert.True(t, errors.Is(err, kazooapi.ErrInvalidStateTransition))
+	assertRequest(t, last(), "GET", path)
+}
+
+func TestPhoneNumbersService_Search(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `[
+		{"number":"+14152338397","formatted_number":"1-415-233-8397","npa_nxx":"415233","rate_center":{"lata":"722","name":"SAN RAFAEL","state":"CA"}},
+		{"number":"+14152338398"}
+	]`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	found, err := acc.PhoneNumbers.Search(ctx, &kazooapi.NumberSearch{Prefix: "415", Quantity: 2})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers")
+	assert.Equal(t, "prefix=415&quantity=2", last().Query)
+
+	if assert.Len(t, found, 2) {
+		assert.Equal(t, "+14152338397", found[0].Number)
+		assert.Equal(t, "SAN RAFAEL", found[0].RateCenter.Name)
+	}
+
+	_, err = acc.PhoneNumbers.Search(ctx, &kazooapi.NumberSearch{})
+	assert.EqualError(t, err, "prefix is required field")
+}
+
+func TestPhoneNumbersService_Collection(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{
+		"success":{"+14155550100":{"state":"reserved"}},
+		"error":{"+14155550101":{"code":409,"error":"number_exists","message":"number_exists","cause":"+14155550101"}}
+	}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	const collection = "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers/collection"
+	nums := []string{"+14155550100", "+14155550101"}
+
+	res, err := acc.PhoneNumbers.AddCollection(ctx, nums)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PUT", collection)
+	assert.Equal(t, map[string]interface{}{"numbers": []interface{}{"+14155550100", "+14155550101"}}, last().Data)
+
+	assert.Equal(t, "+14155550100", res.Success["+14155550100"].ID)
+	assert.Equal(t, kazooapi.NumberReserved, res.Success["+14155550100"].State)
+	assert.Equal(t, "number_exists", res.Error["+14155550101"].Message)
+
+	_, err = acc.PhoneNumbers.DeleteCollection(ctx, nums)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "DELETE", collection)
+
+	_, err = acc.PhoneNumbers.DeleteCollection(ctx, nil)
+	assert.EqualError(t, err, "numbers are required field")
+
+	srv, last = MockDocumentServer(t, `{"+14155550100":"success","+14155550101":"error"}`)
+	defer srv.Close()
+	clt = newMockClient(t, srv)
+
+	statuses, err := clt.PhoneNumbersAPI.CheckPhoneNumbers(ctx, "4dee5c1bef3ace50911c9917c50c9f80", nums)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "POST", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers/check")
+	assert.Equal(t, map[string]string{"+14155550100": "success", "+14155550101": "error"}, statuses)
+}
+
+func TestPhoneNumbersService_Features(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{
+		"id":"+14155550100",
+		"cnam":{"display_name":"ACME","inbound_lookup":true},
+		"prepend":{"enabled":true,"name":"Sales"},
+		"carrier_name":"local",
+		"_read_only":{"state":"in_service"}
+	}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	const path = "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/phone_numbers/+14155550100"
+
+	features, err := acc.PhoneNumbers.GetFeatures(ctx, "+14155550100")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", path)
+	assert.Equal(t, &kazooapi.NumberCNAM{DisplayName: "ACME", InboundLookup: true}, features.CNAM)
+	assert.Nil(t, features.E911)
+
+	force := true
+	features.Prepend = nil
+	features.Failover = &kazooapi.NumberFailover{SIP: "sip:failover@pbx.example.com"}
+	features.ForceOutbound = &force
+	features.E911 = &kazooapi.NumberE911{
+		StreetAddress: "140 Main St",
+		Locality:      "San Francisco",
+		Region:        "CA",
+		PostalCode:    "94105",
+	}
+
+	_, err = acc.PhoneNumbers.UpdateFeatures(ctx, "+14155550100", features)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "POST", path)
+
+	//Fields unknown to NumberFeatures survive, the removed prepend doesn't
+	doc := last().Data.(map[string]interface{})
+	assert.Equal(t, "local", doc["carrier_name"])
+	assert.NotContains(t, doc, "prepend")
+	assert.Equal(t, true, doc["force_outbound"])
+	assert.Equal(t, map[string]interface{}{"sip": "sip:failover@pbx.example.com"}, doc["failover"])
+	assert.Equal(t, map[string]interface{}{
+		"street_address": "140 Main St",
+		"locality":       "San Francisco",
+		"region":         "CA",
+		"postal_code":    "94105",
+	}, doc["e911"])
+
+	//A number without a document still gets its features
+	srv2, last2 := MockDocumentServer(t, `null`)
+	defer srv2.Close()
+	clt2 := newMockClient(t, srv2)
+
+	_, err = clt2.PhoneNumbersAPI.UpdatePhoneNumberFeatures(ctx, "4dee5c1bef3ace50911c9917c50c9f80", "+14155550100",
+		&kazooapi.NumberFeatures{ForceOutbound: &force})
+	assert.NoError(t, err)
+	assertRequest(t, last2(), "POST", path)
+	assert.Equal(t, map[string]interface{}{"force_outbound": true}, last2().Data)
+}
+
+func TestNumberE911_Validate(t *testing.T) {
+	e911 := &kazooapi.NumberE911{
+		StreetAddress:             "140 Main St",
+		Locality:                  "San Francisco",
+		Region:                    "California",
+		PostalCode:                "9410",
+		PlusFour:                  "12",
+		NotificationContactEmails: []string{"noc@example.com", "noc"},
+	}
+
+	err := e911.Validate()
+	assert.True(t, errors.Is(err, kazooapi.ErrValidation))
+	assert.EqualError(t, err, "Validation: invalid e911 address: "+
+		"region must be a two-letter state code, e.g. CA, postal_code must be 5 digits, "+
+		"plus_four must be 4 digits, notification contact noc isn't an email")
+
+	err = (&kazooapi.NumberE911{}).Validate()
+	assert.EqualError(t, err, "Validation: invalid e911 address: "+
+		"street_address is required, locality is required, region is required, postal_code is required")
+
+	//Invalid addresses aren't sent
+	srv, last := MockDocumentServer(t, `{}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	_, err = clt.PhoneNumbersAPI.UpdatePhoneNumberFeatures(context.Background(), "4dee5c1bef3ace50911c9917c50c9f80", "+14155550100",
+		&kazooapi.NumberFeatures{E911: e911})
+	assert.True(t, errors.Is(err, kazooapi.ErrValidation))
+	assert.Equal(t, "", last().Method)
+}
+
+func TestPhoneNumbersService_Classifiers(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{
+		"id":"number_manager",
+		"classifiers":{
+			"emergency":{"regex":"^(911|112)$","friendly_name":"Emergency","emergency":true},
+			"uk":{"regex":"^\\+44([0-9]{10})$","friendly_name":"UK","pretty_print":"SSS0#### ######"}
+		}
+	}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	acc, err := clt.Account("4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+
+	classifiers, err := acc.PhoneNumbers.Classifiers(ctx)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", "/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/configs/number_manager")
+
+	if assert.Len(t, classifiers, 2) {
+		assert.Equal(t, "emergency", classifiers[0].Name)
+		assert.True(t, classifiers[0].Emergency)
+		assert.Equal(t, "uk", classifiers.Classify("00442079460000"))
+		assert.Equal(t, "02079 460000", classifiers[1].Format("+442079460000"))
+	}
+
+	//Accounts without classifiers get the default ones
+	srv2, _ := MockDocumentServer(t, `{"id":"number_manager"}`)
+	defer srv2.Close()
+
+	classifiers, err = newMockClient(t, srv2).PhoneNumbersAPI.GetNumberClassifiers(ctx, "4dee5c1bef3ace50911c9917c50c9f80")
+	assert.NoError(t, err)
+	assert.Len(t, classifiers, 7)
+	assert.Equal(t, "tollfree_us", classifiers.Classify("8005550100"))
+}
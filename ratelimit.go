@@ -0,0 +1,276 @@
+package kazooapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//RateLimit describes client-side throttling of requests made by an APIClient.
+//Crossbar throttles requests per account, so a bulk job sharing
+//one client between many goroutines should slow itself down
+type RateLimit struct {
+	//RequestsPerSecond is the steady rate of requests, zero disables the token bucket
+	RequestsPerSecond float64
+	//Burst is the number of requests which might be made at once, at least 1
+	Burst int
+	//MaxInFlight caps the number of concurrent requests, zero means unlimited
+	MaxInFlight int
+	//PerAccount keeps a separate bucket for every /accounts/{id}
+	PerAccount bool
+	//MinRequestsPerSecond is the floor the rate is lowered to when the server responds with 429
+	MinRequestsPerSecond float64
+}
+
+//bucketSweepInterval is how often per-account buckets are checked for idle ones
+const bucketSweepInterval = time.Minute
+
+//rateLimiter applies RateLimit inside callAPI
+type rateLimiter struct {
+	cfg RateLimit
+	sem chan struct{}
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(cfg *RateLimit) *rateLimiter {
+	if cfg == nil {
+		return nil
+	}
+
+	l := &rateLimiter{
+		cfg:       *cfg,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+
+	if l.cfg.Burst < 1 {
+		l.cfg.Burst = 1
+	}
+	if l.cfg.MinRequestsPerSecond <= 0 {
+		l.cfg.MinRequestsPerSecond = l.cfg.RequestsPerSecond / 10
+	}
+	if cfg.MaxInFlight > 0 {
+		l.sem = make(chan struct{}, cfg.MaxInFlight)
+	}
+
+	return l
+}
+
+//bucket returns the token bucket the request is charged to, nil if there is no rate limit
+func (l *rateLimiter) bucket(request *http.Request) *tokenBucket {
+	if l.cfg.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	key := ""
+	if l.cfg.PerAccount {
+		key = accountFromPath(request.URL.Path)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now := time.Now(); now.Sub(l.lastSweep) >= bucketSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newTokenBucket(l.cfg.RequestsPerSecond, l.cfg.MinRequestsPerSecond, l.cfg.Burst)
+		l.buckets[key] = b
+	}
+
+	return b
+}
+
+//sweep forgets idle buckets, so a client walking many accounts doesn't keep a bucket for each of them.
+//Must be called with the lock held
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.idle(now) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+//acquire waits until the request is allowed to be sent.
+//The returned function must be called when the request is finished
+func (l *rateLimiter) acquire(ctx context.Context, request *http.Request) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if b := l.bucket(request); b != nil {
+		if err := b.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-l.sem }) }, nil
+}
+
+//observe adapts the rate to the server's response
+func (l *rateLimiter) observe(request *http.Request, resp *http.Response) {
+	if l == nil || resp == nil {
+		return
+	}
+
+	b := l.bucket(request)
+	if b == nil {
+		return
+	}
+
+	if resp.StatusCode == 429 {
+		pause, _ := retryAfter(resp.Header.Get("Retry-After"))
+		b.throttle(pause)
+		return
+	}
+
+	if resp.StatusCode < 300 {
+		b.recover()
+	}
+}
+
+//releaseOnClose keeps the in-flight slot until the response body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
+
+//accountFromPath returns the account id from a path like /v2/accounts/{id}/users
+func accountFromPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "accounts" && i+1 < len(segments) {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+//tokenBucket is a token bucket with adjustable rate
+type tokenBucket struct {
+	mu          sync.Mutex
+	rate        float64
+	maxRate     float64
+	minRate     float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(rate, minRate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:    rate,
+		maxRate: rate,
+		minRate: minRate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+//refill must be called with the lock held
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+//idle reports whether the bucket is full and not throttled,
+//such a bucket is no different from a new one and might be dropped
+func (b *tokenBucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tokens := b.tokens + now.Sub(b.last).Seconds()*b.rate
+	return tokens >= b.burst && b.rate >= b.maxRate && !now.Before(b.pausedUntil)
+}
+
+//wait takes a token from the bucket, waiting for one if the bucket is empty
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.refill(now)
+
+		var delay time.Duration
+		switch {
+		case now.Before(b.pausedUntil):
+			delay = b.pausedUntil.Sub(now)
+		case b.tokens >= 1:
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		default:
+			delay = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		}
+		b.mu.Unlock()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+//throttle halves the rate and stops issuing tokens for the given pause
+func (b *tokenBucket) throttle(pause time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.refill(now)
+
+	b.rate /= 2
+	if b.rate < b.minRate {
+		b.rate = b.minRate
+	}
+	b.tokens = 0
+
+	if until := now.Add(pause); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+//recover raises the rate back to the configured one after a successful request
+func (b *tokenBucket) recover() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate >= b.maxRate {
+		return
+	}
+
+	b.refill(time.Now())
+	b.rate += b.maxRate / 20
+	if b.rate > b.maxRate {
+		b.rate = b.maxRate
+	}
+}
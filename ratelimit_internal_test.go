@@ -0,0 +1,31 @@
+package kazooapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_SweepIdleBuckets(t *testing.T) {
+	l := newRateLimiter(&RateLimit{RequestsPerSecond: 1000, Burst: 1, PerAccount: true})
+	ctx := context.Background()
+
+	for _, acc := range []string{"4dee5c1bef3ace50911c9917c50c9f80", "b7f2a1c9d3e84f5a9c6b2d1e0f3a4b5c", "1e2d3c4b5a6978877665544332211000"} {
+		request, _ := http.NewRequest("GET", "http://localhost/v2/accounts/"+acc+"/limits", nil)
+		release, err := l.acquire(ctx, request)
+		assert.NoError(t, err)
+		release()
+	}
+	assert.Len(t, l.buckets, 3)
+
+	//The throttled bucket is kept, the others have refilled and are forgotten
+	throttled, _ := http.NewRequest("GET", "http://localhost/v2/accounts/4dee5c1bef3ace50911c9917c50c9f80/limits", nil)
+	l.observe(throttled, &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": {"120"}}})
+
+	l.sweep(time.Now().Add(time.Second))
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "4dee5c1bef3ace50911c9917c50c9f80")
+}
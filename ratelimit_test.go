@@ -0,0 +1,154 @@
+package kazooapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+//MockLimitsServer serves limits of any account, delaying every response
+func MockLimitsServer(t *testing.T, delay time.Duration, inFlight, maxInFlight *int32) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+
+	mux.HandleFunc("/v2/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+
+		for {
+			max := atomic.LoadInt32(maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(maxInFlight, max, n) {
+				break
+			}
+		}
+
+		time.Sleep(delay)
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"twoway_trunks":10},"status":"success"}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func newRateLimitedClient(t *testing.T, srv *httptest.Server, limit *kazooapi.RateLimit) *kazooapi.APIClient {
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+	cfg.RateLimit = limit
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	return clt
+}
+
+func TestRateLimit_MaxInFlight(t *testing.T) {
+	ctx := context.Background()
+
+	var inFlight, maxInFlight int32
+	srv := MockLimitsServer(t, 20*time.Millisecond, &inFlight, &maxInFlight)
+	defer srv.Close()
+
+	clt := newRateLimitedClient(t, srv, &kazooapi.RateLimit{MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+}
+
+func TestRateLimit_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+
+	var inFlight, maxInFlight int32
+	srv := MockLimitsServer(t, 0, &inFlight, &maxInFlight)
+	defer srv.Close()
+
+	clt := newRateLimitedClient(t, srv, &kazooapi.RateLimit{RequestsPerSecond: 50, Burst: 1})
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+		assert.NoError(t, err)
+	}
+
+	//The first request takes the only token, every next one waits 20ms
+	assert.True(t, time.Since(start) >= 90*time.Millisecond, "requests should be throttled, took %v", time.Since(start))
+}
+
+func TestRateLimit_PerAccount(t *testing.T) {
+	var inFlight, maxInFlight int32
+	srv := MockLimitsServer(t, 0, &inFlight, &maxInFlight)
+	defer srv.Close()
+
+	clt := newRateLimitedClient(t, srv, &kazooapi.RateLimit{RequestsPerSecond: 1, Burst: 1, PerAccount: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+
+	//Another account has its own bucket
+	_, err = clt.LimitsAPI.GetLimits(ctx, "2669be1c6c2d3ead16bbdd0b97aa2744")
+	assert.NoError(t, err)
+
+	//The first account's bucket is empty for the next second
+	_, err = clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRateLimit_Throttled(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/callflows", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Add("Retry-After", "0")
+			w.WriteHeader(429)
+			io.WriteString(w, `{"data":{},"error":"429","message":"too_many_requests","status":"error"}`)
+			return
+		}
+
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{"id":"cf0","numbers":["100"],"flow":{"module":"user"}},"status":"success"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	clt := newRateLimitedClient(t, srv, &kazooapi.RateLimit{RequestsPerSecond: 100, Burst: 10})
+
+	//Even a PUT is replayed after 429 since the server didn't process it
+	cf, err := clt.CallflowsAPI.CreateCallflow(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Callflow{Numbers: []string{"100"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "cf0", cf.ID)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
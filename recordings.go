@@ -5,8 +5,6 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 )
 
 var (
@@ -48,76 +46,49 @@ type RecordingsList struct {
 
 //ListRecordings returns a list of recordings for the account
 func (recapi *RecordingsAPIService) ListRecordings(ctx context.Context, acc string) (rec []Recording, err error) {
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   recapi.client.cfg.BasePath + "/accounts/" + acc + "/recordings",
-	}
-
-	req, err := recapi.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := recapi.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
-	}
-
-	env := RequestEnvelope{}
-
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&env)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %s", decErr)
-	}
+	rec, _, err = do[[]Recording](ctx, recapi.client, endpoint{
+		service:   "RecordingsAPI",
+		operation: "ListRecordings",
+		method:    "GET",
+		path:      path("accounts", acc, "recordings"),
+	})
 
 	return rec, err
-
 }
 
 func (recapi *RecordingsAPIService) GetRecording(ctx context.Context, acc, recording string) (rec *Recording, err error) {
-	rec = &Recording{}
+	rec, _, err = do[*Recording](ctx, recapi.client, endpoint{
+		service:   "RecordingsAPI",
+		operation: "GetRecording",
+		method:    "GET",
+		path:      path("accounts", acc, "recordings", recording),
+	})
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   recapi.client.cfg.BasePath + "/accounts/" + acc + "/recordings/" + recording,
-	}
+	return rec, err
+}
 
-	req, err := recapi.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("can't prepare a request %s", err)
-	}
+//ListRecordingsPaginator returns a paginator over recordings of the account,
+//zero pageSize means the server's default page size
+func (recapi *RecordingsAPIService) ListRecordingsPaginator(acc string, pageSize int64) *Paginator[Recording] {
+	return listPaginator[Recording](recapi.client, "RecordingsAPI", "ListRecordingsPaginator", path("accounts", acc, "recordings"), pageSize)
+}
 
-	resp, err := recapi.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+//DeleteRecording removes the recording and returns the deleted document
+func (recapi *RecordingsAPIService) DeleteRecording(ctx context.Context, acc, recording string) (rec *Recording, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("StatusCode: %v, Status: %v, Body: %s", resp.StatusCode, resp.Status, bodyBytes)
+	if recording == "" {
+		return nil, reportError("recording id is required field")
 	}
 
-	env := RequestEnvelope{}
-	env.Data = rec
-
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&env)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
-	}
+	rec, _, err = do[*Recording](ctx, recapi.client, endpoint{
+		service:   "RecordingsAPI",
+		operation: "DeleteRecording",
+		method:    "DELETE",
+		path:      path("accounts", acc, "recordings", recording),
+	})
 
 	return rec, err
-
 }
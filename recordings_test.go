@@ -0,0 +1,24 @@
+package kazooapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingsAPIService_DeleteRecording(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"201908-6b0f3c1de1a0e3e2c8a6e8a44dbbe2a1","call_id":"a1b2c3d4","media_type":"mp3"}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	rec, err := clt.RecordingsAPI.DeleteRecording(ctx, "qe0ade400015367f0069d6dfbdca072a", "201908-6b0f3c1de1a0e3e2c8a6e8a44dbbe2a1")
+	assert.NoError(t, err)
+	assert.Equal(t, "a1b2c3d4", rec.CallID)
+	assertRequest(t, last(), "DELETE", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/recordings/201908-6b0f3c1de1a0e3e2c8a6e8a44dbbe2a1")
+
+	_, err = clt.RecordingsAPI.DeleteRecording(ctx, "qe0ade400015367f0069d6dfbdca072a", "")
+	assert.EqualError(t, err, "recording id is required field")
+}
@@ -0,0 +1,73 @@
+package kazooapi
+
+import (
+	"context"
+)
+
+//ResponseMeta is the metadata Kazoo sends along with the data of every response.
+//RequestID and Node are what 2600hz support asks for, Revision is
+//the revision of the returned document
+type ResponseMeta struct {
+	StatusCode   int
+	RequestID    string
+	Revision     string
+	Node         string
+	Version      string
+	Timestamp    string
+	AuthToken    string
+	PageSize     int
+	StartKey     PageKey
+	NextStartKey PageKey
+	//ETag is the value of the Etag response header
+	ETag string
+}
+
+type responseMetaKey struct{}
+
+//WithResponseMeta returns a context which makes a service call fill meta
+//with the metadata of the response:
+//
+//	var meta kazooapi.ResponseMeta
+//	acc, err := client.AccountsAPI.GetAccount(kazooapi.WithResponseMeta(ctx, &meta), id)
+//
+//meta is filled for error responses as well. If the call makes several
+//requests (e.g. a paginator), meta describes the last one
+func WithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+//fillResponseMeta copies metadata of the call into the meta attached to the context, if any
+func fillResponseMeta(ctx context.Context, call *Call) {
+	meta, _ := ctx.Value(responseMetaKey{}).(*ResponseMeta)
+	if meta == nil || call.Response == nil {
+		return
+	}
+
+	*meta = newResponseMeta(call)
+}
+
+//newResponseMeta collects metadata of the call's response
+func newResponseMeta(call *Call) ResponseMeta {
+	if call.Response == nil {
+		return ResponseMeta{}
+	}
+
+	meta := ResponseMeta{
+		StatusCode: call.Response.StatusCode,
+		ETag:       call.Response.Header.Get("Etag"),
+	}
+
+	if env := call.ResponseEnvelope; env != nil {
+		meta.RequestID = env.RequestID
+		meta.Revision = env.Revision
+		meta.Node = env.Node
+		meta.Version = env.Version
+		meta.Timestamp = env.Timestamp
+		meta.AuthToken = env.AuthToken
+		meta.PageSize = env.PageSize
+		meta.StartKey = env.StartKey
+		meta.NextStartKey = env.NextStartKey
+	}
+
+	return meta
+}
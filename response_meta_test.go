@@ -0,0 +1,77 @@
+package kazooapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithResponseMeta(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Etag", "2-01809fe4c9e8a85345215c0e29bdc2aa")
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, `{
+    "data": {"twoway_trunks": 1000, "id": "limits"},
+    "revision": "2-01809fe4c9e8a85345215c0e29bdc2aa",
+    "timestamp": "2019-08-20T11:34:28",
+    "version": "4.2.33",
+    "node": "o51LI7TmYyGMzigLbo-upw",
+    "request_id": "8e97b5e8ee54c2e7057ed35e7480d08d",
+    "status": "success",
+    "auth_token": "token"
+}`)
+	})
+
+	limitsSrv := httptest.NewServer(mux)
+	defer limitsSrv.Close()
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = limitsSrv.URL + "/v2"
+	cfg.HTTPClient = limitsSrv.Client()
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	var meta kazooapi.ResponseMeta
+	limits, err := clt.LimitsAPI.GetLimits(kazooapi.WithResponseMeta(ctx, &meta), "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), limits.TwowayTrunks)
+
+	assert.Equal(t, 200, meta.StatusCode)
+	assert.Equal(t, "8e97b5e8ee54c2e7057ed35e7480d08d", meta.RequestID)
+	assert.Equal(t, "o51LI7TmYyGMzigLbo-upw", meta.Node)
+	assert.Equal(t, "2-01809fe4c9e8a85345215c0e29bdc2aa", meta.Revision)
+	assert.Equal(t, "2-01809fe4c9e8a85345215c0e29bdc2aa", meta.ETag)
+	assert.Equal(t, "4.2.33", meta.Version)
+
+	//Error responses carry the metadata as well
+	srv := MockKazooServer(t, "")
+	defer srv.Close()
+
+	cfg2 := kazooapi.NewConfiguration()
+	cfg2.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg2.BasePath = srv.URL + "/v2"
+	cfg2.HTTPClient = srv.Client()
+
+	clt2, err := kazooapi.NewAPIClient(cfg2)
+	assert.NoError(t, err)
+
+	var errMeta kazooapi.ResponseMeta
+	_, err = clt2.PhoneNumbersAPI.DeletePhoneNumber(kazooapi.WithResponseMeta(ctx, &errMeta), "qe0ade400015367f0069d6dfbdca072a", "+74955555555", false)
+	assert.Error(t, err)
+	assert.Equal(t, 404, errMeta.StatusCode)
+	assert.Equal(t, "be0631b976148f384f2d1310b21df3bd", errMeta.RequestID)
+}
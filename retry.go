@@ -0,0 +1,213 @@
+package kazooapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+//RetryPolicy describes how callAPI retries failed requests.
+//Crossbar nodes answer 502/503/504 for a while during a rolling restart,
+//so these codes are retried by default along with 429 (throttled request)
+type RetryPolicy struct {
+	//MaxAttempts is the total number of attempts including the first one,
+	//values less than 2 disable retries
+	MaxAttempts int
+	//InitialBackoff is the delay before the second attempt,
+	//every next delay is twice as long but no longer than MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	//Jitter is the part (0..1) of each delay which is randomized,
+	//so many clients don't hit a recovering cluster at the same moment
+	Jitter float64
+	//MaxRetryAfter caps the delay requested by the server in Retry-After header,
+	//a response asking to wait longer is returned to the caller as is
+	MaxRetryAfter time.Duration
+	//RetryableStatusCodes lists HTTP status codes which are worth retrying
+	RetryableStatusCodes []int
+	//RetryNetworkErrors enables retries of connection errors and timeouts
+	RetryNetworkErrors bool
+	//RetryNonIdempotent enables retries of writes made without If-Match. Kazoo uses PUT to create
+	//documents and POST to run actions (e.g. channel transfers), so a replayed request might apply twice.
+	//Endpoints which must never be replayed aren't retried even with it
+	RetryNonIdempotent bool
+}
+
+//DefaultRetryPolicy returns the policy used by NewConfiguration
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          4,
+		InitialBackoff:       200 * time.Millisecond,
+		MaxBackoff:           5 * time.Second,
+		Jitter:               0.5,
+		MaxRetryAfter:        time.Minute,
+		RetryableStatusCodes: []int{429, 502, 503, 504},
+		RetryNetworkErrors:   true,
+	}
+}
+
+//idempotent reports whether the request can be replayed without side effects.
+//Writes are replayed only when they carry If-Match: once the first attempt has been applied,
+//the revision no longer matches and Kazoo rejects the replay instead of applying it again
+func (p *RetryPolicy) idempotent(request *http.Request) bool {
+	switch request.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+
+	return p.RetryNonIdempotent || request.Header.Get("If-Match") != ""
+}
+
+//retryable decides whether the attempt should be repeated
+func (p *RetryPolicy) retryable(ctx context.Context, attempt int, request *http.Request, resp *http.Response, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if info, _ := request.Context().Value(callInfoKey{}).(*callInfo); info != nil && info.noRetry {
+		return false
+	}
+
+	//429 means the request was rejected before processing, so it's safe to replay any method
+	throttled := resp != nil && resp.StatusCode == 429
+	if !throttled && !p.idempotent(request) {
+		return false
+	}
+
+	if request.Body != nil && request.GetBody == nil {
+		//The body has been consumed and can't be sent again
+		return false
+	}
+
+	if err != nil {
+		return p.RetryNetworkErrors && isNetworkError(err)
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+//delay returns how long to wait before the next attempt,
+//false is returned if the server asked to wait longer than allowed
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+
+	if resp != nil {
+		if ra, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxRetryAfter > 0 && ra > p.MaxRetryAfter {
+				return 0, false
+			}
+			if ra > d {
+				d = ra
+			}
+		}
+	}
+
+	return d, true
+}
+
+//retryAfter parses Retry-After header given either in seconds or as HTTP date
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+//isNetworkError reports whether the error is a transient transport failure
+func isNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return false
+}
+
+//rewindBody prepares the request body to be sent once again
+func rewindBody(request *http.Request) error {
+	if request.Body == nil || request.GetBody == nil {
+		return nil
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return err
+	}
+	request.Body = body
+
+	return nil
+}
+
+//discardResponse releases the connection of a response we aren't going to return
+func discardResponse(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+//sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
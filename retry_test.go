@@ -0,0 +1,212 @@
+package kazooapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+//MockFlakyServer fails the first `failures` requests to the limits endpoint with the given status
+func MockFlakyServer(t *testing.T, failures int32, status int, retryAfter string, hits *int32) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(hits, 1)
+
+		if r.Method == "POST" {
+			b, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, `{"data":{"twoway_trunks":10}}`, string(b), "body should be replayed on every attempt")
+		}
+
+		if n <= failures {
+			if retryAfter != "" {
+				w.Header().Add("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			io.WriteString(w, `<html><body><h1>Service Unavailable</h1></body></html>`)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"twoway_trunks":10},"status":"success"}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func newRetryClient(t *testing.T, srv *httptest.Server) *kazooapi.APIClient {
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+	cfg.RetryPolicy.InitialBackoff = time.Millisecond
+	cfg.RetryPolicy.MaxBackoff = 5 * time.Millisecond
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	return clt
+}
+
+func TestRetryPolicy_RetriesTransientErrors(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	srv := MockFlakyServer(t, 2, 503, "0", &hits)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	//The POST is conditional, so a replay can't be applied twice
+	limits, err := clt.LimitsAPI.UpdateLimits(kazooapi.WithRevision(ctx, "1-a"), "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Limits{TwowayTrunks: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), limits.TwowayTrunks)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func TestRetryPolicy_PostIsNotReplayed(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	srv := MockFlakyServer(t, 1, 503, "0", &hits)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	//Kazoo might have applied the body before the proxy answered 503
+	_, err := clt.LimitsAPI.UpdateLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Limits{TwowayTrunks: 10})
+
+	var apiErr *kazooapi.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, 503, apiErr.StatusCode)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestRetryPolicy_NoRetryEndpoint(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/clicktocall/c2c/connect", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(502)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	//The connect is a GET, but it places a call
+	_, err := clt.ClicktocallAPI.ExecuteClicktocall(ctx, "qe0ade400015367f0069d6dfbdca072a", "c2c", "+14155550100")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestRetryPolicy_GivesUp(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	srv := MockFlakyServer(t, 100, 502, "", &hits)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+
+	var apiErr *kazooapi.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, 502, apiErr.StatusCode)
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+}
+
+func TestRetryPolicy_NotRetryableStatus(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	srv := MockFlakyServer(t, 100, 500, "", &hits)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestRetryPolicy_RetryAfterTooLong(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	srv := MockFlakyServer(t, 100, 503, "3600", &hits)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestRetryPolicy_ContextCancel(t *testing.T) {
+	var hits int32
+	srv := MockFlakyServer(t, 100, 503, "30", &hits)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestRetryPolicy_PutIsNotReplayed(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/callflows", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(504)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	clt := newRetryClient(t, srv)
+
+	_, err := clt.CallflowsAPI.CreateCallflow(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Callflow{Numbers: []string{"100"}})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,170 @@
+package kazooapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+//DefaultConflictAttempts is the number of get-modify-save rounds made by
+//Modify* helpers before the conflict is returned to the caller
+const DefaultConflictAttempts = 3
+
+//ErrNoRevision is returned by Modify* helpers for documents fetched without a revision,
+//saving them unconditionally might overwrite someone else's changes
+var ErrNoRevision = NewError("NoRevision", "document has no revision", nil)
+
+type revisionKey struct{}
+
+//WithRevision returns a context which makes a service call send the
+//document revision in If-Match header. Kazoo refuses to save the document
+//if it has been changed since that revision, the call returns an error
+//matching ErrConflict in this case.
+//The revision might be obtained with WithResponseMeta
+func WithRevision(ctx context.Context, revision string) context.Context {
+	return context.WithValue(ctx, revisionKey{}, revision)
+}
+
+func revisionFromContext(ctx context.Context) string {
+	rev, _ := ctx.Value(revisionKey{}).(string)
+	return rev
+}
+
+//conditional reports whether a request might carry If-Match. Kazoo checks revisions only when account
+//documents are saved or deleted, so GETs and logins made with the same context must not send it:
+//Kazoo would refuse them with 412 for nothing. Bulk collection requests touch many documents at once
+func conditional(method, urlPath string) bool {
+	switch method {
+	case "POST", "PATCH", "DELETE":
+	default:
+		return false
+	}
+
+	return strings.Contains(urlPath, "/accounts/") && !strings.HasSuffix(urlPath, "/collection")
+}
+
+//RetryOnConflict runs fn until it succeeds, returns an error which doesn't
+//match ErrConflict or makes the given number of attempts.
+//fn is expected to refetch the document, so every attempt works on fresh data
+func RetryOnConflict(ctx context.Context, attempts int, fn func(ctx context.Context) error) (err error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for i := 0; i < attempts; i++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		err = fn(ctx)
+		if err == nil || !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+
+	return err
+}
+
+//documentRevision returns the revision a fetched document is saved with: the envelope's one or the ETag
+//if crossbar didn't send it. Without either the save would silently skip the revision check
+func documentRevision(meta ResponseMeta) (string, error) {
+	if meta.Revision != "" {
+		return meta.Revision, nil
+	}
+
+	if etag := strings.Trim(strings.TrimPrefix(meta.ETag, "W/"), `"`); etag != "" {
+		return etag, nil
+	}
+
+	return "", ErrNoRevision
+}
+
+//modifyDocument is the get-modify-save round of Modify* helpers. The document is fetched raw and only
+//the changes mutate makes to its typed view T are merged into it, so fields T doesn't model survive the save.
+//The document is saved only if it hasn't been changed since it was fetched, on conflict the whole
+//round is repeated with the fresh document up to DefaultConflictAttempts times
+func modifyDocument[T any](ctx context.Context, c *APIClient, service, operation, docPath string, mutate func(v *T) error) (saved *T, err error) {
+	err = RetryOnConflict(ctx, DefaultConflictAttempts, func(ctx context.Context) error {
+		var meta ResponseMeta
+
+		doc, _, err := do[map[string]interface{}](WithResponseMeta(ctx, &meta), c, endpoint{
+			service:   service,
+			operation: operation,
+			method:    "GET",
+			path:      docPath,
+		})
+		if err != nil {
+			return err
+		}
+
+		rev, err := documentRevision(meta)
+		if err != nil {
+			return err
+		}
+
+		if doc == nil {
+			doc = make(map[string]interface{})
+		}
+
+		current := new(T)
+		if err := convertDocument(doc, current); err != nil {
+			return err
+		}
+
+		var before map[string]interface{}
+		if err := convertDocument(current, &before); err != nil {
+			return err
+		}
+
+		if err := mutate(current); err != nil {
+			return err
+		}
+
+		var after map[string]interface{}
+		if err := convertDocument(current, &after); err != nil {
+			return err
+		}
+
+		for key := range before {
+			if _, ok := after[key]; !ok {
+				delete(doc, key)
+			}
+		}
+		for key, value := range after {
+			if !reflect.DeepEqual(before[key], value) {
+				doc[key] = value
+			}
+		}
+
+		saved, _, err = do[*T](WithRevision(ctx, rev), c, endpoint{
+			service:   service,
+			operation: operation,
+			method:    "POST",
+			path:      docPath,
+			body:      doc,
+		})
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+//convertDocument converts a document between its raw and typed forms
+func convertDocument(from, to interface{}) error {
+	raw, err := json.Marshal(from)
+	if err != nil {
+		return reportError("can't encode document: %v", err)
+	}
+
+	if err := json.Unmarshal(raw, to); err != nil {
+		return reportError("can't decode document: %v", err)
+	}
+
+	return nil
+}
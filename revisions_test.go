@@ -0,0 +1,248 @@
+package kazooapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+//MockRevisionServer serves limits of a single account as a versioned document.
+//Every successful save bumps the revision, saving with a stale If-Match gets 409.
+//bump is called before every save and might change the document behind the client's back
+func MockRevisionServer(t *testing.T, bump func(limits map[string]interface{}) bool) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var ifMatch []string
+
+	rev := 1
+	limits := map[string]interface{}{"twoway_trunks": 10, "allow_postpay": true, "max_postpay_amount": 50}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == "POST" {
+			ifMatch = append(ifMatch, r.Header.Get("If-Match"))
+
+			if bump != nil && bump(limits) {
+				rev++
+			}
+
+			if r.Header.Get("If-Match") != fmt.Sprintf("%d-rev", rev) {
+				w.WriteHeader(409)
+				io.WriteString(w, `{"data":{"message":"conflicting documents"},"error":"409","message":"datastore_conflict","status":"error","request_id":"b0cd2a3e1f4c2d9e8f7a6b5c4d3e2f1a"}`)
+				return
+			}
+
+			body, _ := ioutil.ReadAll(r.Body)
+			var env struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(body, &env))
+			limits = env.Data
+			rev++
+		}
+
+		data, _ := json.Marshal(limits)
+		w.Header().Add("Etag", fmt.Sprintf("%d-rev", rev))
+		fmt.Fprintf(w, `{"data":%s,"revision":"%d-rev","status":"success"}`, data, rev)
+	})
+
+	return httptest.NewServer(mux), &ifMatch
+}
+
+func TestLimitsAPIService_ModifyLimits(t *testing.T) {
+	ctx := context.Background()
+
+	srv, ifMatch := MockRevisionServer(t, nil)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	limits, err := clt.LimitsAPI.ModifyLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", func(l *kazooapi.Limits) error {
+		l.TwowayTrunks = 20
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), limits.TwowayTrunks)
+	assert.True(t, limits.AllowPostpay, "fields not touched by mutate should be preserved")
+	assert.Equal(t, float64(50), limits.MaxPostpayAmount)
+	assert.Equal(t, []string{"1-rev"}, *ifMatch)
+}
+
+func TestLimitsAPIService_ModifyLimitsConflict(t *testing.T) {
+	ctx := context.Background()
+
+	//Another admin changes inbound trunks right before our first save
+	changed := false
+	srv, ifMatch := MockRevisionServer(t, func(l map[string]interface{}) bool {
+		if changed {
+			return false
+		}
+		changed = true
+		l["inbound_trunks"] = 5
+		return true
+	})
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	calls := 0
+	limits, err := clt.LimitsAPI.ModifyLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", func(l *kazooapi.Limits) error {
+		calls++
+		l.TwowayTrunks = 20
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls, "mutation should be re-applied to the refetched document")
+	assert.Equal(t, int64(20), limits.TwowayTrunks)
+	assert.Equal(t, int64(5), limits.InboundTrunks, "concurrent change should survive")
+	assert.Equal(t, []string{"1-rev", "2-rev"}, *ifMatch)
+}
+
+func TestLimitsAPIService_ModifyLimitsGivesUp(t *testing.T) {
+	ctx := context.Background()
+
+	srv, ifMatch := MockRevisionServer(t, func(l map[string]interface{}) bool { return true })
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	_, err := clt.LimitsAPI.ModifyLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", func(l *kazooapi.Limits) error {
+		l.TwowayTrunks = 20
+		return nil
+	})
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, kazooapi.ErrConflict))
+	assert.Len(t, *ifMatch, kazooapi.DefaultConflictAttempts)
+
+	var apiErr *kazooapi.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, 409, apiErr.StatusCode)
+	}
+
+	//Mutation errors are returned as is without saving
+	errAbort := errors.New("abort")
+	_, err = clt.LimitsAPI.ModifyLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", func(l *kazooapi.Limits) error {
+		return errAbort
+	})
+	assert.Equal(t, errAbort, err)
+	assert.Len(t, *ifMatch, kazooapi.DefaultConflictAttempts)
+}
+
+func TestWithRevision_OnlySaves(t *testing.T) {
+	ctx := kazooapi.WithRevision(context.Background(), "1-rev")
+
+	var (
+		mu      sync.Mutex
+		ifMatch = make(map[string]string)
+	)
+	record := func(r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		ifMatch[r.Method+" "+r.URL.Path] = r.Header.Get("If-Match")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		io.WriteString(w, `{"data":{"twoway_trunks":10},"revision":"1-rev","status":"success"}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	//The client logs in with the revision's context, neither the login nor the GET are conditional
+	_, err := clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	_, err = clt.LimitsAPI.UpdateLimits(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Limits{TwowayTrunks: 20})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"PUT /v2/api_auth": "",
+		"GET /v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits":  "",
+		"POST /v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits": "1-rev",
+	}, ifMatch)
+}
+
+func TestClicktocallAPIService_ModifyClicktocall(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		etag  = `"3-8f14e45fceea167a5a36dedd4bea2543"`
+		saved map[string]interface{}
+		seen  []string
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/clicktocall/c2c", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			seen = append(seen, r.Header.Get("If-Match"))
+
+			var env struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(body, &env))
+			saved = env.Data
+		}
+
+		//Crossbar behind some proxies sends the revision in Etag only
+		if etag != "" {
+			w.Header().Add("Etag", etag)
+		}
+		io.WriteString(w, `{"data":{"id":"c2c","name":"Sales","extension":"100","whitelist":["1000"],
+			"caller_id_number":"2000","dial_first":"contact","custom_field":{"team":"emea"}},"status":"success"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	_, err := clt.ClicktocallAPI.ModifyClicktocall(ctx, "qe0ade400015367f0069d6dfbdca072a", "c2c", func(c2c *kazooapi.Clicktocall) error {
+		c2c.Name = "Support"
+		c2c.Whitelist = nil
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3-8f14e45fceea167a5a36dedd4bea2543"}, seen)
+
+	//Fields the struct doesn't model are saved back as they were, cleared ones are removed
+	assert.Equal(t, map[string]interface{}{
+		"id":               "c2c",
+		"name":             "Support",
+		"extension":        "100",
+		"caller_id_number": "2000",
+		"dial_first":       "contact",
+		"custom_field":     map[string]interface{}{"team": "emea"},
+	}, saved)
+
+	//Without a revision the document isn't saved at all
+	etag = ""
+	_, err = clt.ClicktocallAPI.ModifyClicktocall(ctx, "qe0ade400015367f0069d6dfbdca072a", "c2c", func(c2c *kazooapi.Clicktocall) error {
+		c2c.Name = "Support"
+		return nil
+	})
+	assert.True(t, errors.Is(err, kazooapi.ErrNoRevision))
+	assert.Len(t, seen, 1)
+}
@@ -2,8 +2,6 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 )
 
 type StorageAPIService service
@@ -47,6 +45,13 @@ type (
 		Attachments Attachments `json:"attachments"`
 	}
 
+	//StoragePlan is a named plan which might be referenced by sub-accounts
+	StoragePlan struct {
+		ID   string `json:"id,omitempty"`
+		Name string `json:"name,omitempty"`
+		Plan
+	}
+
 	AttachmentAWS struct {
 		Bucket             string      `json:"bucket"`               //required
 		BucketAccessMethod string      `json:"bucket_access_method"` //required (auto vhost path)
@@ -62,145 +67,192 @@ type (
 )
 
 func (api *StorageAPIService) GetStorage(ctx context.Context, acc string) (stor *Storage, err error) {
-	var response struct {
-		Data Storage `json:"data"`
-		ResponseEnvelope
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
+	stor, _, err = do[*Storage](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "GetStorage",
+		method:    "GET",
+		path:      path("accounts", acc, "storage"),
+	})
+
+	return stor, err
+}
+
+func (api *StorageAPIService) CreateStorage(ctx context.Context, acc string, input *Storage) (stor *Storage, err error) {
+	stor, _, err = do[*Storage](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "CreateStorage",
+		method:    "PUT",
+		path:      path("accounts", acc, "storage"),
+		body:      input,
+	})
+
+	return stor, err
+}
+
+func (api *StorageAPIService) DeleteStorage(ctx context.Context, acc string) (stor *Storage, err error) {
 	if acc == "" {
 		return nil, reportError("account id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/storage",
-	}
+	stor, _, err = do[*Storage](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "DeleteStorage",
+		method:    "DELETE",
+		path:      path("accounts", acc, "storage"),
+	})
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+	return stor, err
+}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+//UpdateStorage replaces the storage document of the account with input
+func (api *StorageAPIService) UpdateStorage(ctx context.Context, acc string, input *Storage) (stor *Storage, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
-	}
+	stor, _, err = do[*Storage](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "UpdateStorage",
+		method:    "POST",
+		path:      path("accounts", acc, "storage"),
+		body:      input,
+	})
 
-	decoder := json.NewDecoder(resp.Body)
+	return stor, err
+}
 
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+//PatchStorage merges input into the storage document of the account leaving other fields untouched
+func (api *StorageAPIService) PatchStorage(ctx context.Context, acc string, input map[string]interface{}) (stor *Storage, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	stor = &response.Data
+	stor, _, err = do[*Storage](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "PatchStorage",
+		method:    "PATCH",
+		path:      path("accounts", acc, "storage"),
+		body:      input,
+	})
 
-	return stor, nil
+	return stor, err
 }
 
-func (api *StorageAPIService) CreateStorage(ctx context.Context, acc string, input *Storage) (stor *Storage, err error) {
-	var response struct {
-		Data Storage `json:"data"`
-		ResponseEnvelope
+//ListStoragePlans returns storage plans defined by the account
+func (api *StorageAPIService) ListStoragePlans(ctx context.Context, acc string) (plans []StoragePlan, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/storage",
-	}
+	plans, _, err = do[[]StoragePlan](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "ListStoragePlans",
+		method:    "GET",
+		path:      path("accounts", acc, "storage", "plans"),
+	})
 
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
+	return plans, err
+}
 
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
+//CreateStoragePlan adds a storage plan to the account
+func (api *StorageAPIService) CreateStoragePlan(ctx context.Context, acc string, input *StoragePlan) (plan *StoragePlan, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
-	}
+	plan, _, err = do[*StoragePlan](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "CreateStoragePlan",
+		method:    "PUT",
+		path:      path("accounts", acc, "storage", "plans"),
+		body:      input,
+	})
 
-	params.PostBody = body
+	return plan, err
+}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("can't prepare a request %s", err)
+//GetStoragePlan returns the storage plan document
+func (api *StorageAPIService) GetStoragePlan(ctx context.Context, acc, id string) (plan *StoragePlan, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+	if id == "" {
+		return nil, reportError("storage plan id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		//bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("error status code: %d", resp.StatusCode)
-	}
+	plan, _, err = do[*StoragePlan](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "GetStoragePlan",
+		method:    "GET",
+		path:      path("accounts", acc, "storage", "plans", id),
+	})
 
-	decoder := json.NewDecoder(resp.Body)
+	return plan, err
+}
 
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+//UpdateStoragePlan replaces the storage plan document with input
+func (api *StorageAPIService) UpdateStoragePlan(ctx context.Context, acc, id string, input *StoragePlan) (plan *StoragePlan, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	stor = &response.Data
+	if id == "" {
+		return nil, reportError("storage plan id is required field")
+	}
 
-	return stor, nil
-}
+	plan, _, err = do[*StoragePlan](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "UpdateStoragePlan",
+		method:    "POST",
+		path:      path("accounts", acc, "storage", "plans", id),
+		body:      input,
+	})
 
-func (api *StorageAPIService) DeleteStorage(ctx context.Context, acc string) (stor *Storage, err error) {
-	var response struct {
-		Data Storage `json:"data"`
-		ResponseEnvelope
-	}
+	return plan, err
+}
 
+//PatchStoragePlan merges input into the storage plan document leaving other fields untouched
+func (api *StorageAPIService) PatchStoragePlan(ctx context.Context, acc, id string, input map[string]interface{}) (plan *StoragePlan, err error) {
 	if acc == "" {
 		return nil, reportError("account id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "DELETE",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/storage",
+	if id == "" {
+		return nil, reportError("storage plan id is required field")
 	}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+	plan, _, err = do[*StoragePlan](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "PatchStoragePlan",
+		method:    "PATCH",
+		path:      path("accounts", acc, "storage", "plans", id),
+		body:      input,
+	})
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return plan, err
+}
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+//DeleteStoragePlan removes the storage plan and returns the deleted document
+func (api *StorageAPIService) DeleteStoragePlan(ctx context.Context, acc, id string) (plan *StoragePlan, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if id == "" {
+		return nil, reportError("storage plan id is required field")
 	}
 
-	stor = &response.Data
+	plan, _, err = do[*StoragePlan](ctx, api.client, endpoint{
+		service:   "StorageAPI",
+		operation: "DeleteStoragePlan",
+		method:    "DELETE",
+		path:      path("accounts", acc, "storage", "plans", id),
+	})
 
-	return stor, nil
+	return plan, err
 }
@@ -376,3 +376,69 @@ func TestStorageAPIService_CreateStorage(t *testing.T) {
 
 	assert.Equal(t, "c1e482623df05d97074f531977866e16", resp.ID, "ID's should be equal")
 }
+
+func TestStorageAPIService_UpdatePatch(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"storage","plan":{"modb":{"types":{"call_recording":{"attachments":{"handler":"s3"}}}}}}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/storage"
+
+	stor, err := clt.StorageAPI.UpdateStorage(ctx, "qe0ade400015367f0069d6dfbdca072a", &kazooapi.Storage{ID: "storage"})
+	assert.NoError(t, err)
+	assert.Equal(t, "s3", stor.Plan.Modb.Types["call_recording"].Attachments.Handler)
+	assertRequest(t, last(), "POST", path)
+
+	_, err = clt.StorageAPI.PatchStorage(ctx, "qe0ade400015367f0069d6dfbdca072a", map[string]interface{}{"connections": map[string]interface{}{}})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", path)
+	assert.Equal(t, map[string]interface{}{"connections": map[string]interface{}{}}, last().Data)
+}
+
+func TestStorageAPIService_Plans(t *testing.T) {
+	ctx := context.Background()
+
+	listSrv, last := MockDocumentServer(t, `[{"id":"7e0e9ebd2e8b4b3d0b5f1c8a9d6e4f21","name":"S3 recordings"}]`)
+	defer listSrv.Close()
+
+	plans, err := newMockClient(t, listSrv).StorageAPI.ListStoragePlans(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "S3 recordings", plans[0].Name)
+	}
+	assertRequest(t, last(), "GET", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/storage/plans")
+
+	srv, last := MockDocumentServer(t, `{"id":"7e0e9ebd2e8b4b3d0b5f1c8a9d6e4f21","name":"S3 recordings","modb":{"types":{"call_recording":{"attachments":{"handler":"s3"}}}}}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/storage/plans/7e0e9ebd2e8b4b3d0b5f1c8a9d6e4f21"
+
+	input := &kazooapi.StoragePlan{Name: "S3 recordings"}
+	input.Modb.Types = map[string]kazooapi.TypeAttachment{"call_recording": {Attachments: kazooapi.TypeAttachmentHandler{Handler: "s3"}}}
+
+	plan, err := clt.StorageAPI.CreateStoragePlan(ctx, "qe0ade400015367f0069d6dfbdca072a", input)
+	assert.NoError(t, err)
+	assert.Equal(t, "7e0e9ebd2e8b4b3d0b5f1c8a9d6e4f21", plan.ID)
+	assert.Equal(t, "s3", plan.Modb.Types["call_recording"].Attachments.Handler)
+	assertRequest(t, last(), "PUT", "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/storage/plans")
+	assert.Equal(t, "S3 recordings", last().Data.(map[string]interface{})["name"])
+
+	_, err = clt.StorageAPI.GetStoragePlan(ctx, "qe0ade400015367f0069d6dfbdca072a", plan.ID)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "GET", path)
+
+	_, err = clt.StorageAPI.UpdateStoragePlan(ctx, "qe0ade400015367f0069d6dfbdca072a", plan.ID, plan)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "POST", path)
+
+	_, err = clt.StorageAPI.PatchStoragePlan(ctx, "qe0ade400015367f0069d6dfbdca072a", plan.ID, map[string]interface{}{"name": "Recordings"})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", path)
+
+	_, err = clt.StorageAPI.DeleteStoragePlan(ctx, "qe0ade400015367f0069d6dfbdca072a", plan.ID)
+	assert.NoError(t, err)
+	assertRequest(t, last(), "DELETE", path)
+}
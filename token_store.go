@@ -0,0 +1,192 @@
+package kazooapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+//tokenExpirySkew is subtracted from the token lifetime, so we re-authenticate
+//a bit earlier instead of sending a token which expires on the way to the server
+const tokenExpirySkew = 30 * time.Second
+
+//Token represents an auth token issued by Kazoo together with
+//the moment it stops being valid. Zero ExpiresAt means that the
+//server didn't tell us when the token expires
+type Token struct {
+	Value     string    `json:"auth_token"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+//Valid reports whether the token might be used for a request at the given moment
+func (t *Token) Valid(now time.Time) bool {
+	if t == nil || t.Value == "" {
+		return false
+	}
+	if t.ExpiresAt.IsZero() {
+		return true
+	}
+	return now.Before(t.ExpiresAt)
+}
+
+//TokenStore keeps the auth token of a single APIClient.
+//Implementations must be safe for concurrent use
+type TokenStore interface {
+	//Token returns the stored token or nil if there is no token
+	Token(ctx context.Context) (*Token, error)
+	//SetToken replaces the stored token
+	SetToken(ctx context.Context, token *Token) error
+	//ClearToken drops the stored token
+	ClearToken(ctx context.Context) error
+}
+
+//MemoryTokenStore keeps the token in memory, this is the default store of an APIClient
+type MemoryTokenStore struct {
+	mu    sync.RWMutex
+	token *Token
+}
+
+//NewMemoryTokenStore returns an empty in-memory token store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+//Token implements TokenStore interface
+func (s *MemoryTokenStore) Token(ctx context.Context) (*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.token == nil {
+		return nil, nil
+	}
+	t := *s.token
+	return &t, nil
+}
+
+//SetToken implements TokenStore interface
+func (s *MemoryTokenStore) SetToken(ctx context.Context, token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token == nil {
+		s.token = nil
+		return nil
+	}
+	t := *token
+	s.token = &t
+	return nil
+}
+
+//ClearToken implements TokenStore interface
+func (s *MemoryTokenStore) ClearToken(ctx context.Context) error {
+	return s.SetToken(ctx, nil)
+}
+
+//FileTokenStore keeps the token in a JSON file, so a session
+//might be reused between several runs of a CLI program
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+//NewFileTokenStore returns a token store backed by the file at the given path.
+//The file is created on the first SetToken call
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+//Token implements TokenStore interface
+func (s *FileTokenStore) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	t := &Token{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, NewError("TokenStoreError", "can't decode token file "+s.path, err)
+	}
+
+	return t, nil
+}
+
+//SetToken implements TokenStore interface
+func (s *FileTokenStore) SetToken(ctx context.Context, token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token == nil {
+		return s.remove()
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	//Write to a temporary file first, so a concurrent reader never sees a half-written token
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+//ClearToken implements TokenStore interface
+func (s *FileTokenStore) ClearToken(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove()
+}
+
+func (s *FileTokenStore) remove() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//tokenExpiration extracts the "exp" claim from a Kazoo auth token.
+//Kazoo issues JWT tokens, so we don't need to guess how long they live.
+//Zero time is returned if the token isn't a JWT or has no "exp" claim
+func tokenExpiration(authToken string) time.Time {
+	parts := strings.Split(authToken, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
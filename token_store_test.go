@@ -0,0 +1,148 @@
+package kazooapi_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeAuthToken(name string, exp time.Time) string {
+	claims := fmt.Sprintf(`{"iss":"kazoo","account_id":"%s","exp":%d}`, name, exp.Unix())
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2lnbmF0dXJl"
+}
+
+func MockAuthServer(t *testing.T, authToken string, authCount *int32) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(authCount, 1)
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"`+authToken+`"}`)
+	})
+
+	mux.HandleFunc("/v2/accounts/qe0ade400015367f0069d6dfbdca072a/limits", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != authToken {
+			w.WriteHeader(401)
+			io.WriteString(w, `{"data":{},"error":"401","message":"invalid_credentials","status":"error"}`)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"twoway_trunks":10},"status":"success"}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestAPIClient_TokenPerClient(t *testing.T) {
+	ctx := context.Background()
+
+	var stagingAuths, productionAuths int32
+	stagingToken := fakeAuthToken("staging", time.Now().Add(time.Hour))
+	productionToken := fakeAuthToken("production", time.Now().Add(time.Hour))
+
+	staging := MockAuthServer(t, stagingToken, &stagingAuths)
+	defer staging.Close()
+	production := MockAuthServer(t, productionToken, &productionAuths)
+	defer production.Close()
+
+	newClient := func(srv *httptest.Server) *kazooapi.APIClient {
+		cfg := kazooapi.NewConfiguration()
+		cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+		cfg.BasePath = srv.URL + "/v2"
+		cfg.HTTPClient = srv.Client()
+
+		clt, err := kazooapi.NewAPIClient(cfg)
+		assert.NoError(t, err)
+		return clt
+	}
+
+	stagingClt := newClient(staging)
+	productionClt := newClient(production)
+
+	for i := 0; i < 3; i++ {
+		_, err := stagingClt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+		assert.NoError(t, err)
+		_, err = productionClt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&stagingAuths), "staging client should authenticate once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&productionAuths), "production client should authenticate once")
+
+	tok, err := stagingClt.Token(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, stagingToken, tok.Value)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), tok.ExpiresAt, 2*time.Second)
+}
+
+func TestAPIClient_ExpiredTokenIsRenewed(t *testing.T) {
+	ctx := context.Background()
+
+	var auths int32
+	authToken := fakeAuthToken("test", time.Now().Add(time.Hour))
+
+	srv := MockAuthServer(t, authToken, &auths)
+	defer srv.Close()
+
+	store := kazooapi.NewMemoryTokenStore()
+	store.SetToken(ctx, &kazooapi.Token{Value: "expired", ExpiresAt: time.Now().Add(-time.Minute)})
+
+	cfg := kazooapi.NewConfiguration()
+	cfg.APIKey = "e0a582bad3fb7fe3897ebf70cc0f542bbdc9a17895764266f094b953254d3d84"
+	cfg.BasePath = srv.URL + "/v2"
+	cfg.HTTPClient = srv.Client()
+	cfg.TokenStore = store
+
+	clt, err := kazooapi.NewAPIClient(cfg)
+	assert.NoError(t, err)
+
+	_, err = clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&auths))
+
+	//A token revoked by the server is renewed on 401
+	store.SetToken(ctx, &kazooapi.Token{Value: "revoked"})
+
+	_, err = clt.LimitsAPI.GetLimits(ctx, "qe0ade400015367f0069d6dfbdca072a")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&auths))
+}
+
+func TestFileTokenStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "session", "token.json")
+
+	store := kazooapi.NewFileTokenStore(path)
+
+	tok, err := store.Token(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, tok)
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	assert.NoError(t, store.SetToken(ctx, &kazooapi.Token{Value: "abc", ExpiresAt: expires}))
+
+	//Another store pointed at the same file picks up the session
+	tok, err = kazooapi.NewFileTokenStore(path).Token(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", tok.Value)
+	assert.True(t, expires.Equal(tok.ExpiresAt))
+
+	assert.NoError(t, store.ClearToken(ctx))
+
+	tok, err = store.Token(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, tok)
+}
@@ -2,36 +2,30 @@ package kazooapi
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 )
 
 type UsersAPIService service
 
 type (
 	User struct {
-		ID         string   `json:"id,omitempty"`
-		Username   string   `json:"username,omitempty"`
-		Password   string   `json:"password,omitempty"`
-		FirstName  string   `json:"first_name,omitempty"`
-		LastName   string   `json:"last_name,omitempty"`
-		Title      string   `json:"title,omitempty"`
-		Email      string   `json:"email,omitempty"`
-		PresenceID string   `json:"presence_id,omitempty"`
-		Enabled    bool     `json:"enabled,omitempty"`
-		Features   []string `json:"features,omitempty"`
-		Verified   bool     `json:"verified,omitempty"`
-		PrivLevel  string   `json:"priv_level,omitempty"`
-		Timezone   string   `json:"timezone,omitempty"`
+		ID         string    `json:"id,omitempty"`
+		Username   string    `json:"username,omitempty"`
+		Password   string    `json:"password,omitempty"`
+		FirstName  string    `json:"first_name,omitempty"`
+		LastName   string    `json:"last_name,omitempty"`
+		Title      string    `json:"title,omitempty"`
+		Email      string    `json:"email,omitempty"`
+		PresenceID string    `json:"presence_id,omitempty"`
+		Enabled    bool      `json:"enabled,omitempty"`
+		Features   []string  `json:"features,omitempty"`
+		Verified   bool      `json:"verified,omitempty"`
+		PrivLevel  string    `json:"priv_level,omitempty"`
+		Timezone   string    `json:"timezone,omitempty"`
+		Metaflows  *Metaflow `json:"metaflows,omitempty"`
 	}
 )
 
 func (api *UsersAPIService) CreateUser(ctx context.Context, acc string, input *User) (usr *User, err error) {
-	var response struct {
-		Data User `json:"data"`
-		ResponseEnvelope
-	}
-
 	if acc == "" {
 		return nil, reportError("account id is required field")
 	}
@@ -44,140 +38,117 @@ func (api *UsersAPIService) CreateUser(ctx context.Context, acc string, input *U
 		return nil, reportError("last name is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "PUT",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/users",
-	}
+	usr, _, err = do[*User](ctx, api.client, endpoint{
+		service:   "UsersAPI",
+		operation: "CreateUser",
+		method:    "PUT",
+		path:      path("accounts", acc, "users"),
+		body:      input,
+	})
 
-	reqBody := RequestEnvelope{
-		Data: input,
-	}
+	return usr, err
+}
 
-	jsonString, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, reportError("can't marshall body for request")
+func (api *UsersAPIService) DeleteUser(ctx context.Context, acc, id string) (usr *User, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	body, err := setBody(jsonString, "json")
-	if err != nil {
-		return nil, reportError("can't prepare body for the request")
-	}
+	usr, _, err = do[*User](ctx, api.client, endpoint{
+		service:   "UsersAPI",
+		operation: "DeleteUser",
+		method:    "DELETE",
+		path:      path("accounts", acc, "users", id),
+	})
 
-	params.PostBody = body
+	return usr, err
+}
 
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
+func (api *UsersAPIService) ListUsers(ctx context.Context, acc string, disablePagination bool) (users []User, err error) {
+	users, _, err = do[[]User](ctx, api.client, endpoint{
+		service:   "UsersAPI",
+		operation: "ListUsers",
+		method:    "GET",
+		path:      path("accounts", acc, "users"),
+		query:     listQuery(disablePagination),
+	})
+
+	return users, err
+}
 
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+//ListUsersPaginator returns a paginator over users of the account,
+//zero pageSize means the server's default page size
+func (api *UsersAPIService) ListUsersPaginator(acc string, pageSize int64) *Paginator[User] {
+	return listPaginator[User](api.client, "UsersAPI", "ListUsersPaginator", path("accounts", acc, "users"), pageSize)
+}
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+//GetUser returns the user document
+func (api *UsersAPIService) GetUser(ctx context.Context, acc, id string) (usr *User, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if id == "" {
+		return nil, reportError("user id is required field")
 	}
 
-	usr = &response.Data
+	usr, _, err = do[*User](ctx, api.client, endpoint{
+		service:   "UsersAPI",
+		operation: "GetUser",
+		method:    "GET",
+		path:      path("accounts", acc, "users", id),
+	})
 
-	return usr, nil
+	return usr, err
 }
 
-func (api *UsersAPIService) DeleteUser(ctx context.Context, acc, id string) (usr *User, err error) {
-	var response struct {
-		Data User `json:"data"`
-		ResponseEnvelope
-	}
-
+//UpdateUser replaces the user document with input,
+//fields missing in input are removed from the document
+func (api *UsersAPIService) UpdateUser(ctx context.Context, acc, id string, input *User) (usr *User, err error) {
 	if acc == "" {
 		return nil, reportError("account id is required field")
 	}
 
-	params := Request{
-		CTX:    ctx,
-		Method: "DELETE",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/users/" + id,
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
+	if id == "" {
+		return nil, reportError("user id is required field")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+	if input.FirstName == "" {
+		return nil, reportError("first name is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if input.LastName == "" {
+		return nil, reportError("last name is required field")
 	}
 
-	usr = &response.Data
+	usr, _, err = do[*User](ctx, api.client, endpoint{
+		service:   "UsersAPI",
+		operation: "UpdateUser",
+		method:    "POST",
+		path:      path("accounts", acc, "users", id),
+		body:      input,
+	})
 
-	return usr, nil
+	return usr, err
 }
 
-func (api *UsersAPIService) ListUsers(ctx context.Context, acc string, disablePagination bool) (users []User, err error) {
-	var response struct {
-		Data []User `json:"data"`
-		ResponseEnvelope
-	}
-
-	params := Request{
-		CTX:    ctx,
-		Method: "GET",
-		Path:   api.client.cfg.BasePath + "/accounts/" + acc + "/users",
-	}
-
-	if disablePagination {
-		params.QueryParams = map[string][]string{"paginate": []string{"false"}}
-	}
-
-	req, err := api.client.prepareRequest(&params)
-	if err != nil {
-		return nil, reportError("Can't prepare a request %s", err)
-	}
-
-	resp, err := api.client.callAPI(ctx, req)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 300 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, reportError("Status: %v, Body: %s", resp.Status, bodyBytes)
+//PatchUser merges input into the user document leaving other fields untouched
+func (api *UsersAPIService) PatchUser(ctx context.Context, acc, id string, input map[string]interface{}) (usr *User, err error) {
+	if acc == "" {
+		return nil, reportError("account id is required field")
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-
-	decErr := decoder.Decode(&response)
-	if decErr != nil {
-		return nil, reportError("Can't decode response: %v", decErr)
+	if id == "" {
+		return nil, reportError("user id is required field")
 	}
 
-	users = response.Data
+	usr, _, err = do[*User](ctx, api.client, endpoint{
+		service:   "UsersAPI",
+		operation: "PatchUser",
+		method:    "PATCH",
+		path:      path("accounts", acc, "users", id),
+		body:      input,
+	})
 
-	return users, nil
+	return usr, err
 }
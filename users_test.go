@@ -0,0 +1,44 @@
+package kazooapi_test
+
+import (
+	"context"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsersAPIService_CRUD(t *testing.T) {
+	ctx := context.Background()
+
+	srv, last := MockDocumentServer(t, `{"id":"4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4","first_name":"John","last_name":"Doe","priv_level":"admin"}`)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	const path = "/v2/accounts/qe0ade400015367f0069d6dfbdca072a/users/4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4"
+
+	usr, err := clt.UsersAPI.GetUser(ctx, "qe0ade400015367f0069d6dfbdca072a", "4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", usr.PrivLevel)
+	assertRequest(t, last(), "GET", path)
+
+	_, err = clt.UsersAPI.UpdateUser(ctx, "qe0ade400015367f0069d6dfbdca072a", "4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4", &kazooapi.User{FirstName: "John", LastName: "Doe", PrivLevel: "admin"})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "POST", path)
+	assert.Equal(t, map[string]interface{}{"first_name": "John", "last_name": "Doe", "priv_level": "admin"}, last().Data)
+
+	_, err = clt.UsersAPI.PatchUser(ctx, "qe0ade400015367f0069d6dfbdca072a", "4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4", map[string]interface{}{"priv_level": "admin"})
+	assert.NoError(t, err)
+	assertRequest(t, last(), "PATCH", path)
+	assert.Equal(t, map[string]interface{}{"priv_level": "admin"}, last().Data)
+
+	_, err = clt.UsersAPI.DeleteUser(ctx, "qe0ade400015367f0069d6dfbdca072a", "4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4")
+	assert.NoError(t, err)
+	assertRequest(t, last(), "DELETE", path)
+
+	_, err = clt.UsersAPI.UpdateUser(ctx, "qe0ade400015367f0069d6dfbdca072a", "4d5f9e6ab6c38a5b0b0d69b0fd0ca8b4", &kazooapi.User{FirstName: "John"})
+	assert.EqualError(t, err, "last name is required field")
+
+	_, err = clt.UsersAPI.GetUser(ctx, "qe0ade400015367f0069d6dfbdca072a", "")
+	assert.EqualError(t, err, "user id is required field")
+}
@@ -0,0 +1,219 @@
+package kazooapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//WalkOrder is the order Walk visits accounts in
+type WalkOrder int
+
+const (
+	//DepthFirst visits the whole subtree of an account before its siblings
+	DepthFirst WalkOrder = iota
+	//BreadthFirst visits all accounts of a level before going deeper
+	BreadthFirst
+)
+
+//defaultWalkWorkers is the number of visitors Walk runs at once if WalkOptions doesn't say otherwise
+const defaultWalkWorkers = 4
+
+//SkipSubtree might be returned by WalkFunc (or FlowVisitFunc) to make the walk skip
+//descendants of the account (or the flow node). It isn't reported as an error
+var SkipSubtree = errors.New("skip this subtree")
+
+//WalkFunc is called by Walk for every account of the tree.
+//Descendants of an account are visited only after the function returns for the account itself
+type WalkFunc func(ctx context.Context, acc Descendant) error
+
+//WalkOptions controls Walk, zero value means depth-first order with default number of workers
+type WalkOptions struct {
+	Order WalkOrder
+	//Workers is the number of visitors running at once, the order is strict only with a single worker
+	Workers int
+}
+
+//WalkError collects errors returned by WalkFunc, an error of a single account
+//doesn't stop the walk
+type WalkError struct {
+	//Errors are keyed by account id
+	Errors map[string]error
+	//Err is the reason the walk was stopped early (e.g. context cancellation), if any
+	Err error
+}
+
+func (e *WalkError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	lines := make([]string, 0, len(ids)+1)
+	if e.Err != nil {
+		lines = append(lines, "walk stopped: "+e.Err.Error())
+	}
+	for _, id := range ids {
+		lines = append(lines, fmt.Sprintf("%s: %v", id, e.Errors[id]))
+	}
+
+	return fmt.Sprintf("walk failed for %d account(s)\n\t%s", len(e.Errors), strings.Join(lines, "\n\t"))
+}
+
+//Unwrap lets errors.Is and errors.As look into errors of every account
+func (e *WalkError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors)+1)
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+type walkNode struct {
+	acc      Descendant
+	children []*walkNode
+}
+
+type walkResult struct {
+	node *walkNode
+	err  error
+}
+
+//Walk visits the account and all of its descendants. The hierarchy is built
+//from Descendant.Tree of a single descendants listing, then fn is run for every
+//account by a pool of opts.Workers goroutines.
+//Errors returned by fn are collected into *WalkError, the walk stops early only
+//when ctx is done
+func (api *AccountsAPIService) Walk(ctx context.Context, acc string, opts *WalkOptions, fn WalkFunc) error {
+	if acc == "" {
+		return reportError("account id is required field")
+	}
+
+	if opts == nil {
+		opts = &WalkOptions{}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultWalkWorkers
+	}
+
+	root, err := api.walkTree(ctx, acc)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ready    = []*walkNode{root}
+		done     = make(chan walkResult)
+		inflight int
+		errs     = make(map[string]error)
+	)
+
+	for len(ready) > 0 || inflight > 0 {
+		for inflight < workers && len(ready) > 0 && ctx.Err() == nil {
+			var n *walkNode
+			if opts.Order == BreadthFirst {
+				n, ready = ready[0], ready[1:]
+			} else {
+				n, ready = ready[len(ready)-1], ready[:len(ready)-1]
+			}
+
+			inflight++
+			go func(n *walkNode) {
+				done <- walkResult{node: n, err: fn(ctx, n.acc)}
+			}(n)
+		}
+
+		if inflight == 0 {
+			break
+		}
+
+		r := <-done
+		inflight--
+
+		if errors.Is(r.err, SkipSubtree) {
+			continue
+		}
+		if r.err != nil {
+			errs[r.node.acc.ID] = r.err
+		}
+
+		if opts.Order == BreadthFirst {
+			ready = append(ready, r.node.children...)
+		} else {
+			//Pushed in reverse, so the first child is popped first
+			for i := len(r.node.children) - 1; i >= 0; i-- {
+				ready = append(ready, r.node.children[i])
+			}
+		}
+	}
+
+	if ctx.Err() == nil && len(errs) == 0 {
+		return nil
+	}
+
+	if len(errs) == 0 {
+		return ctx.Err()
+	}
+
+	return &WalkError{Errors: errs, Err: ctx.Err()}
+}
+
+//walkTree fetches the account with its descendants and links them by Descendant.Tree
+func (api *AccountsAPIService) walkTree(ctx context.Context, acc string) (*walkNode, error) {
+	account, err := api.GetAccount(ctx, acc)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &walkNode{acc: Descendant{ID: acc, Name: account.Name, Realm: account.Realm}}
+	nodes := map[string]*walkNode{acc: root}
+
+	descendants, err := api.ListDescendantsPaginator(acc, 0).Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range descendants {
+		nodes[d.ID] = &walkNode{acc: d}
+	}
+
+	for _, d := range descendants {
+		parent := root
+		if len(d.Tree) > 0 {
+			//The tree might be inconsistent with the listing, such accounts are hung on the root
+			if p, ok := nodes[d.Tree[len(d.Tree)-1]]; ok && p != nodes[d.ID] {
+				parent = p
+			}
+		}
+		parent.children = append(parent.children, nodes[d.ID])
+
+		if root.acc.Tree == nil {
+			for i, id := range d.Tree {
+				if id == acc {
+					root.acc.Tree = d.Tree[:i:i]
+					break
+				}
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		sort.Slice(n.children, func(i, j int) bool {
+			a, b := n.children[i].acc, n.children[j].acc
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.ID < b.ID
+		})
+	}
+
+	return root, nil
+}
@@ -0,0 +1,179 @@
+package kazooapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	kazooapi "github.com/sashker/kazoo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+//MockResellerServer serves a reseller account with the tree:
+//
+//	reseller -> a -> a1
+//	         -> b -> b1, b2
+func MockResellerServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/api_auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		io.WriteString(w, `{"data":{},"status":"success","auth_token":"token"}`)
+	})
+	mux.HandleFunc("/v2/accounts/5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"id":"5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00","name":"reseller","realm":"reseller.pbx.example.com"},"status":"success"}`)
+	})
+	mux.HandleFunc("/v2/accounts/5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00/descendants", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start_key") == "" {
+			io.WriteString(w, `{"data":[
+				{"id":"b0000000000000000000000000000000","name":"b","tree":["fe0ade400015367f0069d6dfbdca072a","5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00"]},
+				{"id":"b2000000000000000000000000000000","name":"b2","tree":["fe0ade400015367f0069d6dfbdca072a","5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00","b0000000000000000000000000000000"]},
+				{"id":"a1000000000000000000000000000000","name":"a1","tree":["fe0ade400015367f0069d6dfbdca072a","5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00","a0000000000000000000000000000000"]}
+			],"next_start_key":"g2","status":"success"}`)
+			return
+		}
+		io.WriteString(w, `{"data":[
+			{"id":"b1000000000000000000000000000000","name":"b1","tree":["fe0ade400015367f0069d6dfbdca072a","5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00","b0000000000000000000000000000000"]},
+			{"id":"a0000000000000000000000000000000","name":"a","tree":["fe0ade400015367f0069d6dfbdca072a","5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00"]}
+		],"status":"success"}`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func walkNames(t *testing.T, clt *kazooapi.APIClient, opts *kazooapi.WalkOptions, fn kazooapi.WalkFunc) ([]string, error) {
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+
+	err := clt.AccountsAPI.Walk(context.Background(), "5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00", opts, func(ctx context.Context, acc kazooapi.Descendant) error {
+		mu.Lock()
+		names = append(names, acc.Name)
+		mu.Unlock()
+
+		if fn != nil {
+			return fn(ctx, acc)
+		}
+		return nil
+	})
+
+	return names, err
+}
+
+func TestAccountsAPIService_WalkOrder(t *testing.T) {
+	srv := MockResellerServer(t)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	names, err := walkNames(t, clt, &kazooapi.WalkOptions{Order: kazooapi.DepthFirst, Workers: 1}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"reseller", "a", "a1", "b", "b1", "b2"}, names)
+
+	names, err = walkNames(t, clt, &kazooapi.WalkOptions{Order: kazooapi.BreadthFirst, Workers: 1}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"reseller", "a", "b", "a1", "b1", "b2"}, names)
+
+	//The root carries its own ancestors
+	err = clt.AccountsAPI.Walk(context.Background(), "5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00", nil, func(ctx context.Context, acc kazooapi.Descendant) error {
+		if acc.Name == "reseller" {
+			assert.Equal(t, []string{"fe0ade400015367f0069d6dfbdca072a"}, acc.Tree)
+			assert.Equal(t, "reseller.pbx.example.com", acc.Realm)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAccountsAPIService_WalkSkipAndErrors(t *testing.T) {
+	srv := MockResellerServer(t)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	names, err := walkNames(t, clt, &kazooapi.WalkOptions{Workers: 1}, func(ctx context.Context, acc kazooapi.Descendant) error {
+		if acc.Name == "b" {
+			return kazooapi.SkipSubtree
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"reseller", "a", "a1", "b"}, names)
+
+	errNoStorage := errors.New("no storage plan")
+	names, err = walkNames(t, clt, &kazooapi.WalkOptions{Workers: 3}, func(ctx context.Context, acc kazooapi.Descendant) error {
+		if acc.Name == "a1" || acc.Name == "b" {
+			return errNoStorage
+		}
+		return nil
+	})
+	assert.Len(t, names, 6, "errors don't stop the walk")
+
+	var walkErr *kazooapi.WalkError
+	if assert.True(t, errors.As(err, &walkErr)) {
+		assert.Len(t, walkErr.Errors, 2)
+		assert.Equal(t, errNoStorage, walkErr.Errors["a1000000000000000000000000000000"])
+		assert.Equal(t, errNoStorage, walkErr.Errors["b0000000000000000000000000000000"])
+		assert.NoError(t, walkErr.Err)
+	}
+	assert.True(t, errors.Is(err, errNoStorage))
+}
+
+func TestAccountsAPIService_WalkConcurrency(t *testing.T) {
+	srv := MockResellerServer(t)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	var running, peak int32
+	release := make(chan struct{})
+
+	go func() {
+		//Let the visitors pile up before releasing them
+		for atomic.LoadInt32(&running) < 2 {
+		}
+		close(release)
+	}()
+
+	names, err := walkNames(t, clt, &kazooapi.WalkOptions{Workers: 2}, func(ctx context.Context, acc kazooapi.Descendant) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+
+		if acc.Name != "reseller" {
+			<-release
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, names, 6)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+}
+
+func TestAccountsAPIService_WalkCancel(t *testing.T) {
+	srv := MockResellerServer(t)
+	defer srv.Close()
+	clt := newMockClient(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var visited []string
+	err := clt.AccountsAPI.Walk(ctx, "5ac2d0b1a4a0e8c1b8d8b0b4b36f7a00", &kazooapi.WalkOptions{Workers: 1}, func(ctx context.Context, acc kazooapi.Descendant) error {
+		visited = append(visited, acc.Name)
+		if acc.Name == "a" {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []string{"reseller", "a"}, visited)
+}